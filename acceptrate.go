@@ -0,0 +1,75 @@
+package graceful
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithAcceptRateLimit configure the rate of the connections accepted by every listener managed by
+// the Graceful instance to at most rate per second, with bursts of up to burst connections, so that
+// a connection storm queues in the kernel backlog rather than overloading the application. Once
+// draining, the rate drops to zero and the listeners stop accepting connections until they are
+// closed, an Accept in progress possibly accepting one more connection.
+func WithAcceptRateLimit(rate float64, burst int) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if rate <= 0 {
+			return nil, donothing, errors.New("invalid accept rate")
+		}
+		if burst < 1 {
+			return nil, donothing, errors.New("invalid accept burst")
+		}
+		g.acceptRate = rate
+		g.acceptBurst = burst
+		return nil, donothing, nil
+	})
+}
+
+// acceptRateListener limits the rate of the connections it accepts.
+type acceptRateListener struct {
+	net.Listener
+	g      *Graceful
+	bucket tokenBucket
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newAcceptRateListener returns a listener limiting the accept rate of l as configured on g.
+func newAcceptRateListener(g *Graceful, l net.Listener) *acceptRateListener {
+	return &acceptRateListener{Listener: l, g: g, closed: make(chan struct{})}
+}
+
+// Accept waits for the accept rate to allow the next connection, then accepts it. While draining,
+// it waits for the listener to be closed.
+func (l *acceptRateListener) Accept() (net.Conn, error) {
+	if l.g.draining.Load() {
+		<-l.closed
+		return nil, net.ErrClosed
+	}
+
+	if wait := l.bucket.reserve(1, l.g.acceptRate, float64(l.g.acceptBurst)); wait > 0 {
+		l.g.lock.Lock()
+		drainStarted := l.g.drainStarted
+		l.g.lock.Unlock()
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-drainStarted:
+			<-l.closed
+			return nil, net.ErrClosed
+		case <-l.closed:
+			return nil, net.ErrClosed
+		}
+	}
+	return l.Listener.Accept()
+}
+
+// Close closes the listener, interrupting a waiting Accept.
+func (l *acceptRateListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
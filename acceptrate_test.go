@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAcceptRateLimit(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8150"), WithAcceptRateLimit(10, 2))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	request := func() error {
+		conn, err := net.Dial("tcp", "127.0.0.1:8150")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(300 * time.Millisecond))
+		if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"); err != nil {
+			return err
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// The burst is accepted right away, the next connections at the rate.
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, request())
+	}
+	assert.Greater(t, time.Since(start), 150*time.Millisecond)
+
+	// Once draining, the connections wait in the backlog.
+	router.Drain()
+	assert.ErrorIs(t, request(), os.ErrDeadlineExceeded)
+	assert.NoError(t, router.Stop())
+
+	_, err = Default(WithAcceptRateLimit(0, 1))
+	assert.EqualError(t, err, "invalid accept rate")
+	_, err = Default(WithAcceptRateLimit(1, 0))
+	assert.EqualError(t, err, "invalid accept burst")
+}
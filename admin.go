@@ -0,0 +1,139 @@
+package graceful
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminConfig configures the admin server enabled by WithAdmin.
+type AdminConfig struct {
+	// Addr is the address the admin server listens on.
+	Addr string `json:"addr"`
+	// Token is the shared secret expected in an "Authorization: Bearer <token>" request header.
+	// Being a secret, it is not serialized.
+	Token string `json:"-"`
+	// TLSConfig enables TLS on the admin server. Requests are authenticated by mutual TLS when
+	// ClientAuth is tls.RequireAndVerifyClientCert. It is not serialized.
+	TLSConfig *tls.Config `json:"-"`
+}
+
+// WithAdmin configure an admin server, named "admin", exposing endpoints for deployment tooling:
+//
+//   - POST /drain drains the Graceful instance, see Drain.
+//   - POST /shutdown gracefully shuts down every server, including the admin server.
+//
+// Both answer with a JSON body describing the resulting state. Requests must be authenticated
+// with the configured token, or with a client certificate when mutual TLS is configured.
+func WithAdmin(cfg AdminConfig) Option {
+	mutualTLS := cfg.TLSConfig != nil && cfg.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert
+	if cfg.Token == "" && !mutualTLS {
+		return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+			return nil, donothing, errors.New("admin server requires a token or mutual tls")
+		})
+	}
+
+	return WithComponent("admin", optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := validateAddr(cfg.Addr); err != nil {
+			return nil, donothing, err
+		}
+		fallback := ":http"
+		if cfg.TLSConfig != nil {
+			fallback = ":https"
+			g.checkCertificates(func() []tls.Certificate { return cfg.TLSConfig.Certificates })
+		}
+		if err := g.claimTCP(cfg.Addr, fallback); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", cfg.Addr, fallback)
+		return func(ctx context.Context, s *managedServer) error {
+			srv := &http.Server{
+				Handler:           g.adminHandler(cfg.Token),
+				TLSConfig:         cfg.TLSConfig,
+				ReadHeaderTimeout: defaultReadHeaderTimeout,
+			}
+			s.setServer(srv)
+
+			if cfg.TLSConfig == nil {
+				l, err := g.listenTCP(ctx, s, cfg.Addr, ":http")
+				if err != nil {
+					return err
+				}
+				return srv.Serve(l)
+			}
+
+			l, err := g.listenTCP(ctx, s, cfg.Addr, ":https")
+			if err != nil {
+				return err
+			}
+			return s.serveTLS(srv, l)
+		}, donothing, nil
+	}))
+}
+
+// adminHandler returns the handler of the admin server.
+func (g *Graceful) adminHandler(token string) http.Handler {
+	engine := gin.New()
+	engine.Use(g.Recovery(), adminAuth(token))
+
+	engine.POST("/drain", func(c *gin.Context) {
+		g.Drain()
+		c.JSON(http.StatusOK, g.adminState())
+	})
+	engine.POST("/shutdown", func(c *gin.Context) {
+		g.Drain()
+		c.JSON(http.StatusAccepted, g.adminState())
+		g.cancelRun("admin")
+	})
+
+	return engine
+}
+
+// adminAuth returns a middleware checking the admin token, if any. Without a token, requests
+// are authenticated by the mutual TLS handshake.
+func adminAuth(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+
+		got := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		}
+	}
+}
+
+// adminState describes the state of the Graceful instance in the admin responses.
+func (g *Graceful) adminState() gin.H {
+	statuses := g.ServerStatus()
+	servers := make([]gin.H, 0, len(statuses))
+	for _, status := range statuses {
+		server := gin.H{
+			"name":  status.Name,
+			"addr":  status.Addr,
+			"state": status.State.String(),
+		}
+		if status.Label != "" {
+			server["label"] = status.Label
+		}
+		if status.Err != nil {
+			server["error"] = status.Err.Error()
+		}
+		if g.trafficStats {
+			server["bytes_read"] = status.BytesRead
+			server["bytes_written"] = status.BytesWritten
+		}
+		servers = append(servers, server)
+	}
+
+	return gin.H{
+		"draining": g.Draining(),
+		"servers":  servers,
+	}
+}
@@ -0,0 +1,101 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAdmin(t *testing.T) {
+	router, err := Default(
+		WithAddr(":8091"),
+		WithAdmin(AdminConfig{Addr: ":8092", Token: "secret"}),
+		WithHealthEndpoints(),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	testRequest(t, "http://localhost:8091/example")
+
+	code, _ := adminRequest(t, "/drain", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = adminRequest(t, "/drain", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.False(t, router.Draining())
+
+	code, body := adminRequest(t, "/drain", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["draining"])
+	if servers, ok := body["servers"].([]any); assert.True(t, ok) && assert.Len(t, servers, 2) {
+		assert.Equal(t, "serving", servers[0].(map[string]any)["state"])
+		assert.Equal(t, "admin", servers[1].(map[string]any)["name"])
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8091/example", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		resp.Body.Close()
+	}
+	code, body = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", body["servers"])
+
+	code, _ = adminRequest(t, "/shutdown", "secret")
+	assert.Equal(t, http.StatusAccepted, code)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("admin shutdown did not stop the run")
+	}
+}
+
+func TestWithAdminRequiresAuthentication(t *testing.T) {
+	_, err := Default(WithAdmin(AdminConfig{Addr: ":8092"}))
+	assert.EqualError(t, err, "admin server requires a token or mutual tls")
+
+	_, err = Default(WithAdmin(AdminConfig{Addr: ":8092", TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}))
+	assert.Error(t, err)
+
+	_, err = Default(WithAdmin(AdminConfig{
+		Addr:      ":8092",
+		TLSConfig: &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS12},
+	}))
+	assert.NoError(t, err)
+}
+
+// adminRequest posts to the given path of the admin server and decodes the JSON response body.
+func adminRequest(t *testing.T, path, token string) (int, map[string]any) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost:8092"+path, nil)
+	assert.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
@@ -0,0 +1,93 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// agentWriteTimeout bounds the time spent answering one agent check.
+const agentWriteTimeout = 5 * time.Second
+
+// WithHAProxyAgent configure a TCP responder, named "haproxy-agent", listening on the given
+// address for the agent checks of L4 load balancers such as HAProxy. Every connection is
+// answered with one line describing the state of the instance, then closed:
+//
+//   - up once every server is serving, the warmup is complete and the health checks pass.
+//   - drain while draining, shutting down or in maintenance, so that no new connection is routed
+//     to the instance while the in-flight ones complete.
+//   - down otherwise.
+//
+// The responder shuts down along with the servers, in the order they are configured, so it
+// should be configured after them to answer drain while they drain.
+func WithHAProxyAgent(addr string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := validateAddr(addr); err != nil {
+			return nil, donothing, err
+		}
+		if err := g.claimTCP(addr, ""); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", addr, "")
+		return WithRunner("haproxy-agent", RunnerFunc(func(ctx context.Context) error {
+			return g.serveAgent(ctx, addr)
+		})).apply(g)
+	})
+}
+
+// serveAgent answers the agent checks on the given address until ctx is canceled.
+func (g *Graceful) serveAgent(ctx context.Context, addr string) error {
+	l, err := g.listenConfig.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+	})
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.handleAgent(ctx, conn)
+		}()
+	}
+}
+
+// handleAgent answers one agent check on conn.
+func (g *Graceful) handleAgent(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(agentWriteTimeout))
+	_, _ = io.WriteString(conn, g.agentState(ctx)+"\n")
+}
+
+// agentState returns the state of the instance reported to the agent checks.
+func (g *Graceful) agentState(ctx context.Context) string {
+	if g.Draining() || g.InMaintenance() {
+		return "drain"
+	}
+	if !g.serving() || !g.warm.Load() {
+		return "down"
+	}
+	if healthy, _ := g.runHealthChecks(ctx); !healthy {
+		return "down"
+	}
+
+	return "up"
+}
@@ -0,0 +1,60 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// agentCheck returns the state reported by the agent listening on addr.
+func agentCheck(t *testing.T, addr string) string {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer conn.Close()
+
+	state, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	return string(state)
+}
+
+func TestWithHAProxyAgent(t *testing.T) {
+	router, err := Default(WithAddr(":8112"), WithHAProxyAgent("localhost:8113"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var unhealthy atomic.Bool
+	router.AddHealthCheck("dependency", func(context.Context) error {
+		if unhealthy.Load() {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	assert.NoError(t, router.Start())
+	defer func() { assert.NoError(t, router.Stop()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, "up\n", agentCheck(t, "localhost:8113"))
+
+	unhealthy.Store(true)
+	assert.Equal(t, "down\n", agentCheck(t, "localhost:8113"))
+	unhealthy.Store(false)
+
+	router.SetMaintenance(true)
+	assert.Equal(t, "drain\n", agentCheck(t, "localhost:8113"))
+	router.SetMaintenance(false)
+	assert.Equal(t, "up\n", agentCheck(t, "localhost:8113"))
+
+	router.Drain()
+	assert.Equal(t, "drain\n", agentCheck(t, "localhost:8113"))
+}
@@ -0,0 +1,151 @@
+package graceful
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// bandwidthChunk is the largest write throttled at once, so that a large response is paced
+// rather than written in a burst after a long wait.
+const bandwidthChunk = 16 << 10
+
+// BandwidthLimit bounds the bytes per second written to the connections of the listeners managed
+// by the Graceful instance, see WithBandwidthLimit. Zero means no limit.
+type BandwidthLimit struct {
+	// Total bounds the bytes per second written over every connection.
+	Total int64
+	// PerConn bounds the bytes per second written to each connection.
+	PerConn int64
+	// DrainTotal and DrainPerConn replace Total and PerConn while draining, e.g. to leave the
+	// egress to the other replicas, zero keeping the limit of the running instance.
+	DrainTotal   int64
+	DrainPerConn int64
+}
+
+// WithBandwidthLimit configure the bandwidth limit of the connections of every listener managed by
+// the Graceful instance, so that a misbehaving client cannot saturate the egress. The writes wait
+// for the bandwidth they use, each limit allowing bursts of up to one second of traffic.
+func WithBandwidthLimit(limit BandwidthLimit) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if limit.Total < 0 || limit.PerConn < 0 || limit.DrainTotal < 0 || limit.DrainPerConn < 0 {
+			return nil, donothing, errors.New("invalid bandwidth limit")
+		}
+		g.bandwidth = &bandwidth{limit: limit}
+		return nil, donothing, nil
+	})
+}
+
+// bandwidth is the bandwidth limit of the Graceful instance, with the budget shared by every
+// connection.
+type bandwidth struct {
+	limit BandwidthLimit
+	total tokenBucket
+}
+
+// rates returns the total and per connection limits, depending on whether the instance drains.
+func (b *bandwidth) rates(draining bool) (int64, int64) {
+	total, perConn := b.limit.Total, b.limit.PerConn
+	if draining {
+		if b.limit.DrainTotal > 0 {
+			total = b.limit.DrainTotal
+		}
+		if b.limit.DrainPerConn > 0 {
+			perConn = b.limit.DrainPerConn
+		}
+	}
+	return total, perConn
+}
+
+// tokenBucket is a token bucket, refilled at the rate given to each reservation and holding up to
+// its burst. The tokens go negative to reserve the tokens not yet available.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes n tokens from the bucket refilled at rate tokens per second up to burst, and
+// returns how long to wait before using them. A zero rate never waits.
+func (b *tokenBucket) reserve(n int, rate, burst float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// bandwidthListener limits the bandwidth of the connections it accepts.
+type bandwidthListener struct {
+	net.Listener
+	g *Graceful
+}
+
+// Accept waits for and returns the next connection, with its bandwidth limited.
+func (l *bandwidthListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &bandwidthConn{Conn: conn, g: l.g}, nil
+}
+
+// bandwidthConn is a connection whose writes wait for the bandwidth they use.
+type bandwidthConn struct {
+	net.Conn
+	g      *Graceful
+	bucket tokenBucket
+}
+
+// Write writes b to the connection in chunks, each waiting for the bandwidth it uses.
+func (c *bandwidthConn) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > bandwidthChunk {
+			chunk = chunk[:bandwidthChunk]
+		}
+
+		limit := c.g.bandwidth
+		total, perConn := limit.rates(c.g.draining.Load())
+		wait := limit.total.reserve(len(chunk), float64(total), float64(total))
+		if d := c.bucket.reserve(len(chunk), float64(perConn), float64(perConn)); d > wait {
+			wait = d
+		}
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+
+		n, err := c.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// Unwrap returns the wrapped connection.
+func (c *bandwidthConn) Unwrap() net.Conn {
+	return c.Conn
+}
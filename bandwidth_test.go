@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var b tokenBucket
+	assert.Zero(t, b.reserve(1000, 0, 0))
+	// The first reservation gets the burst.
+	assert.Zero(t, b.reserve(1000, 1000, 1000))
+	wait := b.reserve(500, 1000, 1000)
+	assert.InDelta(t, 500*time.Millisecond, wait, float64(10*time.Millisecond))
+
+	limit := bandwidth{limit: BandwidthLimit{Total: 100, PerConn: 10, DrainPerConn: 1}}
+	total, perConn := limit.rates(false)
+	assert.Equal(t, []int64{100, 10}, []int64{total, perConn})
+	total, perConn = limit.rates(true)
+	assert.Equal(t, []int64{100, 1}, []int64{total, perConn})
+}
+
+func TestWithBandwidthLimit(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8146"), WithBandwidthLimit(BandwidthLimit{PerConn: 100 << 10}))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/large", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("a", 150<<10))
+	})
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://127.0.0.1:8146/large", nil)
+	assert.NoError(t, err)
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		n, _ := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, int64(150<<10), n)
+	}
+	// The first 100 KiB are the burst, the next 50 KiB take half a second.
+	assert.Greater(t, time.Since(start), 400*time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	_, err = Default(WithBandwidthLimit(BandwidthLimit{Total: -1}))
+	assert.EqualError(t, err, "invalid bandwidth limit")
+}
@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// BindError is returned when a listener cannot be bound. If the address is already in use, it
+// tells which process holds the port, or whether the port is only held by connections in the
+// TIME_WAIT state, where the platform allows it.
+type BindError struct {
+	// Network and Addr are the network and address of the listener.
+	Network string
+	Addr    string
+	// Err is the error returned by the listen call.
+	Err error
+	// PID and Process identify the process listening on the port, if known.
+	PID     int
+	Process string
+	// TimeWait reports whether connections on the port are in the TIME_WAIT state.
+	TimeWait bool
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	msg := fmt.Sprintf("bind %s %s: %v", e.Network, e.Addr, e.Err)
+	switch {
+	case e.PID > 0:
+		msg += fmt.Sprintf(" (held by %s, pid %d)", e.Process, e.PID)
+	case e.TimeWait:
+		msg += " (connections in TIME_WAIT)"
+	}
+	return msg
+}
+
+// Unwrap returns the error returned by the listen call.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrBindFailed.
+func (e *BindError) Is(target error) bool {
+	return target == ErrBindFailed
+}
+
+// bindError returns a *BindError for the failure to bind a listener on the given network and
+// address, with a diagnosis of the port owner if the address is already in use.
+func bindError(network, addr string, err error) error {
+	e := &BindError{Network: network, Addr: addr, Err: err}
+	if network == "tcp" && errors.Is(err, syscall.EADDRINUSE) {
+		if _, port, err := splitTCPAddr(addr); err == nil {
+			e.PID, e.Process, e.TimeWait = diagnosePort(port)
+		}
+	}
+	return e
+}
@@ -0,0 +1,87 @@
+package graceful
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The states of a TCP socket in /proc/net/tcp.
+const (
+	procTCPListen   = "0A"
+	procTCPTimeWait = "06"
+)
+
+// diagnosePort returns the process listening on the given TCP port, if visible to the current
+// user, and whether connections on the port are in the TIME_WAIT state.
+func diagnosePort(port int) (int, string, bool) {
+	var inodes []string
+	timeWait := false
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		listening, waiting := scanProcTCP(table, port)
+		inodes = append(inodes, listening...)
+		timeWait = timeWait || waiting
+	}
+
+	for _, inode := range inodes {
+		if pid, process := socketOwner(inode); pid > 0 {
+			return pid, process, timeWait
+		}
+	}
+	return 0, "", timeWait
+}
+
+// scanProcTCP returns the inodes of the sockets listening on port in the given /proc/net/tcp
+// table, and whether a connection on port is in the TIME_WAIT state.
+func scanProcTCP(table string, port int) ([]string, bool) {
+	f, err := os.Open(table)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var inodes []string
+	timeWait := false
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		i := strings.LastIndexByte(fields[1], ':')
+		if p, err := strconv.ParseInt(fields[1][i+1:], 16, 32); err != nil || int(p) != port {
+			continue
+		}
+		switch fields[3] {
+		case procTCPListen:
+			inodes = append(inodes, fields[9])
+		case procTCPTimeWait:
+			timeWait = true
+		}
+	}
+	return inodes, timeWait
+}
+
+// socketOwner returns the process with an open file descriptor on the socket with the given
+// inode, if visible to the current user.
+func socketOwner(inode string) (int, string) {
+	link := "socket:[" + inode + "]"
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		if target, err := os.Readlink(fd); err != nil || target != link {
+			continue
+		}
+		dir := filepath.Dir(filepath.Dir(fd))
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+		return pid, strings.TrimSpace(string(comm))
+	}
+	return 0, ""
+}
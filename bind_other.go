@@ -0,0 +1,8 @@
+//go:build !linux
+
+package graceful
+
+// diagnosePort does not diagnose the ports on this platform.
+func diagnosePort(int) (int, string, bool) {
+	return 0, "", false
+}
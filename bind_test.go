@@ -0,0 +1,44 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindError(t *testing.T) {
+	listener, err := net.Listen("tcp", ":8117")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	router, err := Default(WithAddr(":8117"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	err = router.RunWithContext(context.Background())
+	var bindErr *BindError
+	if !assert.ErrorAs(t, err, &bindErr) {
+		return
+	}
+	assert.True(t, errors.Is(err, syscall.EADDRINUSE))
+	assert.ErrorIs(t, err, ErrBindFailed)
+	assert.NotErrorIs(t, err, ErrServeFailed)
+	assert.Equal(t, "tcp", bindErr.Network)
+	assert.Equal(t, ":8117", bindErr.Addr)
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	comm, err := os.ReadFile("/proc/self/comm")
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), bindErr.PID)
+	assert.Equal(t, strings.TrimSpace(string(comm)), bindErr.Process)
+	assert.Contains(t, bindErr.Error(), "address already in use (held by ")
+}
@@ -0,0 +1,145 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// The phases of a shutdown a ShutdownBudget is partitioned between.
+const (
+	budgetBefore = iota
+	budgetDrain
+	budgetAfter
+)
+
+// ShutdownBudget partitions one overall shutdown deadline, e.g. the termination grace period of
+// Kubernetes, between the phases of the shutdown. Each phase is allotted its fraction of Total,
+// and the phases after one that overran are shrunk in proportion to their fractions so that the
+// whole shutdown still ends by the deadline.
+type ShutdownBudget struct {
+	// Total is the overall time the shutdown may take. The shutdown context deadline, when earlier,
+	// takes precedence.
+	Total time.Duration `json:"total"`
+	// BeforeShutdown is the fraction of Total allotted to the deregistration and the hooks
+	// configured with WithBeforeShutdown.
+	BeforeShutdown float64 `json:"before_shutdown,omitempty"`
+	// Drain is the fraction of Total allotted to the drain of the servers.
+	Drain float64 `json:"drain,omitempty"`
+	// AfterShutdown is the fraction of Total allotted to the hooks configured with WithAfterShutdown.
+	AfterShutdown float64 `json:"after_shutdown,omitempty"`
+}
+
+// MarshalJSON encodes b with Total in its string form, e.g. "1.5s".
+func (b ShutdownBudget) MarshalJSON() ([]byte, error) {
+	type plain ShutdownBudget
+	return json.Marshal(struct {
+		plain
+		Total duration `json:"total"`
+	}{plain: plain(b), Total: duration(b.Total)})
+}
+
+// UnmarshalJSON decodes b with Total in its string form, see time.ParseDuration.
+func (b *ShutdownBudget) UnmarshalJSON(data []byte) error {
+	type plain ShutdownBudget
+	v := struct {
+		*plain
+		Total duration `json:"total"`
+	}{plain: (*plain)(b), Total: duration(b.Total)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	b.Total = time.Duration(v.Total)
+	return nil
+}
+
+// WithShutdownBudget configure the budget the shutdown deadline is partitioned with. The fractions
+// of the budget must not add up to more than 1, the rest being left unallotted as a safety margin.
+func WithShutdownBudget(budget ShutdownBudget) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if budget.Total <= 0 {
+			return nil, donothing, errors.New("shutdown budget: non-positive total")
+		}
+		fractions := budget.fractions()
+		sum := 0.0
+		for _, f := range fractions {
+			if f < 0 {
+				return nil, donothing, errors.New("shutdown budget: negative fraction")
+			}
+			sum += f
+		}
+		if sum <= 0 || sum > 1+1e-9 {
+			return nil, donothing, errors.New("shutdown budget: fractions must add up to at most 1")
+		}
+		g.budget = &budget
+		return nil, donothing, nil
+	})
+}
+
+// fractions returns the fraction of Total allotted to every phase, in order.
+func (b ShutdownBudget) fractions() [3]float64 {
+	return [3]float64{b.BeforeShutdown, b.Drain, b.AfterShutdown}
+}
+
+// budgetPlan is the partition of one shutdown between its phases.
+type budgetPlan struct {
+	budget   ShutdownBudget
+	deadline time.Time
+}
+
+// planShutdown returns the partition of the shutdown started at start with ctx, nil without a
+// configured budget.
+func (g *Graceful) planShutdown(ctx context.Context, start time.Time) *budgetPlan {
+	if g.budget == nil {
+		return nil
+	}
+	deadline := start.Add(g.budget.Total)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return &budgetPlan{budget: *g.budget, deadline: deadline}
+}
+
+// allot returns the time allotted to the given phase starting now: its fraction of the total, or
+// less if the previous phases overran, out of the time left until the deadline shared between the
+// remaining phases in proportion to their fractions.
+func (p *budgetPlan) allot(phase int, now time.Time) time.Duration {
+	fractions := p.budget.fractions()
+	rest := 0.0
+	for _, f := range fractions[phase:] {
+		rest += f
+	}
+	left := p.deadline.Sub(now)
+	if rest <= 0 || left <= 0 {
+		return 0
+	}
+
+	d := time.Duration(float64(p.budget.Total) * fractions[phase])
+	if share := time.Duration(float64(left) * fractions[phase] / rest); share < d {
+		d = share
+	}
+	return d
+}
+
+// context returns ctx bounded by the time allotted to the given phase, ctx itself without a plan.
+func (p *budgetPlan) context(ctx context.Context, phase int) (context.Context, context.CancelFunc) {
+	if p == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.allot(phase, time.Now()))
+}
+
+// afterContext returns the context the after hooks run with. Unlike ctx it is not canceled, e.g.
+// once the drain gave up, but it is bounded by the time left in the budget or, without a budget,
+// by the deadline of ctx, so that the hooks can adapt their own timeouts to the kill deadline.
+func (p *budgetPlan) afterContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if p != nil {
+		return p.context(detached, budgetAfter)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return detached, func() {}
+}
@@ -0,0 +1,61 @@
+package graceful
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownBudgetAllot(t *testing.T) {
+	start := time.Now()
+	plan := &budgetPlan{
+		budget:   ShutdownBudget{Total: 30 * time.Second, BeforeShutdown: 0.2, Drain: 0.6, AfterShutdown: 0.2},
+		deadline: start.Add(30 * time.Second),
+	}
+	assert.Equal(t, 6*time.Second, plan.allot(budgetBefore, start))
+	assert.Equal(t, 18*time.Second, plan.allot(budgetDrain, start.Add(6*time.Second)))
+	assert.Equal(t, 6*time.Second, plan.allot(budgetAfter, start.Add(24*time.Second)))
+
+	// The before phase overran by 4s: the drain and the after phase share the 20s left.
+	assert.Equal(t, 15*time.Second, plan.allot(budgetDrain, start.Add(10*time.Second)))
+	assert.Equal(t, 5*time.Second, plan.allot(budgetAfter, start.Add(25*time.Second)))
+	assert.Equal(t, time.Duration(0), plan.allot(budgetAfter, start.Add(31*time.Second)))
+}
+
+func TestWithShutdownBudget(t *testing.T) {
+	var remaining time.Duration
+	router, err := Default(
+		WithAddr(":8122"),
+		WithShutdownBudget(ShutdownBudget{Total: time.Second, BeforeShutdown: 0.5, Drain: 0.3, AfterShutdown: 0.2}),
+		WithBeforeShutdown("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}),
+		WithAfterShutdown("flush", func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+
+	// The shutdown context leaves 600ms: the before hook uses its 300ms share, leaving the after
+	// hook its full 200ms.
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.NoError(t, router.Shutdown(ctx))
+	assert.InDelta(t, 300*time.Millisecond, time.Since(start), float64(100*time.Millisecond))
+	assert.InDelta(t, 200*time.Millisecond, remaining, float64(50*time.Millisecond))
+
+	_, err = Default(WithShutdownBudget(ShutdownBudget{Total: time.Second, Drain: 1.5}))
+	assert.EqualError(t, err, "shutdown budget: fractions must add up to at most 1")
+	_, err = Default(WithShutdownBudget(ShutdownBudget{Drain: 1}))
+	assert.EqualError(t, err, "shutdown budget: non-positive total")
+}
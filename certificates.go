@@ -0,0 +1,106 @@
+package graceful
+
+import (
+	"crypto/tls"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// certificateFiles is a certificate loaded from a certificate and a key file, which can be
+// reloaded while serving.
+type certificateFiles struct {
+	mu       sync.Mutex
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// load loads the certificate files. The previous certificate is kept if loading fails.
+func (c *certificateFiles) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.cert.Store(&cert)
+	return nil
+}
+
+// replace loads the certificate from other files, which are loaded from then on. The previous
+// certificate and files are kept if loading fails.
+func (c *certificateFiles) replace(certFile, keyFile string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.certFile, c.keyFile = certFile, keyFile
+	c.cert.Store(&cert)
+	return nil
+}
+
+// getCertificate implements tls.Config.GetCertificate.
+func (c *certificateFiles) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// certificateSet is a set of certificates, selected through SNI, which can be replaced while
+// serving.
+type certificateSet struct {
+	certs atomic.Pointer[[]tls.Certificate]
+}
+
+// newCertificateSet returns a set of the given certificates.
+func newCertificateSet(certs []tls.Certificate) *certificateSet {
+	c := &certificateSet{}
+	c.store(certs)
+	return c
+}
+
+// store replaces the certificates of the set.
+func (c *certificateSet) store(certs []tls.Certificate) {
+	certs = append([]tls.Certificate(nil), certs...)
+	c.certs.Store(&certs)
+}
+
+// certificates returns the certificates of the set.
+func (c *certificateSet) certificates() []tls.Certificate {
+	return *c.certs.Load()
+}
+
+// getCertificate implements tls.Config.GetCertificate, returning the first certificate
+// supported by the client, or else the first certificate.
+func (c *certificateSet) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certs := c.certificates()
+	for i := range certs {
+		if hello.SupportsCertificate(&certs[i]) == nil {
+			return &certs[i], nil
+		}
+	}
+	return &certs[0], nil
+}
+
+// ReloadCertificates reloads the certificate and key files of every server configured with
+// WithTLS, so that renewed certificates are served without a restart. Servers whose files fail
+// to load keep their current certificate, and the errors are returned.
+func (g *Graceful) ReloadCertificates() error {
+	g.lock.Lock()
+	certificates := append([]*certificateFiles(nil), g.certificates...)
+	g.lock.Unlock()
+
+	var errs []error
+	for _, c := range certificates {
+		if err := c.load(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
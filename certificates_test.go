@@ -0,0 +1,51 @@
+package graceful
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	copyFile(t, "./testdata/certificate/cert.pem", certFile)
+	copyFile(t, "./testdata/certificate/key.pem", keyFile)
+
+	router, err := Default(WithTLS(":8443", certFile, keyFile))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.ReloadCertificates())
+	assert.Equal(t, []string{"localhost"}, servedNames(t, router))
+
+	copyFile(t, "./testdata/certificate/example-cert.pem", certFile)
+	copyFile(t, "./testdata/certificate/example-key.pem", keyFile)
+	assert.NoError(t, router.ReloadCertificates())
+	assert.Equal(t, []string{"example.com"}, servedNames(t, router))
+
+	assert.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0o600))
+	assert.Error(t, router.ReloadCertificates())
+	assert.Equal(t, []string{"example.com"}, servedNames(t, router))
+}
+
+// servedNames returns the DNS names of the certificate served by the first WithTLS server.
+func servedNames(t *testing.T, g *Graceful) []string {
+	cert, err := g.certificates[0].getCertificate(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return leaf.DNSNames
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	data, err := os.ReadFile(src)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dst, data, 0o600))
+}
@@ -0,0 +1,153 @@
+package graceful
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config is a plain description of a Graceful instance, easy to build from, and serialize to, the
+// configuration of an application, as an alternative to the functional options it is translated
+// into by NewFromConfig.
+type Config struct {
+	// Listeners are the addresses served over HTTP, see WithAddr.
+	Listeners []string `json:"listeners,omitempty"`
+	// TLS are the addresses served over HTTPS, see WithTLS.
+	TLS []TLSListenerConfig `json:"tls,omitempty"`
+	// Timeouts are the timeouts of the servers and of the shutdown.
+	Timeouts TimeoutsConfig `json:"timeouts"`
+	// Hooks are the shutdown hooks. Being functions, they are not serialized.
+	Hooks HooksConfig `json:"-"`
+	// Admin enables the admin server, see WithAdmin.
+	Admin *AdminConfig `json:"admin,omitempty"`
+}
+
+// TLSListenerConfig is an address served over HTTPS with the certificate and key loaded from files.
+type TLSListenerConfig struct {
+	Addr     string `json:"addr"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// TimeoutsConfig are the timeouts of the http.Servers, zero keeping the default of the servers,
+// and the shutdown budget, if any.
+type TimeoutsConfig struct {
+	ReadHeader time.Duration   `json:"read_header,omitempty"`
+	Read       time.Duration   `json:"read,omitempty"`
+	Write      time.Duration   `json:"write,omitempty"`
+	Idle       time.Duration   `json:"idle,omitempty"`
+	Shutdown   *ShutdownBudget `json:"shutdown,omitempty"`
+}
+
+// MarshalJSON encodes t with the timeouts in their string form, e.g. "1.5s".
+func (t TimeoutsConfig) MarshalJSON() ([]byte, error) {
+	type plain TimeoutsConfig
+	return json.Marshal(struct {
+		plain
+		ReadHeader duration `json:"read_header,omitempty"`
+		Read       duration `json:"read,omitempty"`
+		Write      duration `json:"write,omitempty"`
+		Idle       duration `json:"idle,omitempty"`
+	}{
+		plain:      plain(t),
+		ReadHeader: duration(t.ReadHeader),
+		Read:       duration(t.Read),
+		Write:      duration(t.Write),
+		Idle:       duration(t.Idle),
+	})
+}
+
+// UnmarshalJSON decodes t with the timeouts in their string form, see time.ParseDuration.
+func (t *TimeoutsConfig) UnmarshalJSON(data []byte) error {
+	type plain TimeoutsConfig
+	v := struct {
+		*plain
+		ReadHeader duration `json:"read_header,omitempty"`
+		Read       duration `json:"read,omitempty"`
+		Write      duration `json:"write,omitempty"`
+		Idle       duration `json:"idle,omitempty"`
+	}{
+		plain:      (*plain)(t),
+		ReadHeader: duration(t.ReadHeader),
+		Read:       duration(t.Read),
+		Write:      duration(t.Write),
+		Idle:       duration(t.Idle),
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.ReadHeader = time.Duration(v.ReadHeader)
+	t.Read = time.Duration(v.Read)
+	t.Write = time.Duration(v.Write)
+	t.Idle = time.Duration(v.Idle)
+	return nil
+}
+
+// HooksConfig are the hooks run during the shutdown, in order.
+type HooksConfig struct {
+	// BeforeShutdown are the hooks configured with WithBeforeShutdown.
+	BeforeShutdown []NamedHook
+	// AfterShutdown are the hooks configured with WithAfterShutdown.
+	AfterShutdown []NamedHook
+}
+
+// NamedHook is a shutdown hook with the name it is reported under.
+type NamedHook struct {
+	Name string
+	Hook Hook
+}
+
+// NewFromConfig creates a new Graceful instance serving engine as described by cfg, like New with
+// the options cfg translates into.
+func NewFromConfig(engine *gin.Engine, cfg Config) (*Graceful, error) {
+	return New(engine, cfg.options()...)
+}
+
+// options returns the options cfg translates into.
+func (cfg Config) options() []Option {
+	var opts []Option
+	for _, addr := range cfg.Listeners {
+		opts = append(opts, WithAddr(addr))
+	}
+	for _, l := range cfg.TLS {
+		opts = append(opts, WithTLS(l.Addr, l.CertFile, l.KeyFile))
+	}
+	opts = append(opts, withServerTimeouts(cfg.Timeouts))
+	if cfg.Timeouts.Shutdown != nil {
+		opts = append(opts, WithShutdownBudget(*cfg.Timeouts.Shutdown))
+	}
+	for _, h := range cfg.Hooks.BeforeShutdown {
+		opts = append(opts, WithBeforeShutdown(h.Name, h.Hook))
+	}
+	for _, h := range cfg.Hooks.AfterShutdown {
+		opts = append(opts, WithAfterShutdown(h.Name, h.Hook))
+	}
+	if cfg.Admin != nil {
+		opts = append(opts, WithAdmin(*cfg.Admin))
+	}
+	return opts
+}
+
+// withServerTimeouts configure the non-zero timeouts of t on every http.Server of the Graceful
+// instance.
+func withServerTimeouts(t TimeoutsConfig) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.ConfigureServers(func(srv *http.Server) {
+			if t.ReadHeader > 0 {
+				srv.ReadHeaderTimeout = t.ReadHeader
+			}
+			if t.Read > 0 {
+				srv.ReadTimeout = t.Read
+			}
+			if t.Write > 0 {
+				srv.WriteTimeout = t.Write
+			}
+			if t.Idle > 0 {
+				srv.IdleTimeout = t.Idle
+			}
+		})
+		return nil, donothing, nil
+	})
+}
@@ -0,0 +1,73 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	var cfg Config
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"listeners": ["127.0.0.1:8135"],
+		"timeouts": {"read": "2s", "shutdown": {"total": "1s", "drain": 1}}
+	}`), &cfg))
+
+	var ran []string
+	cfg.Hooks.BeforeShutdown = []NamedHook{{Name: "flag", Hook: func(context.Context) error {
+		ran = append(ran, "flag")
+		return nil
+	}}}
+
+	engine := gin.New()
+	engine.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+	router, err := NewFromConfig(engine, cfg)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	srv := &http.Server{}
+	router.configureServer(srv)
+	assert.Equal(t, 2*time.Second, srv.ReadTimeout)
+	assert.Zero(t, srv.WriteTimeout)
+	assert.Equal(t, &ShutdownBudget{Total: time.Second, Drain: 1}, router.budget)
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, "http://127.0.0.1:8135/example") },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.Equal(t, []string{"flag"}, ran)
+
+	_, err = NewFromConfig(gin.New(), Config{Timeouts: TimeoutsConfig{Shutdown: &ShutdownBudget{}}})
+	assert.EqualError(t, err, "shutdown budget: non-positive total")
+	_, err = NewFromConfig(gin.New(), Config{Admin: &AdminConfig{Addr: ":8136"}})
+	assert.EqualError(t, err, "admin server requires a token or mutual tls")
+}
+
+func TestConfigJSON(t *testing.T) {
+	cfg := Config{
+		Listeners: []string{":8080"},
+		TLS:       []TLSListenerConfig{{Addr: ":8443", CertFile: "cert.pem", KeyFile: "key.pem"}},
+		Timeouts:  TimeoutsConfig{Read: 2 * time.Second, Shutdown: &ShutdownBudget{Total: 1500 * time.Millisecond, Drain: 1}},
+		Hooks:     HooksConfig{AfterShutdown: []NamedHook{{Name: "flush"}}},
+		Admin:     &AdminConfig{Addr: ":9000", Token: "secret"},
+	}
+	data, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"listeners": [":8080"],
+		"tls": [{"addr": ":8443", "cert_file": "cert.pem", "key_file": "key.pem"}],
+		"timeouts": {"read": "2s", "shutdown": {"total": "1.5s", "drain": 1}},
+		"admin": {"addr": ":9000"}
+	}`, string(data))
+
+	var decoded Config
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, cfg.Timeouts, decoded.Timeouts)
+	assert.Equal(t, &AdminConfig{Addr: ":9000"}, decoded.Admin)
+	assert.Error(t, json.Unmarshal([]byte(`{"timeouts": {"read": 2000000000}}`), &decoded))
+}
@@ -0,0 +1,62 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net"
+)
+
+// Lifecycle is the view of the Graceful instance available from the request contexts, so that
+// code deep in the call stack of a handler can query the lifecycle state, or hook into it,
+// without the instance being threaded through its constructors.
+type Lifecycle interface {
+	// Draining reports whether the instance is draining or shutting down.
+	Draining() bool
+	// InMaintenance reports whether the instance is in maintenance mode.
+	InMaintenance() bool
+	// RegisterWakeup registers fn to be invoked once the drain starts, see Graceful.RegisterWakeup.
+	RegisterWakeup(fn func()) func()
+	// RegisterCloser registers c to be closed by Close, see Graceful.RegisterCloser.
+	RegisterCloser(c io.Closer)
+}
+
+var _ Lifecycle = (*Graceful)(nil)
+
+// FromContext returns the Lifecycle of the Graceful instance serving the request of ctx, or running
+// the Runner ctx was given to. It reports false if ctx comes from neither.
+func FromContext(ctx context.Context) (Lifecycle, bool) {
+	g, ok := ctx.Value(gracefulKey{}).(*Graceful)
+	return g, ok
+}
+
+// baseContext returns an http.Server BaseContext hook recording g in the base context of every
+// request, then calling next, if any.
+func (g *Graceful) baseContext(next func(net.Listener) context.Context) func(net.Listener) context.Context {
+	return func(l net.Listener) context.Context {
+		ctx := context.Background()
+		if next != nil {
+			ctx = next(l)
+		}
+		return context.WithValue(ctx, gracefulKey{}, g)
+	}
+}
+
+// RegisterCloser registers c to be closed by Close, once every server is shut down and the
+// cleanups are run, in the reverse order of registration, e.g. a client created by a handler on
+// its first call. The errors are logged.
+func (g *Graceful) RegisterCloser(c io.Closer) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.closers = append(g.closers, c)
+}
+
+// runClosers closes the closers registered with RegisterCloser. The caller must hold g.lock.
+func (g *Graceful) runClosers() {
+	for i := len(g.closers) - 1; i >= 0; i-- {
+		if err := g.closers[i].Close(); err != nil {
+			g.log().Error("closer failed", "error", err)
+		}
+	}
+	g.closers = nil
+}
@@ -0,0 +1,55 @@
+package graceful
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+type baseKey struct{}
+
+func TestFromContext(t *testing.T) {
+	srv := &http.Server{
+		Addr:              ":8118",
+		ReadHeaderTimeout: time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), baseKey{}, "base")
+		},
+	}
+	router, err := Default(WithServer(srv))
+	assert.NoError(t, err)
+
+	var closed []string
+	router.GET("/example", func(c *gin.Context) {
+		l, ok := FromContext(c.Request.Context())
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.False(t, l.Draining())
+		assert.Equal(t, "base", c.Request.Context().Value(baseKey{}))
+		l.RegisterCloser(closerFunc(func() error { closed = append(closed, "first"); return nil }))
+		l.RegisterCloser(closerFunc(func() error { closed = append(closed, "second"); return nil }))
+		c.String(http.StatusOK, "it worked")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, reachable(t, "http://localhost:8118/example"))
+	assert.NoError(t, router.Stop())
+	assert.Empty(t, closed)
+
+	router.Close()
+	assert.Equal(t, []string{"second", "first"}, closed)
+
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,113 @@
+package graceful
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// WithControlSocket configure a control socket, named "control", listening on the given unix
+// socket file. Operators can manage the process with tools such as nc by sending one command
+// per line, each answered with one JSON line:
+//
+//   - status describes the state of the Graceful instance.
+//   - drain drains the Graceful instance, see Drain.
+//   - shutdown gracefully shuts down every server and the control socket.
+//   - reload-certs reloads the certificate files, see ReloadCertificates.
+func WithControlSocket(path string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := g.claimUnix(path); err != nil {
+			return nil, donothing, err
+		}
+		g.checkUnixSocket(path)
+		return WithRunner("control", RunnerFunc(func(ctx context.Context) error {
+			return g.serveControl(ctx, path)
+		})).apply(g)
+	})
+}
+
+// serveControl serves the control protocol of g on the given unix socket file until ctx is canceled.
+func (g *Graceful) serveControl(ctx context.Context, path string) error {
+	l, err := g.listenConfig.Listen(ctx, "unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+	})
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.handleControl(ctx, conn)
+		}()
+	}
+}
+
+// handleControl answers the commands sent on conn until it is closed or ctx is canceled.
+func (g *Graceful) handleControl(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		command := strings.TrimSpace(scanner.Text())
+		if command == "" {
+			continue
+		}
+
+		if err := encoder.Encode(g.controlCommand(command)); err != nil {
+			return
+		}
+		if command == "shutdown" {
+			g.cancelRun("control")
+		}
+	}
+}
+
+// controlCommand runs the given control command and returns its response.
+func (g *Graceful) controlCommand(command string) map[string]any {
+	switch command {
+	case "status":
+	case "drain":
+		g.Drain()
+	case "shutdown":
+		g.Drain()
+	case "reload-certs":
+		if err := g.ReloadCertificates(); err != nil {
+			return map[string]any{"ok": false, "error": err.Error()}
+		}
+	default:
+		return map[string]any{"ok": false, "error": "unknown command: " + command}
+	}
+
+	response := map[string]any{"ok": true}
+	for k, v := range g.adminState() {
+		response[k] = v
+	}
+	return response
+}
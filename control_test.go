@@ -0,0 +1,80 @@
+package graceful
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithControlSocket(t *testing.T) {
+	socket := filepath.Join(os.TempDir(), fmt.Sprintf("graceful-control-%d.sock", time.Now().UnixNano()))
+	defer os.Remove(socket)
+
+	router, err := Default(
+		WithAddr(":8093"),
+		WithTLS(":8443", "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"),
+		WithControlSocket(socket),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("unix", socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+
+	command := func(line string) map[string]any {
+		_, err := fmt.Fprintln(conn, line)
+		assert.NoError(t, err)
+		assert.True(t, scanner.Scan())
+		var response map[string]any
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &response))
+		return response
+	}
+
+	response := command("status")
+	assert.Equal(t, true, response["ok"])
+	assert.Equal(t, false, response["draining"])
+	assert.Len(t, response["servers"], 3)
+
+	response = command("reload-certs")
+	assert.Equal(t, true, response["ok"])
+
+	response = command("unknown")
+	assert.Equal(t, false, response["ok"])
+	assert.Equal(t, "unknown command: unknown", response["error"])
+
+	response = command("drain")
+	assert.Equal(t, true, response["ok"])
+	assert.Equal(t, true, response["draining"])
+	assert.True(t, router.Draining())
+
+	response = command("shutdown")
+	assert.Equal(t, true, response["ok"])
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("control shutdown did not stop the run")
+	}
+
+	_, err = os.Stat(socket)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,70 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// releaseTimeout bounds the release of the drain slot, whatever the shutdown context.
+const releaseTimeout = 5 * time.Second
+
+// ShutdownCoordinator is a distributed semaphore shared by the replicas of a service, e.g. backed
+// by a Redis, etcd or Kubernetes Lease lock, so that only a few replicas drain at the same time.
+type ShutdownCoordinator interface {
+	// Acquire blocks until the instance holds one of the limit drain slots, or ctx is done.
+	Acquire(ctx context.Context, limit int) error
+	// Release releases the drain slot held by the instance.
+	Release(ctx context.Context) error
+}
+
+// WithShutdownCoordinator configure a ShutdownCoordinator so that at most maxConcurrent replicas
+// drain at the same time, e.g. during a mass node drain. A shutting down instance waits for a drain
+// slot before deregistering, and releases it once its servers are drained. The instance drains
+// without a slot if the coordinator fails, or if the shutdown context is done before a slot is
+// free, so that it is not killed without a drain.
+func WithShutdownCoordinator(c ShutdownCoordinator, maxConcurrent int) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if c == nil {
+			return nil, donothing, errors.New("nil shutdown coordinator")
+		}
+		if maxConcurrent < 1 {
+			return nil, donothing, errors.New("invalid max concurrent drains")
+		}
+		g.coordinator = c
+		g.drainSlots = maxConcurrent
+		return nil, donothing, nil
+	})
+}
+
+// acquireDrain waits for a drain slot of the coordinator, and returns the shutdown phase of the
+// wait and whether the slot is held.
+func (g *Graceful) acquireDrain(ctx context.Context) (ShutdownPhase, bool) {
+	start := time.Now()
+	phase := ShutdownPhase{Name: "coordinator acquire"}
+	err := g.coordinator.Acquire(ctx, g.drainSlots)
+	phase.Duration = time.Since(start)
+	if err != nil {
+		phase.Error = err.Error()
+		g.log().Warn("drain slot not acquired, draining anyway", "error", err)
+		return phase, false
+	}
+	g.debugLog("drain slot acquired", "wait", phase.Duration)
+	return phase, true
+}
+
+// releaseDrain releases the drain slot held by the instance, and returns the shutdown phase of the
+// release.
+func (g *Graceful) releaseDrain(ctx context.Context) ShutdownPhase {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	start := time.Now()
+	phase := ShutdownPhase{Name: "coordinator release"}
+	if err := g.coordinator.Release(ctx); err != nil {
+		phase.Error = err.Error()
+		g.log().Error("drain slot release failed", "error", err)
+	}
+	phase.Duration = time.Since(start)
+	return phase
+}
@@ -0,0 +1,115 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// semaphoreCoordinator is a ShutdownCoordinator backed by a channel, standing for a lock shared
+// by the replicas.
+type semaphoreCoordinator struct {
+	once  sync.Once
+	slots chan struct{}
+	limit int
+}
+
+func (c *semaphoreCoordinator) init(limit int) {
+	c.once.Do(func() {
+		c.limit = limit
+		c.slots = make(chan struct{}, limit)
+	})
+}
+
+func (c *semaphoreCoordinator) Acquire(ctx context.Context, limit int) error {
+	c.init(limit)
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *semaphoreCoordinator) Release(context.Context) error {
+	select {
+	case <-c.slots:
+		return nil
+	default:
+		return errors.New("no slot held")
+	}
+}
+
+func TestWithShutdownCoordinator(t *testing.T) {
+	coordinator := &semaphoreCoordinator{}
+	// Another replica holds the only drain slot.
+	assert.NoError(t, coordinator.Acquire(context.Background(), 1))
+
+	var report ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8139"),
+		WithShutdownCoordinator(coordinator, 1),
+		WithShutdownReportFunc(func(r ShutdownReport) { report = r }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	stopped := make(chan error)
+	go func() { stopped <- router.Shutdown(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("shutdown drained without a drain slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, router.Draining())
+
+	assert.NoError(t, coordinator.Release(context.Background()))
+	assert.NoError(t, <-stopped)
+	assert.Empty(t, coordinator.slots)
+	if assert.Len(t, report.Phases, 3) {
+		assert.Equal(t, "coordinator acquire", report.Phases[0].Name)
+		assert.Equal(t, "drain 127.0.0.1:8139", report.Phases[1].Name)
+		assert.Equal(t, "coordinator release", report.Phases[2].Name)
+	}
+}
+
+func TestShutdownCoordinatorTimeout(t *testing.T) {
+	coordinator := &semaphoreCoordinator{}
+	assert.NoError(t, coordinator.Acquire(context.Background(), 1))
+
+	var report ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8139"),
+		WithShutdownCoordinator(coordinator, 1),
+		WithShutdownReportFunc(func(r ShutdownReport) { report = r }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, router.Shutdown(ctx))
+	if assert.Len(t, report.Phases, 2) {
+		assert.Equal(t, "coordinator acquire", report.Phases[0].Name)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Phases[0].Error)
+		assert.Equal(t, "drain 127.0.0.1:8139", report.Phases[1].Name)
+	}
+	assert.Len(t, coordinator.slots, 1)
+
+	_, err = Default(WithShutdownCoordinator(nil, 1))
+	assert.EqualError(t, err, "nil shutdown coordinator")
+	_, err = Default(WithShutdownCoordinator(coordinator, 0))
+	assert.EqualError(t, err, "invalid max concurrent drains")
+}
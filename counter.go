@@ -0,0 +1,102 @@
+package graceful
+
+import (
+	"math/rand"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// counterShards is the number of shards of a counter or a connSet.
+const counterShards = 32
+
+// counter is a counter sharded over atomics padded to their own cache line, so that updates from
+// many cores at tens of thousands of requests per second do not contend on one cache line. A load
+// sums the shards.
+type counter struct {
+	shards [counterShards]paddedInt64
+}
+
+// paddedInt64 is an atomic integer filling a 64 bytes cache line.
+type paddedInt64 struct {
+	atomic.Int64
+	_ [56]byte
+}
+
+// add adds delta to a shard picked at random and returns it, so that the caller undoes the update
+// on the same shard, e.g. once the request it counts completes.
+func (c *counter) add(delta int64) *paddedInt64 {
+	shard := &c.shards[rand.Uint32()%counterShards] //nolint:gosec // no cryptographic use
+	shard.Add(delta)
+	return shard
+}
+
+// load returns the value of the counter.
+func (c *counter) load() int64 {
+	var sum int64
+	for i := range c.shards {
+		sum += c.shards[i].Load()
+	}
+	return sum
+}
+
+// connSet is a set of connections striped over shards by connection, each guarded by its own lock
+// and counting its connections in an atomic, so that tracking the connections of many cores does
+// not contend on a single lock and counting them takes no lock at all.
+type connSet struct {
+	shards [counterShards]connShard
+}
+
+// connShard is a shard of a connSet filling a 64 bytes cache line.
+type connShard struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	n     atomic.Int64
+	_     [40]byte
+}
+
+// add adds conn to the set.
+func (s *connSet) add(conn net.Conn) {
+	shard := s.shard(conn)
+	shard.mu.Lock()
+	if _, ok := shard.conns[conn]; !ok {
+		if shard.conns == nil {
+			shard.conns = make(map[net.Conn]struct{})
+		}
+		shard.conns[conn] = struct{}{}
+		shard.n.Add(1)
+	}
+	shard.mu.Unlock()
+}
+
+// remove removes conn from the set, if present.
+func (s *connSet) remove(conn net.Conn) {
+	shard := s.shard(conn)
+	shard.mu.Lock()
+	if _, ok := shard.conns[conn]; ok {
+		delete(shard.conns, conn)
+		shard.n.Add(-1)
+	}
+	shard.mu.Unlock()
+}
+
+// len returns the number of connections in the set.
+func (s *connSet) len() int {
+	var n int64
+	for i := range s.shards {
+		n += s.shards[i].n.Load()
+	}
+	return int(n)
+}
+
+// shard returns the shard of conn, derived from the address of the connection so that every state
+// change of a connection lands on the same shard.
+func (s *connSet) shard(conn net.Conn) *connShard {
+	var h uintptr
+	if v := reflect.ValueOf(conn); v.Kind() == reflect.Pointer {
+		h = v.Pointer()
+		h = h>>3 ^ h>>8
+	}
+	return &s.shards[h%counterShards]
+}
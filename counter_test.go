@@ -0,0 +1,111 @@
+package graceful
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	var c counter
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.add(1)
+			}
+			c.add(-500)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(8*500), c.load())
+}
+
+func TestConnSet(t *testing.T) {
+	var s connSet
+	conns := make([]net.Conn, 100)
+	for i := range conns {
+		conns[i] = &net.TCPConn{}
+		s.add(conns[i])
+		s.add(conns[i])
+	}
+	assert.Equal(t, 100, s.len())
+
+	used := map[*connShard]bool{}
+	for _, conn := range conns {
+		used[s.shard(conn)] = true
+		s.remove(conn)
+		s.remove(conn)
+	}
+	assert.Equal(t, 0, s.len())
+	assert.Greater(t, len(used), 1)
+}
+
+func TestTrackConn(t *testing.T) {
+	s := &managedServer{}
+	track := s.trackConn(nil)
+	a, b := &net.TCPConn{}, &net.TCPConn{}
+
+	track(a, http.StateNew)
+	track(a, http.StateActive)
+	track(b, http.StateActive)
+	assert.Equal(t, 2, s.activeConnections())
+
+	track(a, http.StateIdle)
+	track(a, http.StateClosed)
+	track(b, http.StateHijacked)
+	assert.Equal(t, 0, s.activeConnections())
+}
+
+func BenchmarkCounter(b *testing.B) {
+	var c counter
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.add(1).Add(-1)
+		}
+	})
+}
+
+func BenchmarkAtomic(b *testing.B) {
+	var c atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Add(1)
+			c.Add(-1)
+		}
+	})
+}
+
+func BenchmarkMutexConnMap(b *testing.B) {
+	var mu sync.Mutex
+	conns := map[net.Conn]struct{}{}
+	b.RunParallel(func(pb *testing.PB) {
+		conn := &net.TCPConn{}
+		for pb.Next() {
+			mu.Lock()
+			conns[conn] = struct{}{}
+			mu.Unlock()
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}
+	})
+}
+
+func BenchmarkTrackConn(b *testing.B) {
+	s := &managedServer{}
+	track := s.trackConn(nil)
+	b.RunParallel(func(pb *testing.PB) {
+		conn := &net.TCPConn{}
+		for pb.Next() {
+			track(conn, http.StateActive)
+			track(conn, http.StateIdle)
+		}
+	})
+}
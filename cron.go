@@ -0,0 +1,136 @@
+package graceful
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronHorizon bounds the search of the next time of a cron schedule.
+const cronHorizon = 5 * 365 * 24 * time.Hour
+
+// cronMacros are the predefined cron schedules.
+var cronMacros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// cronSchedule is a standard five-field cron schedule: minute, hour, day of month, month and
+// day of week, each field a bit set of the matching values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny report whether the day fields are unrestricted: if both are restricted,
+	// a day matching either of them matches, as with cron.
+	domAny, dowAny bool
+}
+
+// parseCron parses a five-field cron schedule, such as "30 3 * * 1-5", or a macro such as "@daily".
+// The fields accept "*", values, ranges, lists and steps, e.g. "*/15" or "1-5,10".
+func parseCron(spec string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron schedule %q: expected 5 fields", spec)
+	}
+
+	var c cronSchedule
+	var err error
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		if *sets[i], err = parseCronField(field, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q: %w", spec, err)
+		}
+	}
+	// Sunday is both 0 and 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+
+	return &c, nil
+}
+
+// parseCronField parses a field of a cron schedule with values between low and high.
+func parseCronField(field string, low, high int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		values, step, hasStep := strings.Cut(part, "/")
+		first, last := low, high
+		if values != "*" {
+			from, to, isRange := strings.Cut(values, "-")
+			var err error
+			if first, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			last = first
+			if isRange {
+				if last, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				last = high
+			}
+		}
+		if first < low || last > high || first > last {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, low, high)
+		}
+
+		every := 1
+		if hasStep {
+			var err error
+			if every, err = strconv.Atoi(step); err != nil || every <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+		for v := first; v <= last; v += every {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// next returns the first time of the schedule after t, or the zero time if there is none within
+// the next five years, e.g. for February 30.
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(cronHorizon); t.Before(limit); {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches the day fields of the schedule.
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
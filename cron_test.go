@@ -0,0 +1,69 @@
+package graceful
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-05-15 is a Wednesday.
+	now := time.Date(2024, 5, 15, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 15, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 5, 16, 3, 30, 0, 0, time.UTC)},
+		{"0 2 * * 0", time.Date(2024, 5, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2024, 5, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * *", time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1-5", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 4 29 2 *", time.Date(2028, 2, 29, 4, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := parseCron(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, schedule.next(now))
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"* * * *", `invalid cron schedule "* * * *": expected 5 fields`},
+		{"60 * * * *", `invalid cron schedule "60 * * * *": value "60" out of range 0-59`},
+		{"5-1 * * * *", `invalid cron schedule "5-1 * * * *": value "5-1" out of range 0-59`},
+		{"*/0 * * * *", `invalid cron schedule "*/0 * * * *": invalid step "*/0"`},
+		{"a * * * *", `invalid cron schedule "a * * * *": invalid value "a"`},
+	}
+	for _, tt := range tests {
+		_, err := parseCron(tt.spec)
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestNextRestartJitter(t *testing.T) {
+	router, err := Default(WithScheduledRestart("0 3 * * *", time.Hour))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	now := time.Date(2024, 5, 15, 2, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		delay, ok := router.nextRestart(now)
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, delay, time.Hour)
+		assert.Less(t, delay, 2*time.Hour)
+	}
+
+	_, err = Default(WithScheduledRestart("every day", 0))
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package graceful
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// databasePollInterval is the interval between two checks of the connections in use of a
+// database being drained.
+const databasePollInterval = 10 * time.Millisecond
+
+// PoolStats are the statistics of a database connection pool when it was closed, see
+// WithDatabase.
+type PoolStats struct {
+	// MaxOpenConnections is the maximum number of open connections, zero for unlimited.
+	MaxOpenConnections int `json:"max_open_connections"`
+	// OpenConnections is the number of open connections, in use or idle.
+	OpenConnections int `json:"open_connections"`
+	// InUse is the number of connections still checked out.
+	InUse int `json:"in_use"`
+	// Idle is the number of idle connections.
+	Idle int `json:"idle"`
+	// WaitCount and WaitDuration are the number of waits for a connection, and their total time.
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration"`
+}
+
+// MarshalJSON encodes s with WaitDuration in its string form, e.g. "1.5s".
+func (s PoolStats) MarshalJSON() ([]byte, error) {
+	type plain PoolStats
+	return json.Marshal(struct {
+		plain
+		WaitDuration duration `json:"wait_duration"`
+	}{plain: plain(s), WaitDuration: duration(s.WaitDuration)})
+}
+
+// UnmarshalJSON decodes s with WaitDuration in its string form, see time.ParseDuration.
+func (s *PoolStats) UnmarshalJSON(data []byte) error {
+	type plain PoolStats
+	v := struct {
+		*plain
+		WaitDuration duration `json:"wait_duration"`
+	}{plain: (*plain)(s), WaitDuration: duration(s.WaitDuration)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.WaitDuration = time.Duration(v.WaitDuration)
+	return nil
+}
+
+// database is a connection pool closed at the end of the shutdown.
+type database struct {
+	db           *sql.DB
+	drainTimeout time.Duration
+}
+
+// WithDatabase configure a database connection pool closed once every server of a running instance
+// is drained and the hooks configured with WithAfterShutdown have run. Unlike a closer, the pool
+// first waits up to drainTimeout, zero for the rest of the shutdown deadline, for its checked-out
+// connections to return, so that the last queries complete. The statistics of the pool are
+// recorded in the "close database" phase of the shutdown report.
+func WithDatabase(db *sql.DB, drainTimeout time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if db == nil {
+			return nil, donothing, errors.New("nil database")
+		}
+		if drainTimeout < 0 {
+			return nil, donothing, errors.New("invalid database drain timeout")
+		}
+		g.databases = append(g.databases, database{db: db, drainTimeout: drainTimeout})
+		return nil, donothing, nil
+	})
+}
+
+// closeDatabases drains then closes the configured databases with ctx, in order, and returns
+// their phases.
+func (g *Graceful) closeDatabases(ctx context.Context) ([]ShutdownPhase, error) {
+	var (
+		phases []ShutdownPhase
+		errs   []error
+	)
+	for _, d := range g.databases {
+		start := time.Now()
+		stats, err := d.close(ctx)
+		phase := ShutdownPhase{Name: "close database", Duration: time.Since(start), Pool: &stats}
+		if err != nil {
+			phase.Error = err.Error()
+			errs = append(errs, err)
+			g.log().Error("database close failed", "error", err, "in_use", stats.InUse)
+		}
+		phases = append(phases, phase)
+	}
+	return phases, errors.Join(errs...)
+}
+
+// close waits for the checked-out connections of the database to return, until its drain timeout
+// or ctx is done, then closes it. It returns the statistics of the pool before it was closed.
+func (d database) close(ctx context.Context) (PoolStats, error) {
+	if d.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.drainTimeout)
+		defer cancel()
+	}
+
+	var err error
+	ticker := time.NewTicker(databasePollInterval)
+	defer ticker.Stop()
+	for d.db.Stats().InUse > 0 && err == nil {
+		select {
+		case <-ctx.Done():
+			err = drainError(fmt.Errorf("database drain: %w", ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+
+	s := d.db.Stats()
+	stats := PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+	}
+	if e := d.db.Close(); e != nil {
+		err = errors.Join(err, fmt.Errorf("database close: %w", e))
+	}
+	return stats, err
+}
@@ -0,0 +1,74 @@
+package graceful
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConnector opens connections supporting nothing but being checked out and closed.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestWithDatabase(t *testing.T) {
+	released, held := sql.OpenDB(fakeConnector{}), sql.OpenDB(fakeConnector{})
+	var reports []ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8162"),
+		WithDatabase(released, time.Second),
+		WithDatabase(held, 20*time.Millisecond),
+		WithShutdownReportFunc(func(r ShutdownReport) { reports = append(reports, r) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = router.RunWithContext(context.Background())
+	}()
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	conn, err := released.Conn(context.Background())
+	assert.NoError(t, err)
+	time.AfterFunc(50*time.Millisecond, func() { _ = conn.Close() })
+	_, err = held.Conn(context.Background())
+	assert.NoError(t, err)
+
+	err = router.Shutdown(context.Background())
+	assert.ErrorIs(t, err, ErrDrainTimeout)
+	assert.ErrorContains(t, err, "database drain: context deadline exceeded")
+	<-done
+
+	if assert.Len(t, reports, 1) && assert.Len(t, reports[0].Phases, 3) {
+		phase := reports[0].Phases[1]
+		assert.Equal(t, "close database", phase.Name)
+		assert.Empty(t, phase.Error)
+		assert.GreaterOrEqual(t, phase.Duration, 40*time.Millisecond)
+		assert.Equal(t, &PoolStats{OpenConnections: 1, Idle: 1}, phase.Pool)
+
+		phase = reports[0].Phases[2]
+		assert.Equal(t, "database drain: context deadline exceeded", phase.Error)
+		assert.Equal(t, &PoolStats{OpenConnections: 1, InUse: 1}, phase.Pool)
+	}
+	assert.ErrorContains(t, released.Ping(), "database is closed")
+
+	_, err = Default(WithDatabase(nil, 0))
+	assert.EqualError(t, err, "nil database")
+	_, err = Default(WithDatabase(released, -1))
+	assert.EqualError(t, err, "invalid database drain timeout")
+}
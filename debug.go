@@ -0,0 +1,68 @@
+package graceful
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// debugOption is the Option returned by WithDebug.
+type debugOption struct{}
+
+// applyEarly enables the debug mode.
+func (debugOption) applyEarly(g *Graceful) {
+	g.debug = true
+}
+
+// apply enables the debug mode.
+func (o debugOption) apply(g *Graceful) (listenAndServe, cleanup, error) {
+	o.applyEarly(g)
+	return nil, donothing, nil
+}
+
+// WithDebug enable the debug mode: every internal transition of the Graceful instance, such
+// as an option applied, a listener created, a serve goroutine started, a shutdown invoked or
+// a cleanup run, is logged at the debug level. The events are logged to the logger configured
+// with WithLogger, or to the standard error if none is.
+func WithDebug() Option {
+	return debugOption{}
+}
+
+// debugLog logs the given internal transition if the debug mode is enabled.
+func (g *Graceful) debugLog(msg string, keysAndValues ...any) {
+	if !g.debug {
+		return
+	}
+
+	g.log().Debug(msg, keysAndValues...)
+}
+
+// stderrDebugLogger is the logger of the debug mode when no logger is configured.
+var stderrDebugLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// optionName returns a readable name of the given option, e.g. "WithAddr".
+func optionName(o Option) string {
+	if k, ok := o.(keyedOption); ok {
+		o = k.Option
+	}
+	v := reflect.ValueOf(o)
+	if v.Kind() != reflect.Func {
+		return strings.TrimPrefix(fmt.Sprintf("%T", o), "graceful.")
+	}
+
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = name[strings.Index(name, ".")+1:]
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,43 @@
+package graceful
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	router, err := Default(WithAddr(":8097"), WithDebug(), WithLogger(logger))
+	assert.NoError(t, err)
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8097/example")
+	assert.NoError(t, router.Stop())
+	router.Close()
+
+	logs := buf.String()
+	assert.Contains(t, logs, `msg="option applied" option=WithAddr`)
+	assert.Contains(t, logs, `msg="option applied" option=debugOption`)
+	assert.Contains(t, logs, `msg="listener created"`)
+	assert.Contains(t, logs, `msg="serve goroutine started"`)
+	assert.Contains(t, logs, `msg="shutdown invoked" reason="context done"`)
+	assert.Contains(t, logs, `msg="cleanup run"`)
+}
+
+func TestWithoutDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	router, err := Default(WithAddr(":8097"), WithLogger(logger))
+	assert.NoError(t, err)
+	router.Close()
+
+	assert.NotContains(t, buf.String(), "option applied")
+}
@@ -0,0 +1,189 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Drain marks the Graceful instance as draining ahead of a shutdown: the readiness endpoint
+// fails so that load balancers stop routing traffic to the instance, and new requests are
+// rejected with 503 Service Unavailable while in-flight requests complete. The servers keep
+// their listeners open until Shutdown is called. A new run resets the draining state.
+func (g *Graceful) Drain() {
+	g.startDrain()
+}
+
+// startDrain marks the Graceful instance as draining, cancels the low priority requests, wakes
+// up the long-poll handlers and schedules the cutoff of the streaming responses.
+func (g *Graceful) startDrain() {
+	g.draining.Store(true)
+	g.lock.Lock()
+	if g.drainStarted != nil {
+		close(g.drainStarted)
+		g.drainStarted = nil
+	}
+	g.lock.Unlock()
+	g.cancelLowPriority()
+	g.wakeUp()
+	g.scheduleStreamCutoff()
+}
+
+// Draining reports whether the Graceful instance is draining or shutting down.
+func (g *Graceful) Draining() bool {
+	return g.draining.Load()
+}
+
+// gracefulKey is the request context key of the Graceful instance serving the request.
+type gracefulKey struct{}
+
+// IsShuttingDown reports whether the Graceful instance serving the request of c is draining or
+// shutting down, e.g. so that a handler skips enqueueing new asynchronous work. It reports
+// false if the request is not served by a Graceful instance.
+func IsShuttingDown(c *gin.Context) bool {
+	if c == nil || c.Request == nil {
+		return false
+	}
+
+	l, ok := FromContext(c.Request.Context())
+	return ok && l.Draining()
+}
+
+// ServeHTTP serves the request with the active gin.Engine, see SetEngine and Promote. While
+// draining, new requests are rejected, except for the paths exempted from draining such as the
+// health endpoints. In maintenance mode, requests are answered by the maintenance handler. The
+// Graceful instance is recorded in the request context, see IsShuttingDown and FromContext.
+func (g *Graceful) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// The servers of the instance already record it in their base context.
+	if req.Context().Value(gracefulKey{}) == nil {
+		req = req.WithContext(context.WithValue(req.Context(), gracefulKey{}, g))
+	}
+
+	g.countRequest()
+	if g.maxConnLifetime > 0 {
+		g.limitConnLifetime(w, req)
+	}
+	if len(g.drainHeader) > 0 {
+		w = &drainHeaderWriter{ResponseWriter: w, g: g}
+	}
+	if g.idleTimeout > 0 {
+		defer g.trackActivity()()
+	}
+
+	// The request is tracked before checking the draining state, so that a shutdown either
+	// rejects it or waits for it.
+	if len(g.drainRoutes) > 0 {
+		defer g.trackDrainRoutes(req.URL.Path)()
+	}
+
+	draining := g.draining.Load()
+	if draining && !g.isDrainExempt(req.URL.Path) {
+		g.rejectDraining(w, req)
+		return
+	}
+
+	if g.maintenance.Load() && !g.isMaintenanceExempt(req.URL.Path) {
+		g.serveMaintenance(w, req)
+		return
+	}
+
+	if len(g.priorityRoutes) > 0 && g.drainPriority(req.URL.Path) == DrainPriorityLow {
+		g.serveLowPriority(w, req)
+		return
+	}
+
+	if g.streamCutoff > 0 {
+		g.serveStream(w, req)
+	} else {
+		g.serveEngine(w, req)
+	}
+
+	if !draining && g.draining.Load() {
+		g.recordDrained(req)
+	}
+}
+
+// exemptFromDrain exempts the given paths from the rejection of requests while draining.
+func (g *Graceful) exemptFromDrain(paths ...string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.drainExempt == nil {
+		g.drainExempt = map[string]struct{}{}
+	}
+	for _, p := range paths {
+		g.drainExempt[p] = struct{}{}
+	}
+}
+
+// isDrainExempt reports whether the given path is exempted from draining.
+func (g *Graceful) isDrainExempt(path string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	_, ok := g.drainExempt[path]
+	return ok
+}
+
+// DrainResponse describes the response to the requests rejected while draining.
+type DrainResponse struct {
+	// StatusCode is the status code of the response. Defaults to 503 Service Unavailable.
+	StatusCode int
+	// Header holds the headers added to the response.
+	Header http.Header
+	// Body is the body of the response. Defaults to the status text.
+	Body []byte
+}
+
+// WithDrainResponse configure the response to the requests rejected while draining, e.g. a
+// 429 Too Many Requests with a Retry-After header, or a JSON error matching the API schema.
+func WithDrainResponse(resp DrainResponse) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.drainResponse = &resp
+		return nil, donothing, nil
+	})
+}
+
+// WithDrainHandler configure the handler answering the requests rejected while draining. The
+// handler must write a response, otherwise 404 Not Found is returned.
+func WithDrainHandler(handler gin.HandlerFunc) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		engine := gin.New()
+		engine.NoRoute(handler)
+		g.drainHandler = engine
+		return nil, donothing, nil
+	})
+}
+
+// rejectDraining answers a request rejected while draining, with the configured drain handler
+// or response. The connection is closed afterward.
+func (g *Graceful) rejectDraining(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Connection", "close")
+
+	if g.drainHandler != nil {
+		g.drainHandler.ServeHTTP(w, req)
+		return
+	}
+
+	resp := g.drainResponse
+	if resp == nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	code := resp.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	if resp.Body == nil {
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+
+	w.WriteHeader(code)
+	_, _ = w.Write(resp.Body)
+}
@@ -0,0 +1,116 @@
+package graceful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDrainResponse(t *testing.T) {
+	router, err := New(gin.New(), WithDrainResponse(DrainResponse{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"5"}, "Content-Type": {"application/json"}},
+		Body:       []byte(`{"error":"draining"}`),
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.Drain()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Equal(t, "close", w.Header().Get("Connection"))
+	assert.JSONEq(t, `{"error":"draining"}`, w.Body.String())
+}
+
+func TestWithDrainResponseDefaults(t *testing.T) {
+	router, err := New(gin.New(), WithDrainResponse(DrainResponse{Header: http.Header{"Retry-After": {"5"}}}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.Drain()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Equal(t, "Service Unavailable\n", w.Body.String())
+}
+
+func TestWithDrainHandler(t *testing.T) {
+	router, err := New(gin.New(), WithDrainHandler(func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "draining", "path": c.Request.URL.Path})
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.Drain()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"draining","path":"/users"}`, w.Body.String())
+}
+
+func TestWithDrainHeader(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	engine := gin.New()
+	engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	router, err := New(engine, WithDrainHeader(http.Header{"X-Graceful-Draining": {"1"}}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, w.Header().Get("X-Graceful-Draining"))
+
+	inFlight := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		router.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+	router.Drain()
+	close(release)
+	<-served
+	assert.Equal(t, "done", inFlight.Body.String())
+	assert.Equal(t, "1", inFlight.Header().Get("X-Graceful-Draining"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Graceful-Draining"))
+}
+
+func TestIsShuttingDown(t *testing.T) {
+	router, err := New(gin.New())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/", func(c *gin.Context) {
+		before := IsShuttingDown(c)
+		router.Drain()
+		c.JSON(http.StatusOK, gin.H{"before": before, "after": IsShuttingDown(c)})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"before":false,"after":true}`, w.Body.String())
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.False(t, IsShuttingDown(c))
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, IsShuttingDown(c))
+}
@@ -0,0 +1,84 @@
+package graceful
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// WithDrainHeader configure headers added to every response started while the Graceful instance
+// drains, including the rejected requests, e.g. "Connection: close" and "X-Graceful-Draining: 1",
+// so that smart clients and sidecars send their next requests elsewhere. A response already
+// started when the drain starts is left as is.
+func WithDrainHeader(header http.Header) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.drainHeader = header.Clone()
+		return nil, donothing, nil
+	})
+}
+
+// drainHeaderWriter is the http.ResponseWriter adding the drain headers to a response started
+// while draining.
+type drainHeaderWriter struct {
+	http.ResponseWriter
+	g    *Graceful
+	once sync.Once
+}
+
+// addHeader adds the drain headers when the response starts while draining.
+func (w *drainHeaderWriter) addHeader() {
+	w.once.Do(func() {
+		if !w.g.draining.Load() {
+			return
+		}
+		h := w.ResponseWriter.Header()
+		for k, v := range w.g.drainHeader {
+			h[k] = append([]string(nil), v...)
+		}
+	})
+}
+
+// WriteHeader sends the response header, with the drain headers while draining.
+func (w *drainHeaderWriter) WriteHeader(code int) {
+	w.addHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the response body, sending the header with the drain headers first if needed.
+func (w *drainHeaderWriter) Write(b []byte) (int, error) {
+	w.addHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered response.
+func (w *drainHeaderWriter) Flush() {
+	w.addHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection.
+func (w *drainHeaderWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	return h.Hijack()
+}
+
+// CloseNotify implements http.CloseNotifier, which gin.Context.Stream relies on.
+func (w *drainHeaderWriter) CloseNotify() <-chan bool {
+	//nolint:staticcheck // http.CloseNotifier is deprecated but still used by gin.
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool, 1)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *drainHeaderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,128 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DrainProgress describes the drain of one server, reported as soon as it completes, see
+// WithDrainProgress.
+type DrainProgress struct {
+	// Server identifies the drained server, by component name, label or address.
+	Server string
+	// Duration is how long the drain of the server took.
+	Duration time.Duration
+	// Err is the error the drain ended with, if any.
+	Err error
+	// Drained is the number of servers drained so far in the shutdown, this one included, out of
+	// Total.
+	Drained int
+	Total   int
+}
+
+// WithDrainConcurrency configure the number of servers drained concurrently, so that the
+// shutdown of an instance with many listeners, such as per-tenant unix sockets, takes about the
+// longest drain instead of the sum of the drains. The servers are drained one after the other,
+// in the order they are configured, by default.
+func WithDrainConcurrency(n int) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if n < 1 {
+			return nil, donothing, errors.New("invalid drain concurrency")
+		}
+		g.drainConcurrency = n
+		return nil, donothing, nil
+	})
+}
+
+// WithDrainProgress configure a callback invoked once the drain of every server completes, e.g.
+// to report the progress of a long shutdown. It may be invoked concurrently with
+// WithDrainConcurrency.
+func WithDrainProgress(fn func(DrainProgress)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.drainProgress = fn
+		return nil, donothing, nil
+	})
+}
+
+// drainServers shuts down every server with ctx, with at most the configured number of drains at
+// once, and returns the phases of the running servers, in the order they are configured, with the
+// errors of the failed ones, each a *ShutdownError.
+func (g *Graceful) drainServers(ctx context.Context, servers []*managedServer) ([]ShutdownPhase, error) {
+	workers := g.drainConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	total := 0
+	for _, srv := range servers {
+		if srv.running() {
+			total++
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		drained int
+	)
+	phases := make([]*ShutdownPhase, len(servers))
+	errs := make([]error, len(servers))
+	sem := make(chan struct{}, workers)
+	for i, srv := range servers {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, srv *managedServer) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			running, e := srv.shutdown(ctx)
+			if !running {
+				return
+			}
+			phase := &ShutdownPhase{
+				Name:              "drain " + srv.label(),
+				Duration:          time.Since(start),
+				ActiveConnections: srv.activeConnections(),
+			}
+			if e != nil {
+				errs[i] = &ShutdownError{Server: srv.label(), Err: drainError(e)}
+				phase.Error = e.Error()
+				g.log().Error("server drain failed", serverAttrs(srv, "error", e)...)
+			}
+			phases[i] = phase
+			if e == nil && g.cleanupOnShutdown && srv.release != nil {
+				srv.release()
+				g.debugLog("server cleanup run", serverAttrs(srv)...)
+			}
+
+			mu.Lock()
+			drained++
+			progress := DrainProgress{
+				Server:   srv.label(),
+				Duration: phase.Duration,
+				Err:      e,
+				Drained:  drained,
+				Total:    total,
+			}
+			mu.Unlock()
+			g.debugLog("server drained", serverAttrs(srv, "drained", progress.Drained, "total", total)...)
+			if g.drainProgress != nil {
+				g.drainProgress(progress)
+			}
+		}(i, srv)
+	}
+	wg.Wait()
+
+	var result []ShutdownPhase
+	for _, phase := range phases {
+		if phase != nil {
+			result = append(result, *phase)
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDrainConcurrency(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		progress []DrainProgress
+	)
+	router, err := Default(
+		WithAddr("127.0.0.1:8132"),
+		WithAddr("127.0.0.1:8133"),
+		WithAddr("127.0.0.1:8134"),
+		WithDrainConcurrency(3),
+		WithDrainProgress(func(p DrainProgress) {
+			lock.Lock()
+			defer lock.Unlock()
+			progress = append(progress, p)
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "it worked")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+	var requests sync.WaitGroup
+	for _, port := range []string{"8132", "8133", "8134"} {
+		requests.Add(1)
+		go func(port string) {
+			defer requests.Done()
+			assert.True(t, reachable(t, "http://127.0.0.1:"+port+"/slow"))
+		}(port)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.Less(t, time.Since(start), 350*time.Millisecond)
+	requests.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if assert.Len(t, progress, 3) {
+		for i, p := range progress {
+			assert.Equal(t, i+1, p.Drained)
+			assert.Equal(t, 3, p.Total)
+			assert.NoError(t, p.Err)
+		}
+	}
+
+	_, err = Default(WithDrainConcurrency(0))
+	assert.EqualError(t, err, "invalid drain concurrency")
+}
+
+func TestWithCleanupOnShutdown(t *testing.T) {
+	for _, onShutdown := range []bool{false, true} {
+		var released atomic.Int32
+		server := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+			run, _, err := WithAddr("127.0.0.1:8137").apply(g)
+			return run, func() { released.Add(1) }, err
+		})
+		opts := []Option{server}
+		if onShutdown {
+			opts = append(opts, WithCleanupOnShutdown())
+		}
+		router, err := Default(opts...)
+		assert.NoError(t, err)
+
+		assert.NoError(t, router.Start())
+		assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+			time.Second, 5*time.Millisecond)
+		assert.NoError(t, router.Shutdown(context.Background()))
+		if onShutdown {
+			assert.Equal(t, int32(1), released.Load())
+		} else {
+			assert.Zero(t, released.Load())
+		}
+
+		router.Close()
+		assert.Equal(t, int32(1), released.Load())
+	}
+}
+
+func TestShutdownErrorPerServer(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8156"), WithAddr("127.0.0.1:8157"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var inFlight atomic.Int32
+	release := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		inFlight.Add(1)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = router.RunWithContext(context.Background())
+	}()
+	assert.Eventually(t, func() bool { return router.ServerStatus()[1].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	var requests sync.WaitGroup
+	for _, url := range []string{"http://127.0.0.1:8156/slow", "http://127.0.0.1:8157/slow"} {
+		requests.Add(1)
+		go func(url string) {
+			defer requests.Done()
+			reachable(t, url)
+		}(url)
+	}
+	assert.Eventually(t, func() bool { return inFlight.Load() == 2 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = router.Shutdown(ctx)
+	assert.ErrorIs(t, err, ErrDrainTimeout)
+	assert.ErrorContains(t, err, `shutdown "127.0.0.1:8156": context deadline exceeded`)
+	assert.ErrorContains(t, err, `shutdown "127.0.0.1:8157": context deadline exceeded`)
+	close(release)
+	requests.Wait()
+	<-done
+}
@@ -0,0 +1,102 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// drainRoutePollInterval is how often the in-flight requests of the drain routes are checked.
+const drainRoutePollInterval = 10 * time.Millisecond
+
+// drainRoute tracks the in-flight requests on the routes matching a pattern, see WithDrainRoutes.
+type drainRoute struct {
+	pattern  string
+	timeout  time.Duration
+	inFlight atomic.Int64
+}
+
+// match reports whether the given path matches the pattern of the route.
+func (r *drainRoute) match(path string) bool {
+	return matchRoute(r.pattern, path)
+}
+
+// matchRoute reports whether the given path matches pattern: a pattern ending with "*"
+// matches every path with the preceding prefix, any other pattern matches itself.
+func matchRoute(pattern, path string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == pattern
+}
+
+// WithDrainRoutes configure long-running routes, such as uploads, whose in-flight requests
+// are waited for during a shutdown: once new requests are rejected, the shutdown waits at most
+// timeout for the requests on the paths matching pattern to complete, or less if the deadline of
+// the shutdown context comes first, before shutting down the servers. A pattern ending with "*"
+// matches every path with the preceding prefix, e.g. "/upload/*".
+func WithDrainRoutes(pattern string, timeout time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.drainRoutes = append(g.drainRoutes, &drainRoute{pattern: pattern, timeout: timeout})
+		return nil, donothing, nil
+	})
+}
+
+// trackDrainRoutes counts a request on the given path as in flight on the matching drain
+// routes, until the returned function is called.
+func (g *Graceful) trackDrainRoutes(path string) func() {
+	var routes []*drainRoute
+	for _, r := range g.drainRoutes {
+		if r.match(path) {
+			r.inFlight.Add(1)
+			routes = append(routes, r)
+		}
+	}
+
+	return func() {
+		for _, r := range routes {
+			r.inFlight.Add(-1)
+		}
+	}
+}
+
+// waitDrainRoutes waits for the in-flight requests on the drain routes, each up to its own
+// timeout, or until ctx is done. It returns a shutdown phase for every route that had requests in
+// flight.
+func (g *Graceful) waitDrainRoutes(ctx context.Context) []ShutdownPhase {
+	var phases []ShutdownPhase
+
+	ticker := time.NewTicker(drainRoutePollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for _, r := range g.drainRoutes {
+		if r.inFlight.Load() == 0 {
+			continue
+		}
+
+		routeCtx, cancel := context.WithDeadline(ctx, start.Add(r.timeout))
+		for r.inFlight.Load() > 0 && routeCtx.Err() == nil {
+			select {
+			case <-routeCtx.Done():
+			case <-ticker.C:
+			}
+		}
+		cancel()
+
+		phase := ShutdownPhase{Name: "drain route " + r.pattern, Duration: time.Since(start)}
+		if n := r.inFlight.Load(); n > 0 {
+			if err := ctx.Err(); err != nil {
+				phase.Error = fmt.Sprintf("%d requests still in flight: %v", n, err)
+			} else {
+				phase.Error = fmt.Sprintf("%d requests still in flight after %s", n, r.timeout)
+			}
+			g.log().Warn("drain route timed out", "route", r.pattern, "in_flight", n)
+		}
+		phases = append(phases, phase)
+	}
+
+	return phases
+}
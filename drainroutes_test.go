@@ -0,0 +1,166 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDrainRoutes(t *testing.T) {
+	var reports []ShutdownReport
+	router, err := Default(
+		WithAddr(":8099"),
+		WithDrainRoutes("/upload/*", time.Second),
+		WithShutdownReportFunc(func(report ShutdownReport) { reports = append(reports, report) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var completed atomic.Bool
+	router.POST("/upload/:name", func(c *gin.Context) {
+		time.Sleep(100 * time.Millisecond)
+		completed.Store(true)
+		c.String(http.StatusOK, "uploaded")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost:8099/upload/file", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, router.Shutdown(ctx))
+	assert.True(t, completed.Load())
+	assert.NoError(t, router.Stop())
+
+	if assert.NotEmpty(t, reports) {
+		assert.Equal(t, "drain route /upload/*", reports[0].Phases[0].Name)
+		assert.Empty(t, reports[0].Phases[0].Error)
+	}
+}
+
+func TestWithDrainRoutesTimeout(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []ShutdownReport
+	)
+	router, err := Default(
+		WithAddr(":8099"),
+		WithDrainRoutes("/upload/*", 20*time.Millisecond),
+		WithShutdownReportFunc(func(report ShutdownReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, report)
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	release := make(chan struct{})
+	router.POST("/upload/:name", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "uploaded")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost:8099/upload/file", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, router.Shutdown(ctx), context.DeadlineExceeded)
+	close(release)
+	<-requestDone
+	assert.NoError(t, router.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.NotEmpty(t, reports) {
+		assert.Equal(t, "1 requests still in flight after 20ms", reports[0].Phases[0].Error)
+	}
+}
+
+func TestWithDrainRoutesContext(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []ShutdownReport
+	)
+	router, err := Default(
+		WithAddr("127.0.0.1:8165"),
+		WithDrainRoutes("/upload/*", 10*time.Second),
+		WithShutdownReportFunc(func(report ShutdownReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, report)
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var inFlight atomic.Bool
+	release := make(chan struct{})
+	router.POST("/upload/:name", func(c *gin.Context) {
+		inFlight.Store(true)
+		<-release
+		c.String(http.StatusOK, "uploaded")
+	})
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:8165/upload/file", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	assert.Eventually(t, inFlight.Load, time.Second, 5*time.Millisecond)
+
+	// The route timeout is capped by the shutdown context.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, router.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	close(release)
+	<-requestDone
+	assert.NoError(t, router.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.NotEmpty(t, reports) {
+		assert.Equal(t, "1 requests still in flight: context deadline exceeded", reports[0].Phases[0].Error)
+	}
+}
+
+func TestDrainRouteMatch(t *testing.T) {
+	assert.True(t, (&drainRoute{pattern: "/upload/*"}).match("/upload/a/b"))
+	assert.False(t, (&drainRoute{pattern: "/upload/*"}).match("/download"))
+	assert.True(t, (&drainRoute{pattern: "/export"}).match("/export"))
+	assert.False(t, (&drainRoute{pattern: "/export"}).match("/export/a"))
+}
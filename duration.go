@@ -0,0 +1,29 @@
+package graceful
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// duration is a time.Duration encoded in JSON in its string form, e.g. "1.5s", the encoding of
+// every duration of the JSON configuration and output of the package.
+type duration time.Duration
+
+// MarshalJSON encodes d as its string form, see time.Duration.String.
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes d from its string form, see time.ParseDuration.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
@@ -0,0 +1,201 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithEngineFactory configure factory to build the gin.Engine serving the requests, once by New
+// and then on every call to ReloadEngine, e.g. to rebuild the routes from a configuration file.
+// The routes registered by the options, such as the health endpoints, are registered on every
+// built engine, while the routes registered on the Graceful instance itself are no longer served.
+func WithEngineFactory(factory func() (*gin.Engine, error)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if factory == nil {
+			return nil, donothing, errors.New("nil engine factory")
+		}
+		g.engineFactory = factory
+		return nil, donothing, g.ReloadEngine()
+	})
+}
+
+// ReloadEngine builds a new gin.Engine with the factory configured with WithEngineFactory and
+// atomically swaps it into every server: the requests in flight complete on the previous engine
+// and the new requests are served by the new one. The current engine is kept if the factory fails.
+func (g *Graceful) ReloadEngine() error {
+	g.lock.Lock()
+	factory := g.engineFactory
+	g.lock.Unlock()
+
+	if factory == nil {
+		return errors.New("no engine factory")
+	}
+	engine, err := factory()
+	if err != nil {
+		return fmt.Errorf("engine factory: %w", err)
+	}
+	if engine == nil {
+		return errors.New("nil engine")
+	}
+
+	g.lock.Lock()
+	routes := g.routes
+	g.lock.Unlock()
+	for _, register := range routes {
+		register(engine)
+	}
+
+	g.SetEngine(engine)
+	g.debugLog("engine reloaded")
+	return nil
+}
+
+// SetEngine atomically swaps engine into every server: the requests in flight complete on the
+// previous engine and the new requests are served by engine. Unlike ReloadEngine, the routes
+// registered by the options are not added to engine. A nil engine serves the gin.Engine given
+// to New again.
+func (g *Graceful) SetEngine(engine *gin.Engine) {
+	if engine == nil {
+		g.active.Store(nil)
+		return
+	}
+	g.active.Store(&servedEngine{engine: engine})
+}
+
+// WithEngine configure a named gin.Engine served behind the same listeners as the other named
+// engines, e.g. a "blue" active engine and a "green" standby one, so that Promote can switch
+// between them. The first named engine serves the requests until the next Promote, and the
+// routes registered on the Graceful instance itself are no longer served.
+func WithEngine(name string, engine *gin.Engine) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if engine == nil {
+			return nil, donothing, errors.New("nil engine")
+		}
+
+		g.lock.Lock()
+		if _, ok := g.engines[name]; ok {
+			g.lock.Unlock()
+			return nil, donothing, fmt.Errorf("duplicate engine %q", name)
+		}
+		if g.engines == nil {
+			g.engines = map[string]*servedEngine{}
+		}
+		e := &servedEngine{name: name, engine: engine}
+		g.engines[name] = e
+		first := len(g.engines) == 1
+		routes := g.routes
+		g.lock.Unlock()
+
+		if engine != g.Engine {
+			for _, register := range routes {
+				register(engine)
+			}
+		}
+		// Only the first named engine is active right away.
+		if first {
+			g.active.Store(e)
+		}
+		return nil, donothing, nil
+	})
+}
+
+// Promote atomically switches the requests to the engine configured with WithEngine under the
+// given name, then waits until ctx is done for the requests still executing on the previously
+// active engine, e.g. to roll a canary out, or back, within a single process.
+func (g *Graceful) Promote(ctx context.Context, name string) error {
+	g.lock.Lock()
+	e, ok := g.engines[name]
+	g.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown engine %q", name)
+	}
+
+	previous := g.active.Swap(e)
+	if previous == nil || previous == e {
+		return nil
+	}
+	g.log().Info("engine promoted", "engine", name, "previous", previous.name)
+
+	for previous.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("engine %q: %d requests still in flight: %w", previous.name, previous.inFlight.Load(), ctx.Err())
+		case <-time.After(drainRoutePollInterval):
+		}
+	}
+	return nil
+}
+
+// ActiveEngine returns the name of the engine serving the requests, empty if it was not
+// configured with WithEngine.
+func (g *Graceful) ActiveEngine() string {
+	if e := g.active.Load(); e != nil {
+		return e.name
+	}
+	return ""
+}
+
+// servedEngine is a gin.Engine swapped in to serve the requests, tracking its requests in flight.
+type servedEngine struct {
+	name     string
+	engine   *gin.Engine
+	inFlight atomic.Int64
+}
+
+// serveEngine serves the request with the active engine, or the gin.Engine given to New.
+func (g *Graceful) serveEngine(w http.ResponseWriter, req *http.Request) {
+	e := g.active.Load()
+	// The request is counted on the engine before serving it, and the engine is loaded again so
+	// that a concurrent Promote either sees the request or has the request served by the new engine.
+	for e != nil {
+		e.inFlight.Add(1)
+		if current := g.active.Load(); current != e {
+			e.inFlight.Add(-1)
+			e = current
+			continue
+		}
+		defer e.inFlight.Add(-1)
+		e.engine.ServeHTTP(w, req)
+		return
+	}
+
+	g.Engine.ServeHTTP(w, req)
+}
+
+// currentEngine returns the engine serving the new requests: the active engine, or the gin.Engine
+// given to New.
+func (g *Graceful) currentEngine() *gin.Engine {
+	if e := g.active.Load(); e != nil {
+		return e.engine
+	}
+	return g.Engine
+}
+
+// addRoutes registers routes on the gin.Engine given to New, on the engines swapped in, if any,
+// and on every engine built by ReloadEngine or configured with WithEngine from then on.
+func (g *Graceful) addRoutes(register func(gin.IRoutes)) {
+	g.lock.Lock()
+	g.routes = append(g.routes, register)
+	engines := []*gin.Engine{g.Engine}
+	for _, e := range g.engines {
+		engines = append(engines, e.engine)
+	}
+	g.lock.Unlock()
+	if e := g.active.Load(); e != nil {
+		engines = append(engines, e.engine)
+	}
+
+	registered := map[*gin.Engine]bool{}
+	for _, engine := range engines {
+		if !registered[engine] {
+			registered[engine] = true
+			register(engine)
+		}
+	}
+}
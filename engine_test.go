@@ -0,0 +1,107 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadEngine(t *testing.T) {
+	version := "v1"
+	var factoryErr error
+	factory := func() (*gin.Engine, error) {
+		if factoryErr != nil {
+			return nil, factoryErr
+		}
+		engine := gin.New()
+		current := version
+		engine.GET("/version", func(c *gin.Context) { c.String(http.StatusOK, current) })
+		return engine, nil
+	}
+
+	router, err := Default(WithEngineFactory(factory), WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.Equal(t, "v1", serveBody(router, "/version"))
+	code, _ := probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	version = "v2"
+	assert.NoError(t, router.ReloadEngine())
+	assert.Equal(t, "v2", serveBody(router, "/version"))
+	code, _ = probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	factoryErr = errors.New("invalid routes")
+	assert.ErrorContains(t, router.ReloadEngine(), "invalid routes")
+	assert.Equal(t, "v2", serveBody(router, "/version"))
+
+	router.SetEngine(nil)
+	router.GET("/version", func(c *gin.Context) { c.String(http.StatusOK, "base") })
+	assert.Equal(t, "base", serveBody(router, "/version"))
+}
+
+func TestReloadEngineWithoutFactory(t *testing.T) {
+	router, err := Default()
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.ReloadEngine(), "no engine factory")
+}
+
+// serveBody returns the body of the response of router to a GET request to path.
+func serveBody(router *Graceful, path string) string {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Body.String()
+}
+
+func TestPromote(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blue := gin.New()
+	blue.GET("/color", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "blue")
+	})
+	green := gin.New()
+	green.GET("/color", func(c *gin.Context) { c.String(http.StatusOK, "green") })
+
+	router, err := Default(WithEngine("blue", blue), WithEngine("green", green), WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.Equal(t, "blue", router.ActiveEngine())
+
+	inFlight := make(chan string)
+	go func() { inFlight <- serveBody(router, "/color") }()
+	<-started
+
+	promoted := make(chan error)
+	go func() { promoted <- router.Promote(context.Background(), "green") }()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "green", router.ActiveEngine())
+	assert.Equal(t, "green", serveBody(router, "/color"))
+	code, _ := probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	select {
+	case <-promoted:
+		t.Fatal("promote returned with a request in flight on the previous engine")
+	default:
+	}
+	close(release)
+	assert.Equal(t, "blue", <-inFlight)
+	assert.NoError(t, <-promoted)
+
+	assert.EqualError(t, router.Promote(context.Background(), "red"), `unknown engine "red"`)
+	_, err = Default(WithEngine("blue", blue), WithEngine("blue", green))
+	assert.EqualError(t, err, `duplicate engine "blue"`)
+}
@@ -0,0 +1,141 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gin-contrib/graceful/lifecycle"
+)
+
+// errorsBuffer is the capacity of the channel returned by Errors.
+const errorsBuffer = 16
+
+// The kinds of the errors returned by RunWithContext, Start, Stop and Shutdown, to be matched with
+// errors.Is while the errors keep their own messages and types, e.g. a *BindError.
+var (
+	// ErrBindFailed matches the errors of a listener that could not be bound.
+	ErrBindFailed = errors.New("bind failed")
+	// ErrServeFailed matches the errors of a server that stopped serving unexpectedly, including
+	// a *PanicError.
+	ErrServeFailed = errors.New("serve failed")
+	// ErrDrainTimeout matches the errors of a server whose drain did not complete before the
+	// shutdown context was done.
+	ErrDrainTimeout = errors.New("drain timeout")
+)
+
+// HookError is returned when a shutdown hook fails, see WithBeforeShutdown and WithAfterShutdown.
+// The hooks are run by the lifecycle package, Phase being the phase of the shutdown the hook ran
+// in, see HookInfo.
+type HookError = lifecycle.HookError
+
+// kindError classifies err as one of the error kinds, without changing its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error implements the error interface.
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind and the classified error.
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// serveError classifies the error a server stopped with as ErrServeFailed, unless it is a bind
+// failure.
+func serveError(err error) error {
+	if errors.Is(err, ErrBindFailed) || errors.Is(err, ErrServeFailed) {
+		return err
+	}
+	return &kindError{kind: ErrServeFailed, err: err}
+}
+
+// drainError classifies the error a server drain ended with as ErrDrainTimeout when the shutdown
+// context was done.
+func drainError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return &kindError{kind: ErrDrainTimeout, err: err}
+	}
+	return err
+}
+
+// ShutdownError is returned when a server could not be drained, one per server, so that the
+// instances with several listeners tell which one failed. It matches ErrDrainTimeout when the
+// shutdown context was done before the drain completed.
+type ShutdownError struct {
+	// Server identifies the server, by component name, label or address.
+	Server string
+	// Err is the error the drain ended with.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown %q: %v", e.Server, e.Err)
+}
+
+// Unwrap returns the error the drain ended with.
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// RestartError is sent on the Errors channel when a server is restarted after an error or a panic.
+type RestartError struct {
+	// Server identifies the restarted server, by component name, label or address.
+	Server string
+	// Restarts is the number of restarts of the server in the current run, this one included.
+	Restarts int
+	// Err is the error the server stopped with, a *PanicError if it panicked.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RestartError) Error() string {
+	return fmt.Sprintf("server %s restarted (%d) after: %v", e.Server, e.Restarts, e.Err)
+}
+
+// Unwrap returns the error the server stopped with.
+func (e *RestartError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns a channel receiving the asynchronous, non-fatal failures of the Graceful
+// instance while it keeps running, such as a *RestartError when a server is restarted, a
+// *HookError when a shutdown hook fails, or a shutdown report that could not be written. The
+// fatal errors are still returned by RunWithContext and Stop. Errors are dropped while the channel
+// is full, so that serving never blocks on its reader. The channel is closed by Close.
+func (g *Graceful) Errors() <-chan error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.errors == nil {
+		g.errors = make(chan error, errorsBuffer)
+	}
+	return g.errors
+}
+
+// notifyError sends err on the Errors channel, if any and not full.
+func (g *Graceful) notifyError(err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.errors == nil {
+		return
+	}
+	select {
+	case g.errors <- err:
+	default:
+	}
+}
+
+// closeErrors closes the Errors channel, if any. The caller must hold g.lock.
+func (g *Graceful) closeErrors() {
+	if g.errors != nil {
+		close(g.errors)
+		g.errors = nil
+	}
+}
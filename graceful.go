@@ -5,10 +5,14 @@ package graceful
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"net"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,16 +23,123 @@ import (
 type Graceful struct {
 	*gin.Engine
 
+	// active is the engine swapped in by SetEngine, ReloadEngine or Promote, if any.
+	active        atomic.Pointer[servedEngine]
+	engines       map[string]*servedEngine
+	engineFactory func() (*gin.Engine, error)
+	routes        []func(gin.IRoutes)
+
 	started context.Context
-	stop    context.CancelFunc
+	stop    context.CancelCauseFunc
+	cancel  context.CancelFunc
+	runCtx  context.Context
 	err     chan error
 
 	lock           sync.Mutex
-	servers        []*http.Server
-	listenAndServe []listenAndServe
+	servers        serverSet
 	cleanup        []cleanup
+	healthChecks   []*healthCheck
+	drainExempt    map[string]struct{}
+	lowPriority    map[*cancelableWriter]struct{}
+	wakeups        map[*wakeup]struct{}
+	streams        map[*streamWriter]struct{}
+	pauseListeners map[*pauseListener]struct{}
+	acceptResumed  chan struct{}
+	certificates   []*certificateFiles
+	configure      []func(*http.Server)
+	preflight      []preflightCheck
+	certSources    []func() []tls.Certificate
+	listeners      []configuredListener
+	registered     []registration
+	closers        []io.Closer
+	databases      []database
+	reloaded       []*reloadEntry
+	errors         chan error
+	draining       atomic.Bool
+
+	drainRoutes        []*drainRoute
+	priorityRoutes     []priorityRoute
+	drainResponse      *DrainResponse
+	drainHeader        http.Header
+	webhook            *shutdownWebhook
+	drainHandler       http.Handler
+	maintenance        atomic.Bool
+	maintenanceHandler http.Handler
+	maintenanceExempt  []string
+	listenConfig       net.ListenConfig
+	tcpKeepAlive       time.Duration
+	trafficStats       bool
+	bandwidth          *bandwidth
+	reaper             *idleReaper
+	maxConnLifetime    time.Duration
+	ipFilter           *ipFilter
+	acceptRate         float64
+	acceptBurst        int
+	startTimeout       time.Duration
+	watchdog           time.Duration
+	watchdogExitCode   int
+	startupSummary     bool
+	loopback           int
+	streamCutoff       time.Duration
+	streamTimer        *time.Timer
+	drainConcurrency   int
+	drainProgress      func(DrainProgress)
+	slowStart          time.Duration
+	slowStartRate      float64
+	panicRestart       *RestartPolicy
+	restartPolicy      *RestartPolicy
+	onServePanic       func(recovered any)
+	onPanic            func(c *gin.Context, recovered any)
+	onServeError       func(addr string, err error)
+	warmup             func(ctx context.Context) error
+	warmupTimeout      time.Duration
+	warm               atomic.Bool
+	warmed             chan struct{}
+	drainStarted       chan struct{}
+	reporters          []func(ShutdownReport)
+	registries         []Registry
+	deregistrationWait time.Duration
+	resourceChecks     *ResourceChecks
+	maxRequests        uint64
+	requests           atomic.Uint64
+	onRecycle          func(reason string)
+	recycling          atomic.Bool
+	recycleReason      string
+	cancelReason       string
+	recycleAction      RecycleAction
+	memoryLimit        uint64
+	restartSchedule    *cronSchedule
+	restartJitter      time.Duration
+	idleTimeout        time.Duration
+	lastRequest        atomic.Int64
+	activeRequests     counter
+	reloadFn           func(ctx context.Context) ([]Option, error)
+	reloadLock         sync.Mutex
+	reloadServes       sync.WaitGroup
+	beforeHooks        []shutdownHook
+	afterHooks         []shutdownHook
+	budget             *ShutdownBudget
+	stopSignal         os.Signal
+	stopCause          error
+	signalInbox        *signalInbox
+	metrics            *otelMetrics
+	statsd             *statsdClient
+	profile            *shutdownProfile
+	logger             Logger
+	debug              bool
+	strict             bool
+	cleanupOnShutdown  bool
+	coordinator        ShutdownCoordinator
+	drainSlots         int
+
+	timingsLock  sync.Mutex
+	timings      Timings
+	servingSince time.Time
 }
 
+// defaultReadHeaderTimeout is the ReadHeaderTimeout of the http.Servers created by the Graceful instance.
+const defaultReadHeaderTimeout = 5 * time.Second
+
 // ErrAlreadyStarted is returned when trying to start a router that has already been started.
 var ErrAlreadyStarted = errors.New("already started router")
 
@@ -36,7 +147,9 @@ var ErrAlreadyStarted = errors.New("already started router")
 var ErrNotStarted = errors.New("router not started")
 
 // listenAndServe is a function type that starts an HTTP server and returns an error if it fails.
-type listenAndServe func() error
+// The given managedServer records the http.Server and listener state, and ctx is canceled once
+// the run ends.
+type listenAndServe func(ctx context.Context, s *managedServer) error
 
 // cleanup is a function type that performs cleanup operations.
 type cleanup func()
@@ -45,7 +158,34 @@ var donothing cleanup = func() {}
 
 // Default returns a Graceful gin instance with the Logger and Recovery middleware already attached.
 func Default(opts ...Option) (*Graceful, error) {
-	return New(gin.Default(), opts...)
+	var g *Graceful
+	engine := gin.New()
+	// The recovery is installed before the options register their routes, and only reads g once
+	// a handler panics.
+	engine.Use(gin.Logger(), gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		g.recovered(c, recovered)
+	}))
+
+	g, err := New(engine, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Recovery returns a gin middleware recovering from the panics of the handlers, like gin.Recovery,
+// which also passes the recovered value to the callback configured with WithOnPanic.
+func (g *Graceful) Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(g.recovered)
+}
+
+// recovered passes the panic recovered from a handler to the WithOnPanic callback, if any, and
+// aborts the request.
+func (g *Graceful) recovered(c *gin.Context, recovered any) {
+	if g.onPanic != nil {
+		g.onPanic(c, recovered)
+	}
+	c.AbortWithStatus(http.StatusInternalServerError)
 }
 
 // New returns a Graceful gin instance from the given gin.Engine.
@@ -54,6 +194,14 @@ func New(router *gin.Engine, opts ...Option) (*Graceful, error) {
 		Engine: router,
 	}
 
+	// Some options, such as the logging ones, are applied first so that they apply to every
+	// other option whatever their position.
+	for _, o := range opts {
+		if e, ok := o.(earlyOption); ok {
+			e.applyEarly(g)
+		}
+	}
+
 	for _, o := range opts {
 		if err := g.apply(o); err != nil {
 			g.Close()
@@ -61,6 +209,11 @@ func New(router *gin.Engine, opts ...Option) (*Graceful, error) {
 		}
 	}
 
+	if err := g.resolveDependencies(); err != nil {
+		g.Close()
+		return nil, err
+	}
+
 	return g, nil
 }
 
@@ -115,61 +268,331 @@ func (g *Graceful) RunListener(listener net.Listener) error {
 }
 
 // RunWithContext attaches the router to the configured http.Server (fallback to configuring one on
-// :8080 if none are configured) and starts listening and serving HTTP requests. If the passed
-// context is canceled, the server is gracefully shut down.
+// :8080 if none are configured, unless in strict mode, see WithStrict) and starts listening and
+// serving HTTP requests. If the passed context is canceled, the server is gracefully shut down,
+// waiting at most the total of the ShutdownBudget, if configured, and RunWithContext returns once
+// the shutdown completes. If a server fails, every other server is gracefully shut down right away
+// and the first error is returned. If the instance recycles itself, e.g. with WithMaxRequests,
+// every server is gracefully shut down and ErrRecycled is returned.
 func (g *Graceful) RunWithContext(ctx context.Context) error {
 	if err := g.ensureAtLeastDefaultServer(); err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
+	if err := g.checkResources(); err != nil {
+		return err
+	}
+	g.debugLog("run started")
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	watched := make(chan struct{})
+	// watchErr is the error of the shutdown of a canceled run, set before watched is closed.
+	var watchErr error
 	go func() {
+		defer close(watched)
 		<-ctx.Done()
-		_ = g.Shutdown(ctx)
+		if parent.Err() != nil {
+			g.lock.Lock()
+			g.stopSignal = signalOf(parent)
+			g.stopCause = causeOf(parent)
+			g.lock.Unlock()
+			// The run context is done, the shutdown gets its own, see shutdownContext.
+			shutdownCtx, cancel := g.shutdownContext(context.WithoutCancel(parent))
+			defer cancel()
+			watchErr = g.shutdown(shutdownCtx, "context done")
+			return
+		}
+		// The run was canceled because a server failed, by ShutdownNow, or to recycle or stop
+		// the instance, the remaining servers are drained.
+		reason := "server failed"
+		if canceled := g.canceledReason(); canceled != "" {
+			reason = canceled
+		}
+		watchErr = g.shutdown(context.Background(), reason)
+	}()
+	// Every goroutine of the run is released before RunWithContext returns, so that repeated runs
+	// do not accumulate them.
+	var background sync.WaitGroup
+	goBackground := func(fn func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			fn(ctx)
+		}()
+	}
+	defer func() {
+		cancel()
+		<-watched
+		g.reloadServes.Wait()
+		background.Wait()
 	}()
-	defer cancel()
 
 	eg := errgroup.Group{}
+	start := time.Now()
+
+	g.timingsLock.Lock()
+	g.timings = Timings{}
+	g.servingSince = time.Time{}
+	g.timingsLock.Unlock()
 
 	g.lock.Lock()
 
-	for _, srv := range g.listenAndServe {
+	g.cancel = cancel
+	g.runCtx = ctx
+	g.requests.Store(0)
+	g.recycling.Store(false)
+	g.recycleReason = ""
+	g.cancelReason = ""
+	g.stopSignal = nil
+	g.stopCause = nil
+	// A stream cutoff still pending from the previous run must not cut off the streams of this one.
+	if g.streamTimer != nil {
+		g.streamTimer.Stop()
+		g.streamTimer = nil
+	}
+	g.lastRequest.Store(time.Now().UnixNano())
+	g.warm.Store(false)
+	g.warmed = make(chan struct{})
+	g.draining.Store(false)
+	g.drainStarted = make(chan struct{})
+	drainStarted := g.drainStarted
+	servers := g.servers.load()
+	for _, srv := range servers {
+		srv.reset()
+	}
+	for _, srv := range servers {
 		safeCopy := srv
 		eg.Go(func() error {
-			if err := safeCopy(); err != nil && err != http.ErrServerClosed {
-				return err
-			}
-			return nil
+			return g.serve(ctx, cancel, safeCopy)
 		})
 	}
 
 	g.lock.Unlock()
 
-	if err := waitWithContext(ctx, &eg); err != nil {
+	goBackground(func(ctx context.Context) { g.measureBind(ctx, start, servers) })
+	if g.startupSummary {
+		goBackground(func(ctx context.Context) { g.logStartupSummary(ctx, start, servers) })
+	}
+	eg.Go(func() error {
+		return g.warmUp(ctx, cancel, servers)
+	})
+	eg.Go(func() error {
+		return g.register(ctx, cancel, servers)
+	})
+	if g.startTimeout > 0 {
+		eg.Go(func() error {
+			return g.watchStart(ctx, servers)
+		})
+	}
+	if g.memoryLimit > 0 {
+		goBackground(g.watchMemory)
+	}
+	if g.restartSchedule != nil {
+		goBackground(g.watchSchedule)
+	}
+	if g.idleTimeout > 0 {
+		goBackground(g.watchIdle)
+	}
+	if g.reaper != nil {
+		goBackground(g.watchIdleConns)
+	}
+	if g.reloadFn != nil {
+		// The servers come and go with the reloads, so the run lasts until it is shut down.
+		eg.Go(func() error {
+			g.watchReload(ctx, drainStarted)
+			return nil
+		})
+	}
+
+	if err := waitWithContext(parent, &eg); err != nil {
 		return err
 	}
+	if reason := g.recycledReason(); reason != "" {
+		// The recycle action runs once the servers are drained.
+		<-watched
+		return g.recycled(reason)
+	}
+	if g.canceledReason() != "" {
+		// The run was canceled to stop the instance, e.g. once handed off, the error of its
+		// shutdown is returned.
+		<-watched
+		return watchErr
+	}
 	return g.Shutdown(ctx)
 }
 
 // Shutdown gracefully shuts down the server without interrupting any active connections.
 func (g *Graceful) Shutdown(ctx context.Context) error {
+	return g.shutdown(ctx, "shutdown")
+}
+
+// ShutdownWithTimeout gracefully shuts down the server, waiting at most d for the active
+// connections.
+func (g *Graceful) ShutdownWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return g.Shutdown(ctx)
+}
+
+// shutdown gracefully shuts down every server for the given reason, and reports the shutdown
+// if at least one server was running.
+func (g *Graceful) shutdown(ctx context.Context, reason string) error {
+	var (
+		hookErr   error
+		hooksTime time.Duration
+	)
+
+	g.debugLog("shutdown invoked", "reason", reason)
+	report := ShutdownReport{Reason: reason, StartedAt: time.Now()}
+	g.lock.Lock()
+	if g.stopCause != nil {
+		report.Cause = g.stopCause.Error()
+	}
+	g.lock.Unlock()
+	plan := g.planShutdown(ctx, report.StartedAt)
+
+	servers := g.servers.load()
+	// The hooks and profiles only run when the shutdown stops a running instance.
+	stopping := false
+	for _, srv := range servers {
+		stopping = stopping || srv.running()
+	}
+	if stopping {
+		defer g.startWatchdog(reason)()
+	}
+
+	beforeCtx, cancel := plan.context(ctx, budgetBefore)
+	holding := false
+	if stopping && g.coordinator != nil {
+		var phase ShutdownPhase
+		phase, holding = g.acquireDrain(beforeCtx)
+		report.Phases = append(report.Phases, phase)
+	}
+	report.Phases = append(report.Phases, g.deregister(beforeCtx)...)
+	if stopping {
+		hooksStart := time.Now()
+		phases, e := g.runHooks(beforeCtx, reason, "before shutdown", g.beforeHooks)
+		hooksTime += time.Since(hooksStart)
+		report.Phases = append(report.Phases, phases...)
+		hookErr = e
+	}
+	cancel()
+	g.startDrain()
+	if stopping {
+		g.captureProfiles("drain", true)
+	}
+
+	// The drain routes are waited for within the share of the drain, once the drain started.
+	drainCtx, cancel := plan.context(ctx, budgetDrain)
+	defer cancel()
+	report.Phases = append(report.Phases, g.waitDrainRoutes(drainCtx)...)
+
+	drainStart := time.Now()
+	phases, err := g.drainServers(drainCtx, servers)
+	drainTime := time.Since(drainStart)
+	report.Phases = append(report.Phases, phases...)
+	if holding {
+		report.Phases = append(report.Phases, g.releaseDrain(ctx))
+	}
+
+	if errors.Is(err, ErrDrainTimeout) {
+		g.captureProfiles("timeout", false)
+	}
+
+	if stopping {
+		afterCtx, cancel := plan.afterContext(ctx)
+		hooksStart := time.Now()
+		phases, e := g.runHooks(afterCtx, reason, "after shutdown", g.afterHooks)
+		hooksTime += time.Since(hooksStart)
+		cancel()
+		report.Phases = append(report.Phases, phases...)
+		hookErr = errors.Join(hookErr, e)
+	}
+	if stopping && len(g.databases) > 0 {
+		closeCtx, cancel := plan.afterContext(ctx)
+		phases, e := g.closeDatabases(closeCtx)
+		cancel()
+		report.Phases = append(report.Phases, phases...)
+		err = errors.Join(err, e)
+	}
+
+	if hookErr != nil {
+		err = errors.Join(err, hookErr)
+	}
+	if len(report.Phases) > 0 {
+		report.Duration = time.Since(report.StartedAt)
+		g.measureShutdown(report.StartedAt, drainTime, hooksTime)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		g.report(report)
+		if stopping && g.webhook != nil {
+			afterCtx, cancel := plan.afterContext(ctx)
+			if e := g.postReport(afterCtx, report); e != nil {
+				err = errors.Join(err, e)
+			}
+			cancel()
+		}
+		g.recordShutdown(reason, report.Duration)
+		g.log().Info("shutdown complete", "reason", reason, "duration", report.Duration)
+		g.syncLogger()
+	}
+
+	return err
+}
+
+// ShutdownNow immediately closes every server and their active connections, without draining
+// them, and cancels the background tasks of the run, such as the warmup and the pending restarts.
+// It is meant for emergency stops, e.g. on a second interrupt signal.
+func (g *Graceful) ShutdownNow() error {
 	var err error
 
+	g.debugLog("shutdown invoked", "reason", "shutdown now")
+	g.startDrain()
+	report := ShutdownReport{Reason: "shutdown now", StartedAt: time.Now()}
+
+	servers := g.servers.load()
 	g.lock.Lock()
-	defer g.lock.Unlock()
+	cancel := g.cancel
+	g.lock.Unlock()
+
+	for _, srv := range servers {
+		start := time.Now()
+		running, e := srv.close()
+		if !running {
+			continue
+		}
 
-	for _, srv := range g.servers {
-		if e := srv.Shutdown(ctx); e != nil {
+		phase := ShutdownPhase{Name: "close " + srv.label(), Duration: time.Since(start)}
+		if e != nil {
 			err = e
+			phase.Error = e.Error()
 		}
+		report.Phases = append(report.Phases, phase)
+	}
+	if cancel != nil {
+		cancel()
+	}
+
+	if len(report.Phases) > 0 {
+		report.Duration = time.Since(report.StartedAt)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		g.report(report)
+		g.recordShutdown(report.Reason, report.Duration)
+		g.log().Info("shutdown complete", "reason", report.Reason, "duration", report.Duration)
+		g.syncLogger()
 	}
-	g.servers = nil
 
 	return err
 }
 
 // Start will start the Graceful instance and all underlying http.Servers in a separate
-// goroutine and return right away. You must call Stop and not Shutdown if you use Start.
+// goroutine and return right away. You must call Stop or StopWithContext and not Shutdown if you
+// use Start.
 func (g *Graceful) Start() error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
@@ -178,15 +601,17 @@ func (g *Graceful) Start() error {
 		return ErrAlreadyStarted
 	}
 
-	g.err = make(chan error)
+	chErr := make(chan error, 1)
 	ctxStarted, cancel := context.WithCancel(context.Background())
-	ctx, cancelStop := context.WithCancel(context.Background())
+	ctx, cancelStop := context.WithCancelCause(context.Background())
 	go func() {
 		err := g.RunWithContext(ctx)
 		cancel()
-		g.err <- err
+		chErr <- err
 	}()
 
+	g.err = chErr
+
 	g.stop = cancelStop
 	g.started = ctxStarted
 
@@ -196,29 +621,74 @@ func (g *Graceful) Start() error {
 // Stop will stop the Graceful instance previously started with Start. It
 // will return once the instance has been stopped.
 func (g *Graceful) Stop() error {
-	resetStartedState := func() (context.Context, context.CancelFunc, chan error, error) {
-		g.lock.Lock()
-		defer g.lock.Unlock()
-
-		if g.started == nil {
-			return nil, nil, nil, ErrNotStarted
-		}
+	started, stop, chErr, err := g.resetStartedState()
+	if err != nil {
+		return err
+	}
 
-		stop := g.stop
-		started := g.started
-		chErr := g.err
-		g.stop = nil
-		g.started = nil
+	stop(nil)
+	return stopped(started, <-chErr)
+}
 
-		return started, stop, chErr, nil
+// StopWithCause will stop the Graceful instance previously started with Start, like Stop, recording
+// cause as the reason why it stopped: the cause is passed to the shutdown hooks, see HookInfo, and
+// recorded in the shutdown report, e.g. a failed dependency or a lost leadership. A run with
+// RunWithContext records the cause of its context the same way, see context.WithCancelCause.
+func (g *Graceful) StopWithCause(cause error) error {
+	started, stop, chErr, err := g.resetStartedState()
+	if err != nil {
+		return err
 	}
-	started, stop, chErr, err := resetStartedState()
+
+	stop(cause)
+	return stopped(started, <-chErr)
+}
+
+// StopWithContext will gracefully shut down the Graceful instance previously started with Start,
+// waiting for active connections until ctx is done, then stop it. It returns the context error if
+// ctx is done before the instance has been stopped.
+func (g *Graceful) StopWithContext(ctx context.Context) error {
+	started, stop, chErr, err := g.resetStartedState()
 	if err != nil {
 		return err
 	}
 
-	stop()
-	err = <-chErr
+	shutdownErr := g.shutdown(ctx, "stop")
+	stop(nil)
+
+	select {
+	case err = <-chErr:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err = stopped(started, err); err != nil {
+		return err
+	}
+	return shutdownErr
+}
+
+// resetStartedState resets the state recorded by Start, and returns the context canceled once
+// the run returns, the function stopping the run and the channel receiving the run error.
+func (g *Graceful) resetStartedState() (context.Context, context.CancelCauseFunc, chan error, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.started == nil {
+		return nil, nil, nil, ErrNotStarted
+	}
+
+	stop := g.stop
+	started := g.started
+	chErr := g.err
+	g.stop = nil
+	g.started = nil
+
+	return started, stop, chErr, nil
+}
+
+// stopped waits for the run started with Start to return and returns its error, ignoring the
+// cancellation of the run by Stop.
+func stopped(started context.Context, err error) error {
 	<-started.Done()
 
 	if !errors.Is(err, context.Canceled) {
@@ -235,7 +705,8 @@ func (g *Graceful) Stop() error {
 
 // Close gracefully shuts down the server.
 // It first shuts down the server using the Shutdown method,
-// then it performs any cleanup operations registered with the server.
+// then it performs any cleanup operations registered with the server, unless already
+// performed by the shutdown, see WithCleanupOnShutdown.
 // Finally, it resets the server's internal state.
 func (g *Graceful) Close() {
 	_ = g.Shutdown(context.Background())
@@ -243,66 +714,124 @@ func (g *Graceful) Close() {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	start := time.Now()
 	for _, c := range g.cleanup {
 		c()
 	}
+	for _, e := range g.reloaded {
+		e.cleanup()
+	}
+	g.measureCleanup(time.Since(start))
+	g.debugLog("cleanup run", "cleanups", len(g.cleanup)+len(g.reloaded))
 
+	g.runClosers()
+	g.closeErrors()
 	g.cleanup = nil
-	g.listenAndServe = nil
-	g.servers = nil
+	g.reloaded = nil
+	g.servers.clear()
+	g.listeners = nil
+	g.preflight = nil
+	g.certSources = nil
 }
 
 // apply applies the given option to the Graceful instance.
 // It creates a new server, applies the option to it, and adds the server and cleanup function to the Graceful instance.
+// Options that only configure the Graceful instance return no server and are not added.
 // If an error occurs during the application of the option, it returns the error.
 func (g *Graceful) apply(o Option) error {
 	srv, cleanup, err := o.apply(g)
 	if err != nil {
+		g.debugLog("option failed", "option", optionName(o), "error", err)
 		return err
 	}
-	g.listenAndServe = append(g.listenAndServe, srv)
+	g.debugLog("option applied", "option", optionName(o))
+	if srv != nil {
+		s := &managedServer{run: srv, release: sync.OnceFunc(cleanup)}
+		g.servers.add(s)
+		cleanup = s.release
+	}
 	g.cleanup = append(g.cleanup, cleanup)
 	return nil
 }
 
-// appendHTTPServer appends a new HTTP server to the list of servers managed by the Graceful instance.
+// appendHTTPServer creates a new HTTP server and records it as the http.Server of s.
 // It returns the newly created http.Server.
-func (g *Graceful) appendHTTPServer() *http.Server {
+func (g *Graceful) appendHTTPServer(s *managedServer) *http.Server {
 	srv := &http.Server{
-		Handler:           g.Engine,
-		ReadHeaderTimeout: time.Second * 5, // Set a reasonable ReadHeaderTimeout value
+		Handler:           g,
+		ReadHeaderTimeout: defaultReadHeaderTimeout, // Set a reasonable ReadHeaderTimeout value
+		ConnState:         s.trackConn(g.recordConnState(s, g.trackIdle(nil))),
+		BaseContext:       g.baseContext(nil),
+		ConnContext:       g.connContext(nil),
 	}
+	g.configureServer(srv)
 
-	g.lock.Lock()
-	defer g.lock.Unlock()
-	g.servers = append(g.servers, srv)
+	s.setServer(srv)
 
 	return srv
 }
 
-// appendExistHTTPServer appends an existing HTTP server to the list of servers managed by the Graceful instance.
-// This allows for customization of the http.Server, and srv.Handler will be set to the current g.Engine.
-func (g *Graceful) appendExistHTTPServer(srv *http.Server) {
-	srv.Handler = g.Engine
+// appendExistHTTPServer records an existing HTTP server as the http.Server of s.
+// This allows for customization of the http.Server, and srv.Handler will be set to g, serving the current g.Engine.
+// The connection states are tracked before being passed on to connState, the original srv.ConnState,
+// and the Graceful instance is recorded in the context returned by baseContext, the original srv.BaseContext.
+// The connection contexts record the accept time of the connections on top of connContext, the original
+// srv.ConnContext.
+func (g *Graceful) appendExistHTTPServer(
+	s *managedServer,
+	srv *http.Server,
+	connState func(net.Conn, http.ConnState),
+	baseContext func(net.Listener) context.Context,
+	connContext func(context.Context, net.Conn) context.Context,
+) {
+	srv.Handler = g
+	srv.ConnState = s.trackConn(g.recordConnState(s, g.trackIdle(connState)))
+	srv.BaseContext = g.baseContext(baseContext)
+	srv.ConnContext = g.connContext(connContext)
+	g.configureServer(srv)
+
+	s.setServer(srv)
+}
 
+// ConfigureServers registers fn to be applied to every http.Server of the Graceful instance when
+// it is created, so that cross-cutting settings, such as TLSNextProto or the HTTP/2 tuning, need
+// not require WithServer. It applies to the servers created from the next run on. The address and
+// TLS configuration of the options are set after fn is applied, and fn must not change the
+// Handler, ConnState and ConnContext of the server.
+func (g *Graceful) ConfigureServers(fn func(*http.Server)) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
-	g.servers = append(g.servers, srv)
+
+	g.configure = append(g.configure, fn)
+}
+
+// configureServer applies the functions registered with ConfigureServers to srv.
+func (g *Graceful) configureServer(srv *http.Server) {
+	g.lock.Lock()
+	configure := g.configure
+	g.lock.Unlock()
+
+	for _, fn := range configure {
+		fn(srv)
+	}
 }
 
 // ensureAtLeastDefaultServer ensures that there is at least one server running with the default address ":8080".
 // If no server is running, it creates a new server with the default address and starts it.
-// It returns an error if there was a problem creating or starting the server.
+// It returns an error if there was a problem creating or starting the server, or ErrNoListener in strict mode.
 func (g *Graceful) ensureAtLeastDefaultServer() error {
 	g.lock.Lock()
-	defer g.lock.Unlock()
+	configured := len(g.servers.load()) > 0
+	g.lock.Unlock()
 
-	if len(g.listenAndServe) == 0 {
-		if err := g.apply(WithAddr(":8080")); err != nil {
-			return err
-		}
+	if configured {
+		return nil
 	}
-	return nil
+	if g.strict {
+		return ErrNoListener
+	}
+	// The option records its listener under g.lock.
+	return g.apply(WithAddr(":8080"))
 }
 
 // waitWithContext waits for the completion of the errgroup.Group and returns any error encountered.
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -150,12 +151,260 @@ func TestSimpleCycle(t *testing.T) {
 	}
 }
 
+func TestShutdownWithTimeout(t *testing.T) {
+	router, err := Default(WithAddr(":8108"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	release := make(chan struct{})
+	router.GET("/example", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	go func() {
+		assert.NoError(t, router.RunWithContext(context.Background()))
+	}()
+
+	requested := make(chan struct{})
+	go func() {
+		defer close(requested)
+		testRequest(t, "http://localhost:8108/example")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	assert.ErrorIs(t, router.ShutdownWithTimeout(50*time.Millisecond), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(release)
+	<-requested
+}
+
+func TestStopWithContext(t *testing.T) {
+	router, err := Default(WithAddr(":8108"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.ErrorIs(t, router.StopWithContext(context.Background()), ErrNotStarted)
+
+	release := make(chan struct{})
+	router.GET("/example", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	assert.NoError(t, router.Start())
+	requested := make(chan struct{})
+	go func() {
+		defer close(requested)
+		testRequest(t, "http://localhost:8108/example")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, router.StopWithContext(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, router.StopWithContext(context.Background()), ErrNotStarted)
+
+	close(release)
+	<-requested
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, router.StopWithContext(context.Background()))
+}
+
+func TestStopWithCause(t *testing.T) {
+	cause := errors.New("leadership lost")
+	var info HookInfo
+	var report ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8144"),
+		WithBeforeShutdown("before", func(ctx context.Context) error {
+			info, _ = HookInfoFromContext(ctx)
+			return nil
+		}),
+		WithShutdownReportFunc(func(r ShutdownReport) { report = r }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.ErrorIs(t, router.StopWithCause(cause), ErrNotStarted)
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.StopWithCause(cause))
+	assert.Equal(t, "context done", info.Reason)
+	assert.Equal(t, cause, info.Cause)
+	assert.Equal(t, "leadership lost", report.Cause)
+
+	// A plain stop has no cause.
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+	assert.NoError(t, info.Cause)
+	assert.Empty(t, report.Cause)
+}
+
+func TestShutdownNow(t *testing.T) {
+	warmupCanceled := make(chan struct{})
+	router, err := Default(
+		WithAddr(":8108"),
+		WithWarmup(func(ctx context.Context) error {
+			<-ctx.Done()
+			close(warmupCanceled)
+			return nil
+		}, 0),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	router.GET("/example", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	requested := make(chan error)
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8108/example", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		requested <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(t, router.ShutdownNow())
+	assert.Error(t, <-requested)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("run did not stop")
+	}
+	<-warmupCanceled
+	assert.Equal(t, ServerStopped, router.ServerStatus()[0].State)
+}
+
+func TestConfigureServers(t *testing.T) {
+	router, err := Default(WithAddr(":8108"), WithServer(&http.Server{Addr: ":8109", ReadHeaderTimeout: time.Second}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var lock sync.Mutex
+	configured := 0
+	router.ConfigureServers(func(srv *http.Server) {
+		lock.Lock()
+		configured++
+		lock.Unlock()
+		srv.SetKeepAlivesEnabled(false)
+	})
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	defer func() { assert.NoError(t, router.Stop()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	for _, url := range []string{"http://localhost:8108/example", "http://localhost:8109/example"} {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.True(t, resp.Close)
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 2, configured)
+}
+
+func TestWithStrict(t *testing.T) {
+	router, err := Default(WithStrict())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.ErrorIs(t, router.Run(), ErrNoListener)
+	assert.NoError(t, router.Start())
+	assert.ErrorIs(t, router.Stop(), ErrNoListener)
+	assert.Empty(t, router.ServerStatus())
+}
+
+func TestServerFlags(t *testing.T) {
+	router, err := Default(WithDisableGeneralOptionsHandler(), WithoutHTTP2())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	srv := &http.Server{}
+	router.configureServer(srv)
+	assert.True(t, srv.DisableGeneralOptionsHandler)
+	assert.NotNil(t, srv.TLSNextProto)
+	assert.Empty(t, srv.TLSNextProto)
+}
+
 func TestWithTLS(t *testing.T) {
 	testRouterConstructor(t, func() (*Graceful, error) {
 		return Default(WithTLS(":8443", "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"))
 	}, "https://localhost:8443/example")
 }
 
+func TestWithTLSCertificates(t *testing.T) {
+	localhost, err := tls.LoadX509KeyPair("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")
+	assert.NoError(t, err)
+	example, err := tls.LoadX509KeyPair("./testdata/certificate/example-cert.pem", "./testdata/certificate/example-key.pem")
+	assert.NoError(t, err)
+
+	_, err = Default(WithTLSCertificates(":8444"))
+	assert.Error(t, err)
+
+	router, err := Default(WithTLSCertificates(":8444", localhost, example))
+	assert.NoError(t, err)
+	assert.NotNil(t, router)
+	defer router.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+		assert.NoError(t, router.RunWithContext(ctx))
+		cancel()
+	}()
+
+	testRequest(t, "https://localhost:8444/example")
+
+	pemData, err := os.ReadFile("./testdata/certificate/example-cert.pem")
+	assert.NoError(t, err)
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(pemData)
+
+	conn, err := tls.Dial("tcp", "localhost:8444", &tls.Config{
+		ServerName: "example.com",
+		RootCAs:    caCertPool,
+		MinVersion: tls.VersionTLS12,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"example.com"}, conn.ConnectionState().PeerCertificates[0].DNSNames)
+		conn.Close()
+	}
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+
+	<-ctx.Done()
+}
+
 func TestWithFd(t *testing.T) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	assert.NoError(t, err)
@@ -187,6 +436,24 @@ func TestWithListener(t *testing.T) {
 	}, fmt.Sprintf("http://localhost:%d/example", listener.Addr().(*net.TCPAddr).Port))
 }
 
+func TestWithListenerFactory(t *testing.T) {
+	testRouterConstructor(t, func() (*Graceful, error) {
+		return Default(WithListenerFactory(func(ctx context.Context) (net.Listener, error) {
+			return net.Listen("tcp", "localhost:8102")
+		}))
+	}, "http://localhost:8102/example")
+}
+
+func TestWithListenerFactoryError(t *testing.T) {
+	router, err := Default(WithListenerFactory(func(ctx context.Context) (net.Listener, error) {
+		return nil, errors.New("no transport")
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "no transport")
+}
+
 func TestWithServer(t *testing.T) {
 	cert, err := tls.LoadX509KeyPair("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")
 	assert.NoError(t, err)
@@ -0,0 +1,24 @@
+package gracefultest_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/gin-contrib/graceful/gracefultest"
+)
+
+func ExampleBuilder() {
+	var build gracefultest.Builder = func(opts ...graceful.Option) (*graceful.Graceful, error) {
+		return graceful.Default(append(opts, graceful.WithDrainRoutes("/upload/*", time.Second))...)
+	}
+
+	g, err := build(graceful.WithListener(gracefultest.NewListener()))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	fmt.Println("built")
+	// Output: built
+}
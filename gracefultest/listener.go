@@ -0,0 +1,84 @@
+package gracefultest
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// memoryAddr is the address of a Listener.
+type memoryAddr struct{}
+
+func (memoryAddr) Network() string { return "memory" }
+func (memoryAddr) String() string  { return "memory" }
+
+// Listener is an in-memory net.Listener whose connections are dialed with Dial, so that a test
+// serves a Graceful instance without binding a port.
+type Listener struct {
+	conns chan net.Conn
+
+	once   sync.Once
+	closed chan struct{}
+
+	mu     sync.Mutex
+	dialed map[net.Conn]struct{}
+}
+
+// NewListener returns an in-memory Listener.
+func NewListener() *Listener {
+	return &Listener{conns: make(chan net.Conn), closed: make(chan struct{}), dialed: map[net.Conn]struct{}{}}
+}
+
+// Accept waits for and returns the next connection dialed with Dial.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes the listener, the connections dialed from then on are refused.
+func (l *Listener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr returns the address of the listener.
+func (l *Listener) Addr() net.Addr {
+	return memoryAddr{}
+}
+
+// Dial returns the client end of a new connection, once accepted. It fails with net.ErrClosed if
+// the listener is closed, or if ctx is done first.
+func (l *Listener) Dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		l.mu.Lock()
+		l.dialed[server] = struct{}{}
+		l.mu.Unlock()
+		return client, nil
+	case <-l.closed:
+		_ = client.Close()
+		_ = server.Close()
+		return nil, &net.OpError{Op: "dial", Net: "memory", Err: net.ErrClosed}
+	case <-ctx.Done():
+		_ = client.Close()
+		_ = server.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Kill closes every connection dialed with Dial, as if the process serving them exited, e.g. once
+// the drain timed out.
+func (l *Listener) Kill() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for conn := range l.dialed {
+		_ = conn.Close()
+	}
+	l.dialed = map[net.Conn]struct{}{}
+}
@@ -0,0 +1,353 @@
+// Package gracefultest provides helpers to test the shutdown behavior of graceful.Graceful
+// instances, such as DrainScenario.
+package gracefultest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/gin-gonic/gin"
+)
+
+// The paths of the synthetic routes registered by a DrainScenario.
+const (
+	slowPath    = "/gracefultest/slow"
+	upgradePath = "/gracefultest/upgrade"
+)
+
+// defaultShutdownTimeout is the default timeout of the shutdown triggered by a DrainScenario.
+const defaultShutdownTimeout = 5 * time.Second
+
+// settleDelay is the time given to the requests to reach their handlers before the shutdown.
+const settleDelay = 20 * time.Millisecond
+
+// Builder builds the Graceful instance under test with the given options, which serve it on an
+// in-memory listener, e.g.
+//
+//	func(opts ...graceful.Option) (*graceful.Graceful, error) {
+//		return graceful.Default(append(opts, graceful.WithDrainRoutes("/upload/*", time.Second))...)
+//	}
+type Builder func(opts ...graceful.Option) (*graceful.Graceful, error)
+
+// DrainResult is the outcome of a DrainScenario.
+type DrainResult struct {
+	// Completed is the number of requests in flight when the shutdown started that completed
+	// with a 2xx response.
+	Completed int
+	// Failed is the number of requests in flight when the shutdown started that failed, with a
+	// connection error or a response other than 2xx, e.g. when they were still in flight once the
+	// drain timed out.
+	Failed int
+	// Rejected is the number of requests sent once the drain started that were refused, or
+	// answered with 503 Service Unavailable.
+	Rejected int
+	// Order is the index of every slow request, in the order they completed.
+	Order []int
+	// UpgradesClosed is the number of upgraded connections closed by the server.
+	UpgradesClosed int
+	// Duration is how long the shutdown took.
+	Duration time.Duration
+	// Err is the error the shutdown returned.
+	Err error
+}
+
+// DrainScenario spins up a Graceful instance on an in-memory listener, launches synthetic slow
+// requests and upgraded connections, such as WebSockets, triggers its shutdown, and asserts on the
+// outcome, so that an application can regression-test its shutdown configuration, e.g.
+//
+//	gracefultest.NewDrainScenario(build).
+//		SlowRequests(100*time.Millisecond, 200*time.Millisecond).
+//		LateRequests(3).
+//		ExpectCompleted(2).
+//		ExpectRejected(3).
+//		ExpectShutdownWithin(time.Second).
+//		Run(t)
+type DrainScenario struct {
+	build    Builder
+	slow     []time.Duration
+	paths    []string
+	upgrades int
+	late     int
+	timeout  time.Duration
+
+	expectations []func(t testing.TB, r DrainResult)
+}
+
+// NewDrainScenario returns a DrainScenario of the Graceful instance built by build.
+func NewDrainScenario(build Builder) *DrainScenario {
+	return &DrainScenario{build: build, timeout: defaultShutdownTimeout}
+}
+
+// SlowRequests launches one synthetic request per given duration, each taking that long to
+// complete, before the shutdown.
+func (s *DrainScenario) SlowRequests(durations ...time.Duration) *DrainScenario {
+	s.slow = append(s.slow, durations...)
+	return s
+}
+
+// Requests launches a GET request to every given path of the application before the shutdown.
+func (s *DrainScenario) Requests(paths ...string) *DrainScenario {
+	s.paths = append(s.paths, paths...)
+	return s
+}
+
+// Upgrades opens n synthetic upgraded connections, such as WebSockets, before the shutdown. The
+// server closes them once the drain starts, see graceful.Graceful.RegisterWakeup.
+func (s *DrainScenario) Upgrades(n int) *DrainScenario {
+	s.upgrades += n
+	return s
+}
+
+// LateRequests sends n synthetic requests once the drain started.
+func (s *DrainScenario) LateRequests(n int) *DrainScenario {
+	s.late += n
+	return s
+}
+
+// ShutdownTimeout sets the timeout of the shutdown. Defaults to five seconds.
+func (s *DrainScenario) ShutdownTimeout(d time.Duration) *DrainScenario {
+	s.timeout = d
+	return s
+}
+
+// ExpectCompleted asserts that n requests in flight completed.
+func (s *DrainScenario) ExpectCompleted(n int) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if r.Completed != n {
+			t.Errorf("gracefultest: %d requests completed, want %d", r.Completed, n)
+		}
+	})
+}
+
+// ExpectFailed asserts that n requests in flight failed.
+func (s *DrainScenario) ExpectFailed(n int) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if r.Failed != n {
+			t.Errorf("gracefultest: %d requests failed, want %d", r.Failed, n)
+		}
+	})
+}
+
+// ExpectRejected asserts that n late requests were rejected.
+func (s *DrainScenario) ExpectRejected(n int) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if r.Rejected != n {
+			t.Errorf("gracefultest: %d late requests rejected, want %d", r.Rejected, n)
+		}
+	})
+}
+
+// ExpectOrder asserts that the slow requests completed in the given order of their indexes.
+func (s *DrainScenario) ExpectOrder(indexes ...int) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if fmt.Sprint(r.Order) != fmt.Sprint(indexes) {
+			t.Errorf("gracefultest: slow requests completed in order %v, want %v", r.Order, indexes)
+		}
+	})
+}
+
+// ExpectShutdownWithin asserts that the shutdown took at most d.
+func (s *DrainScenario) ExpectShutdownWithin(d time.Duration) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if r.Duration > d {
+			t.Errorf("gracefultest: shutdown took %v, want at most %v", r.Duration, d)
+		}
+	})
+}
+
+// ExpectShutdownError asserts whether the shutdown returned an error.
+func (s *DrainScenario) ExpectShutdownError(want bool) *DrainScenario {
+	return s.expect(func(t testing.TB, r DrainResult) {
+		if (r.Err != nil) != want {
+			t.Errorf("gracefultest: shutdown returned %v, want an error: %t", r.Err, want)
+		}
+	})
+}
+
+// expect adds an assertion on the result.
+func (s *DrainScenario) expect(fn func(t testing.TB, r DrainResult)) *DrainScenario {
+	s.expectations = append(s.expectations, fn)
+	return s
+}
+
+// Run runs the scenario, asserts on its outcome, and returns it.
+func (s *DrainScenario) Run(t testing.TB) DrainResult {
+	t.Helper()
+
+	l := NewListener()
+	g, err := s.build(graceful.WithListener(l))
+	if err != nil {
+		t.Fatalf("gracefultest: build: %v", err)
+	}
+	defer g.Close()
+
+	var started sync.WaitGroup
+	s.registerRoutes(g, &started)
+
+	done := make(chan error, 1)
+	go func() { done <- g.RunWithContext(context.Background()) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext:       func(ctx context.Context, _, _ string) (net.Conn, error) { return l.Dial(ctx) },
+		DisableKeepAlives: true,
+	}}
+
+	var (
+		mu       sync.Mutex
+		result   DrainResult
+		inFlight sync.WaitGroup
+	)
+	record := func(ok bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ok {
+			result.Completed++
+		} else {
+			result.Failed++
+		}
+	}
+
+	started.Add(len(s.slow) + s.upgrades)
+	for i, d := range s.slow {
+		inFlight.Add(1)
+		go func(i int, d time.Duration) {
+			defer inFlight.Done()
+			ok := get(client, fmt.Sprintf("http://memory%s?d=%s", slowPath, d)) == nil
+			record(ok)
+			if ok {
+				mu.Lock()
+				result.Order = append(result.Order, i)
+				mu.Unlock()
+			}
+		}(i, d)
+	}
+	for _, path := range s.paths {
+		inFlight.Add(1)
+		go func(path string) {
+			defer inFlight.Done()
+			record(get(client, "http://memory"+path) == nil)
+		}(path)
+	}
+	for i := 0; i < s.upgrades; i++ {
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			if upgrade(l) {
+				mu.Lock()
+				result.UpgradesClosed++
+				mu.Unlock()
+			}
+		}()
+	}
+	started.Wait()
+	time.Sleep(settleDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	start := time.Now()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- g.Shutdown(ctx) }()
+
+	for !g.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < s.late; i++ {
+		if err := get(client, "http://memory"+slowPath); err != nil {
+			result.Rejected++
+		}
+	}
+
+	result.Err = <-shutdown
+	result.Duration = time.Since(start)
+	// The process would exit once the shutdown returns, failing the requests still in flight.
+	l.Kill()
+	inFlight.Wait()
+	<-done
+
+	for _, expectation := range s.expectations {
+		expectation(t, result)
+	}
+	return result
+}
+
+// registerRoutes registers the synthetic routes of the scenario on g.
+func (s *DrainScenario) registerRoutes(g *graceful.Graceful, started *sync.WaitGroup) {
+	g.GET(slowPath, func(c *gin.Context) {
+		if c.Query("d") == "" {
+			c.String(http.StatusOK, "")
+			return
+		}
+		d, err := time.ParseDuration(c.Query("d"))
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		started.Done()
+		time.Sleep(d)
+		c.String(http.StatusOK, "")
+	})
+
+	g.GET(upgradePath, func(c *gin.Context) {
+		conn, rw, err := c.Writer.Hijack()
+		if err != nil {
+			started.Done()
+			return
+		}
+		defer conn.Close()
+
+		closed := make(chan struct{})
+		unregister := g.RegisterWakeup(func() { close(closed) })
+		defer unregister()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Connection: Upgrade\r\nUpgrade: gracefultest\r\n\r\n")
+		_ = rw.Flush()
+		started.Done()
+		<-closed
+	})
+}
+
+// get sends a GET request to url and returns an error unless it is answered with a 2xx response.
+func get(client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// upgrade opens an upgraded connection on l and reports whether the server closed it.
+func upgrade(l *Listener) bool {
+	conn, err := l.Dial(context.Background())
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	req := "GET " + upgradePath + " HTTP/1.1\r\nHost: memory\r\nConnection: Upgrade\r\nUpgrade: gracefultest\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return false
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		return false
+	}
+	_, err = r.ReadByte()
+	return err != nil
+}
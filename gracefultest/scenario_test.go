@@ -0,0 +1,42 @@
+package gracefultest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainScenario(t *testing.T) {
+	build := func(opts ...graceful.Option) (*graceful.Graceful, error) {
+		return graceful.Default(opts...)
+	}
+
+	result := NewDrainScenario(build).
+		SlowRequests(150*time.Millisecond, 50*time.Millisecond).
+		Upgrades(2).
+		LateRequests(3).
+		ExpectCompleted(2).
+		ExpectRejected(3).
+		ExpectOrder(1, 0).
+		ExpectShutdownError(false).
+		ExpectShutdownWithin(time.Second).
+		Run(t)
+	assert.Equal(t, 2, result.UpgradesClosed)
+	assert.GreaterOrEqual(t, result.Duration, 100*time.Millisecond)
+}
+
+func TestDrainScenarioTimeout(t *testing.T) {
+	build := func(opts ...graceful.Option) (*graceful.Graceful, error) {
+		return graceful.Default(opts...)
+	}
+
+	NewDrainScenario(build).
+		SlowRequests(500*time.Millisecond, 10*time.Millisecond).
+		ShutdownTimeout(100 * time.Millisecond).
+		ExpectCompleted(1).
+		ExpectFailed(1).
+		ExpectShutdownError(true).
+		Run(t)
+}
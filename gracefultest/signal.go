@@ -0,0 +1,14 @@
+package gracefultest
+
+import (
+	"syscall"
+
+	"github.com/gin-contrib/graceful"
+)
+
+// SendShutdownSignal delivers SIGTERM to g, run with RunWithSignals, as if the process received
+// it, so that an integration test exercises the exact shutdown path of a terminated process,
+// the hooks seeing the signal, without killing the test process. See graceful.Graceful.Signal.
+func SendShutdownSignal(g *graceful.Graceful) error {
+	return g.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,43 @@
+package gracefultest
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendShutdownSignal(t *testing.T) {
+	var info graceful.HookInfo
+	router, err := graceful.Default(
+		graceful.WithListener(NewListener()),
+		graceful.WithBeforeShutdown("flag", func(ctx context.Context) error {
+			info, _ = graceful.HookInfoFromContext(ctx)
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.ErrorIs(t, SendShutdownSignal(router), graceful.ErrNotStarted)
+
+	done := make(chan error, 1)
+	go func() { done <- router.RunWithSignals() }()
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == graceful.ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	assert.EqualError(t, router.Signal(syscall.SIGHUP), "signal hangup not handled")
+	assert.NoError(t, SendShutdownSignal(router))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the run did not stop")
+	}
+	assert.Equal(t, "signal", info.Reason)
+	assert.Equal(t, syscall.SIGTERM, info.Signal)
+	var signalErr *graceful.SignalError
+	assert.ErrorAs(t, info.Cause, &signalErr)
+}
@@ -0,0 +1,121 @@
+// Package grpcserver runs a gRPC server alongside the servers of a graceful.Graceful instance,
+// driving the standard grpc.health.v1 service from the lifecycle of the instance: the services
+// are SERVING while the server runs and NOT_SERVING as soon as the drain starts, so that the
+// health checking gRPC load balancers stop routing to the instance before its connections close.
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// defaultName is the component name of the gRPC server.
+const defaultName = "grpc"
+
+// Option configures the gRPC server of WithGRPCServer.
+type Option func(*runner)
+
+// WithName configure the component name of the gRPC server, so that other components can depend
+// on it, see graceful.WithComponent. Defaults to "grpc".
+func WithName(name string) Option {
+	return func(r *runner) {
+		r.name = name
+	}
+}
+
+// WithHealthServer configure the health server driven from the lifecycle, for a server which
+// already registered one. Without it, a health server is registered on the gRPC server.
+func WithHealthServer(h *health.Server) Option {
+	return func(r *runner) {
+		r.health = h
+	}
+}
+
+// WithStopDelay configure the time waited between reporting NOT_SERVING and the graceful stop of
+// the gRPC server, e.g. about the health check interval of the load balancers.
+func WithStopDelay(d time.Duration) Option {
+	return func(r *runner) {
+		r.stopDelay = d
+	}
+}
+
+// WithGRPCServer configure srv to serve gRPC requests on addr alongside the servers of the
+// Graceful instance. The server is gracefully stopped when the instance shuts down, and as a
+// grpc.Server can not serve again once stopped, the instance can then not be run again. The
+// components depending on the server start once it serves. New fails on a nil server.
+func WithGRPCServer(addr string, srv *grpc.Server, opts ...Option) graceful.Option {
+	r := &runner{name: defaultName, addr: addr, srv: srv, ready: make(chan struct{})}
+	for _, o := range opts {
+		o(r)
+	}
+
+	if srv == nil {
+		// WithRunner rejects a nil runner, so that New fails.
+		return graceful.WithRunner(r.name, nil)
+	}
+	if r.health == nil {
+		r.health = health.NewServer()
+		healthpb.RegisterHealthServer(srv, r.health)
+	}
+	return graceful.WithRunner(r.name, r)
+}
+
+// runner runs a gRPC server as a graceful.Runner.
+type runner struct {
+	name      string
+	addr      string
+	srv       *grpc.Server
+	health    *health.Server
+	stopDelay time.Duration
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// Ready returns a channel closed once the gRPC server serves, see graceful.ReadyNotifier.
+func (r *runner) Ready() <-chan struct{} {
+	return r.ready
+}
+
+// Run serves the gRPC server until ctx is canceled, then gracefully stops it.
+func (r *runner) Run(ctx context.Context) error {
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", r.addr)
+	if err != nil {
+		return err
+	}
+
+	r.health.Resume()
+	for service := range r.srv.GetServiceInfo() {
+		r.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	if lifecycle, ok := graceful.FromContext(ctx); ok {
+		defer lifecycle.RegisterWakeup(r.health.Shutdown)()
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- r.srv.Serve(l) }()
+	r.readyOnce.Do(func() { close(r.ready) })
+
+	select {
+	case err := <-served:
+		r.health.Shutdown()
+		return err
+	case <-ctx.Done():
+	}
+
+	// The run may end without a drain, e.g. when another server fails.
+	r.health.Shutdown()
+	if r.stopDelay > 0 {
+		time.Sleep(r.stopDelay)
+	}
+	r.srv.GracefulStop()
+	return <-served
+}
@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWithGRPCServer(t *testing.T) {
+	srv := grpc.NewServer()
+	router, err := graceful.Default(WithGRPCServer("127.0.0.1:8138", srv, WithStopDelay(200*time.Millisecond)))
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.NoError(t, router.Start())
+
+	conn, err := grpc.NewClient("127.0.0.1:8138", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.GetStatus()
+	}
+
+	assert.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_SERVING },
+		time.Second, 10*time.Millisecond)
+
+	stopped := make(chan error)
+	go func() { stopped <- router.Shutdown(context.Background()) }()
+	assert.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_NOT_SERVING },
+		time.Second, 10*time.Millisecond)
+	assert.NoError(t, <-stopped)
+}
+
+func TestWithGRPCServerNil(t *testing.T) {
+	_, err := graceful.Default(WithGRPCServer(":0", nil))
+	assert.EqualError(t, err, "nil runner")
+}
+
+func TestWithGRPCServerReady(t *testing.T) {
+	srv := grpc.NewServer()
+	router, err := graceful.Default(
+		WithGRPCServer("127.0.0.1:8166", srv),
+		graceful.WithComponent("http", graceful.WithAddr("127.0.0.1:8167"), defaultName),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.NoError(t, router.Start())
+
+	// The HTTP server starts once the gRPC server serves.
+	assert.Eventually(t, func() bool {
+		for _, s := range router.ServerStatus() {
+			if s.Name == "http" && s.State == graceful.ServerServing {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	conn, err := grpc.NewClient("127.0.0.1:8166", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	assert.NoError(t, router.Stop())
+}
@@ -0,0 +1,190 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// WithUnixHandoff configure a http.Server to listen on the given unix socket file, handed off
+// between successive processes so that a restart drops no connection. A handoff server, named
+// "handoff", listens on the control socket file controlPath: when a new process is created with
+// the same option, New asks the running process for the listener over the control socket, which
+// sends its file descriptor in a SCM_RIGHTS control message. Once the new process serves on the
+// same listener, it acknowledges the handoff and the running process gracefully shuts down, its
+// run returning the error of the shutdown; if the new process fails before, the running process
+// keeps serving. Without a running process, the unix socket file is created.
+func WithUnixHandoff(file, controlPath string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := g.claimUnix(file); err != nil {
+			return nil, donothing, err
+		}
+		if err := g.claimUnix(controlPath); err != nil {
+			return nil, donothing, err
+		}
+
+		listener, predecessor, err := receiveListener(controlPath)
+		if err != nil {
+			return nil, donothing, err
+		}
+		if listener == nil {
+			if listener, err = g.listenConfig.Listen(context.Background(), "unix", file); err != nil {
+				return nil, donothing, err
+			}
+		}
+
+		h := &handoff{listener: listener, controlPath: controlPath, predecessor: predecessor}
+		h.owned.Store(predecessor == nil)
+		if err := g.apply(WithListener(listener)); err != nil {
+			h.abort()
+			listener.Close()
+			return nil, donothing, err
+		}
+		servers := g.servers.load()
+		h.server = servers[len(servers)-1]
+		if err := g.apply(WithRunner("handoff", RunnerFunc(func(ctx context.Context) error {
+			return g.serveHandoff(ctx, h)
+		}))); err != nil {
+			h.abort()
+			return nil, donothing, err
+		}
+
+		return nil, func() {
+			h.abort()
+			if h.owned.Load() && !h.handedOff.Load() {
+				os.Remove(file)
+			}
+			listener.Close()
+		}, nil
+	})
+}
+
+// handoff is the unix socket listener of WithUnixHandoff, with its control socket file.
+type handoff struct {
+	listener    net.Listener
+	server      *managedServer
+	controlPath string
+	// owned is whether the socket file belongs to this process: it created the listener, or
+	// acknowledged the handoff. handedOff is whether it was handed off since.
+	owned     atomic.Bool
+	handedOff atomic.Bool
+
+	// predecessor is the connection to the process the listener was received from, until the
+	// handoff is acknowledged or aborted.
+	lock        sync.Mutex
+	predecessor net.Conn
+}
+
+// ack acknowledges the handoff to the process the listener was received from, if any.
+func (h *handoff) ack() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.predecessor == nil {
+		return nil
+	}
+	_, err := h.predecessor.Write([]byte{0})
+	h.predecessor.Close()
+	h.predecessor = nil
+	h.owned.Store(true)
+	return err
+}
+
+// abort closes the connection to the process the listener was received from, if any, without
+// acknowledging the handoff, so that the process keeps serving.
+func (h *handoff) abort() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.predecessor != nil {
+		h.predecessor.Close()
+		h.predecessor = nil
+	}
+}
+
+// serveHandoff acknowledges the handoff to the previous process once the listener is served, if
+// it was received from one, then hands the listener off to the first process asking for it on the
+// control socket, and cancels the run once that process acknowledges the handoff in turn. It
+// returns once ctx is canceled or the listener has been handed off.
+func (g *Graceful) serveHandoff(ctx context.Context, h *handoff) error {
+	select {
+	case <-h.server.startedChan():
+	case <-ctx.Done():
+		h.abort()
+		return nil
+	}
+	if err := h.ack(); err != nil {
+		g.log().Warn("listener handoff acknowledgement failed", "error", err)
+	}
+
+	// The control socket file of the previous process, if any, is replaced.
+	_ = os.Remove(h.controlPath)
+	l, err := g.listenConfig.Listen(ctx, "unix", h.controlPath)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+	})
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		err = sendListener(conn, h.listener)
+		if err == nil {
+			err = waitHandoffAck(ctx, conn)
+		}
+		conn.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// The new process failed before serving, this one keeps serving.
+			g.log().Warn("listener handoff failed", "error", err)
+			continue
+		}
+
+		// Both socket files now belong to the new process.
+		h.handedOff.Store(true)
+		unlinkOnClose(h.listener, false)
+		unlinkOnClose(l, false)
+		g.log().Info("listener handed off", "path", h.controlPath)
+		g.cancelRun("handoff")
+		return nil
+	}
+}
+
+// waitHandoffAck waits for the process the listener was sent to on conn to acknowledge the
+// handoff, see handoff.ack, until ctx is done.
+func waitHandoffAck(ctx context.Context, conn net.Conn) error {
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return fmt.Errorf("handoff not acknowledged: %w", err)
+	}
+	return nil
+}
+
+// unlinkOnClose sets whether closing l removes its unix socket file.
+func unlinkOnClose(l net.Listener, unlink bool) {
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(unlink)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package graceful
+
+import (
+	"errors"
+	"net"
+)
+
+// errHandoffUnsupported is returned by WithUnixHandoff on platforms without SCM_RIGHTS.
+var errHandoffUnsupported = errors.New("handoff: unsupported platform")
+
+// receiveListener is not supported on this platform.
+func receiveListener(string) (net.Listener, net.Conn, error) {
+	return nil, nil, errHandoffUnsupported
+}
+
+// sendListener is not supported on this platform.
+func sendListener(net.Conn, net.Listener) error {
+	return errHandoffUnsupported
+}
@@ -0,0 +1,145 @@
+//go:build unix
+
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// unixGet sends a GET request on the unix socket file and returns the response body.
+func unixGet(t *testing.T, file, path string) string {
+	t.Helper()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", file)
+		},
+	}}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://unix"+path, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestWithUnixHandoff(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "graceful.sock")
+	control := filepath.Join(dir, "graceful.ctl")
+
+	run := func(version string) (*Graceful, chan error) {
+		router, err := Default(WithUnixHandoff(file, control))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		router.GET("/version", func(c *gin.Context) { c.String(http.StatusOK, version) })
+
+		done := make(chan error, 1)
+		go func() {
+			done <- router.RunWithContext(context.Background())
+		}()
+		time.Sleep(50 * time.Millisecond)
+		return router, done
+	}
+
+	old, oldDone := run("old")
+	assert.Equal(t, "old", unixGet(t, file, "/version"))
+
+	// The new process takes over the listener, and the old one shuts down.
+	successor, done := run("new")
+	defer successor.Close()
+
+	select {
+	case err := <-oldDone:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("old process did not shut down")
+	}
+	old.Close()
+
+	_, err := os.Stat(file)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "new", unixGet(t, file, "/version"), fmt.Sprintf("request %d", i))
+	}
+
+	assert.NoError(t, successor.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+	successor.Close()
+	_, err = os.Stat(file)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWithUnixHandoffFailedSuccessor(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "graceful.sock")
+	control := filepath.Join(dir, "graceful.ctl")
+
+	old, err := Default(WithUnixHandoff(file, control))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer old.Close()
+	old.GET("/version", func(c *gin.Context) { c.String(http.StatusOK, "old") })
+
+	oldDone := make(chan error, 1)
+	go func() {
+		oldDone <- old.RunWithContext(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The new process receives the listener but never serves it, the old one keeps serving.
+	failed, err := Default(WithUnixHandoff(file, control))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	failed.Close()
+
+	select {
+	case err := <-oldDone:
+		t.Fatalf("old process shut down: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, "old", unixGet(t, file, "/version"))
+
+	// A later successor still takes over.
+	successor, err := Default(WithUnixHandoff(file, control))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer successor.Close()
+	successor.GET("/version", func(c *gin.Context) { c.String(http.StatusOK, "new") })
+
+	done := make(chan error, 1)
+	go func() {
+		done <- successor.RunWithContext(context.Background())
+	}()
+
+	select {
+	case err := <-oldDone:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("old process did not shut down")
+	}
+	assert.Equal(t, "new", unixGet(t, file, "/version"))
+
+	assert.NoError(t, successor.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,94 @@
+//go:build unix
+
+package graceful
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// handoffTimeout bounds the time waited for the running process to hand off its listener.
+const handoffTimeout = 5 * time.Second
+
+// receiveListener asks the process listening on the control socket file for its listener. It
+// returns no listener if no process is listening, or else the listener and the connection to the
+// process, to acknowledge the handoff on once the listener is served, see ackHandoff.
+func receiveListener(controlPath string) (net.Listener, net.Conn, error) {
+	conn, err := net.DialTimeout("unix", controlPath, handoffTimeout)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("handoff: %w", err)
+	}
+
+	l, err := readListener(conn.(*net.UnixConn))
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("handoff: %w", err)
+	}
+	return l, conn, nil
+}
+
+// readListener reads the listener sent by sendListener on conn.
+func readListener(conn *net.UnixConn) (net.Listener, error) {
+	if err := conn.SetDeadline(time.Now().Add(handoffTimeout)); err != nil {
+		return nil, err
+	}
+	defer conn.SetDeadline(time.Time{})
+
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) != 1 {
+		return nil, errors.New("no file descriptor received")
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(fds) != 1 {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+		return nil, errors.New("no file descriptor received")
+	}
+
+	f := os.NewFile(uintptr(fds[0]), "handoff")
+	defer f.Close()
+
+	return net.FileListener(f)
+}
+
+// sendListener sends the file descriptor of l on conn.
+func sendListener(conn net.Conn, l net.Listener) error {
+	fl, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("handoff: unsupported listener %T", l)
+	}
+	f, err := fl.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("handoff: unsupported connection %T", conn)
+	}
+	_, _, err = uc.WriteMsgUnix([]byte{0}, syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
@@ -0,0 +1,173 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHealthCheckTimeout = 5 * time.Second
+
+// HealthCheckOption configures a health check registered with AddHealthCheck.
+type HealthCheckOption func(*healthCheck)
+
+// WithCheckTimeout sets the maximum duration of one run of the health check. Defaults to five seconds.
+func WithCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.timeout = timeout
+	}
+}
+
+// WithCheckCache caches the result of the health check for the given duration, so that frequent
+// probes do not hit the checked dependency every time.
+func WithCheckCache(ttl time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.ttl = ttl
+	}
+}
+
+// healthCheck is a named dependency check aggregated into the health endpoints.
+type healthCheck struct {
+	name    string
+	check   func(ctx context.Context) error
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// run runs the check, or returns its cached result if it is still fresh.
+func (c *healthCheck) run(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl > 0 && !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl {
+		return c.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	c.err = c.check(ctx)
+	c.checkedAt = time.Now()
+
+	return c.err
+}
+
+// AddHealthCheck registers a named dependency check, e.g. a database ping, aggregated into the
+// health and readiness endpoints. A check registered with an existing name replaces it.
+func (g *Graceful) AddHealthCheck(name string, check func(ctx context.Context) error, opts ...HealthCheckOption) {
+	c := &healthCheck{
+		name:    name,
+		check:   check,
+		timeout: defaultHealthCheckTimeout,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for i, existing := range g.healthChecks {
+		if existing.name == name {
+			g.healthChecks[i] = c
+			return
+		}
+	}
+	g.healthChecks = append(g.healthChecks, c)
+}
+
+// WithHealthEndpoints registers the /healthz and /readyz endpoints on the gin.Engine.
+// /healthz reports whether every health check passes. /readyz additionally requires every
+// server to be serving, the warmup to be complete and the instance not to be draining, so it
+// fails before the start and as soon as a drain or a shutdown begins.
+func WithHealthEndpoints() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.addRoutes(func(r gin.IRoutes) {
+			r.GET("/healthz", g.handleHealth(false))
+			r.GET("/readyz", g.handleHealth(true))
+		})
+		g.exemptFromDrain("/healthz", "/readyz")
+		return nil, donothing, nil
+	})
+}
+
+// handleHealth returns the handler of the health endpoints.
+func (g *Graceful) handleHealth(readiness bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		healthy, checks := g.runHealthChecks(c.Request.Context())
+
+		body := gin.H{"checks": checks}
+		if readiness && g.Draining() {
+			healthy = false
+			body["servers"] = "draining"
+		} else if readiness && !g.serving() {
+			healthy = false
+			body["servers"] = "not serving"
+		} else if readiness && !g.warm.Load() {
+			healthy = false
+			body["servers"] = "warming up"
+		}
+
+		status := http.StatusOK
+		body["status"] = "ok"
+		if !healthy {
+			status = http.StatusServiceUnavailable
+			body["status"] = "unavailable"
+		}
+
+		c.JSON(status, body)
+	}
+}
+
+// runHealthChecks runs every registered health check concurrently. It returns whether all of
+// them passed, along with the result of each check.
+func (g *Graceful) runHealthChecks(ctx context.Context) (bool, map[string]string) {
+	g.lock.Lock()
+	checks := append([]*healthCheck(nil), g.healthChecks...)
+	g.lock.Unlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *healthCheck) {
+			defer wg.Done()
+			errs[i] = check.run(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	healthy := true
+	results := make(map[string]string, len(checks))
+	for i, check := range checks {
+		results[check.name] = "ok"
+		if errs[i] != nil {
+			healthy = false
+			results[check.name] = errs[i].Error()
+		}
+	}
+
+	return healthy, results
+}
+
+// serving reports whether every server managed by the Graceful instance is serving.
+func (g *Graceful) serving() bool {
+	statuses := g.ServerStatus()
+	if len(statuses) == 0 {
+		return false
+	}
+	for _, status := range statuses {
+		if status.State != ServerServing {
+			return false
+		}
+	}
+
+	return true
+}
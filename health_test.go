@@ -0,0 +1,98 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	router, err := Default(WithAddr(":8086"), WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	code, body := probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+
+	code, body = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not serving", body["servers"])
+
+	assert.NoError(t, router.Start())
+	time.Sleep(50 * time.Millisecond)
+
+	code, _ = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	router.AddHealthCheck("db", func(context.Context) error { return errors.New("connection refused") })
+	router.AddHealthCheck("cache", func(context.Context) error { return nil })
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		code, body = probe(t, router, path)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", body["status"])
+		assert.Equal(t, map[string]any{"db": "connection refused", "cache": "ok"}, body["checks"])
+	}
+
+	router.AddHealthCheck("db", func(context.Context) error { return nil })
+	code, _ = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	assert.NoError(t, router.Stop())
+
+	code, _ = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	router, err := Default(WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.AddHealthCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithCheckTimeout(10*time.Millisecond))
+
+	code, body := probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]any{"slow": context.DeadlineExceeded.Error()}, body["checks"])
+}
+
+func TestHealthCheckCache(t *testing.T) {
+	router, err := Default(WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var calls int32
+	router.AddHealthCheck("counted", func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, WithCheckCache(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		code, _ := probe(t, router, "/healthz")
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// probe requests the given path on the router and decodes the JSON response body.
+func probe(t *testing.T, router *Graceful, path string) (int, map[string]any) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	return w.Code, body
+}
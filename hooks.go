@@ -0,0 +1,196 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/gin-contrib/graceful/lifecycle"
+)
+
+// Hook is a step of the shutdown run with a context done once the time allotted to it has elapsed,
+// see WithBeforeShutdown and WithAfterShutdown.
+type Hook func(ctx context.Context) error
+
+// HookInfo describes the shutdown a hook runs in, see HookInfoFromContext.
+type HookInfo struct {
+	// Reason is what triggered the shutdown, see ShutdownReport.
+	Reason string
+	// Signal is the signal that triggered the shutdown, when the RunWithContext context was returned
+	// by NotifyContext, nil otherwise.
+	Signal os.Signal
+	// Cause is the cause the shutdown was triggered with, by StopWithCause or as the cause of the
+	// RunWithContext context, nil otherwise.
+	Cause error
+	// Phase is the phase of the shutdown the hook runs in, "before shutdown" or "after shutdown".
+	Phase string
+	// Deadline is when the hook context is done, zero if it has no deadline.
+	Deadline time.Time
+}
+
+// hookInfoKey is the context key of the HookInfo passed to the hooks.
+type hookInfoKey struct{}
+
+// HookInfoFromContext returns the HookInfo of the shutdown the hook given ctx runs in. It reports
+// false if ctx is not the context of a hook.
+func HookInfoFromContext(ctx context.Context) (HookInfo, bool) {
+	info, ok := ctx.Value(hookInfoKey{}).(HookInfo)
+	return info, ok
+}
+
+// HookOption configures a hook registered with WithBeforeShutdown or WithAfterShutdown.
+type HookOption func(*shutdownHook)
+
+// WithHookRetry runs the hook up to attempts times until it succeeds, e.g. for the flaky calls to
+// an external load balancer, waiting backoff before the first retry, doubled before every next
+// one. The retries stop once the hook context is done, and every attempt is recorded as a phase
+// of the shutdown report.
+func WithHookRetry(attempts int, backoff time.Duration) HookOption {
+	return func(h *shutdownHook) {
+		h.attempts = attempts
+		h.backoff = backoff
+	}
+}
+
+// shutdownHook is a named Hook.
+type shutdownHook struct {
+	name     string
+	fn       Hook
+	attempts int
+	backoff  time.Duration
+}
+
+// newShutdownHook returns the named hook configured with opts.
+func newShutdownHook(name string, fn Hook, opts []HookOption) shutdownHook {
+	h := shutdownHook{name: name, fn: fn, attempts: 1}
+	for _, o := range opts {
+		o(&h)
+	}
+	if h.attempts < 1 {
+		h.attempts = 1
+	}
+	return h
+}
+
+// SyncHook returns a Hook flushing the buffered logs of syncer, such as a *zap.Logger, so that the
+// logs of the drain reach their destination before the process exits, e.g. with
+// WithAfterShutdown("sync logs", SyncHook(logger)). The logger configured with WithLogger, if it
+// has a Sync method, is synced on its own at the end of the shutdown.
+func SyncHook(syncer interface{ Sync() error }) Hook {
+	if syncer == nil {
+		return nil
+	}
+	return func(context.Context) error {
+		return syncer.Sync()
+	}
+}
+
+// WithBeforeShutdown configure a hook run, in the order configured, when a running instance shuts
+// down, after the deregistration from the registries and before the drain starts, e.g. to flip a
+// readiness flag in an external load balancer. A failed hook is logged and its error returned by
+// the shutdown, which goes on regardless.
+func WithBeforeShutdown(name string, hook Hook, opts ...HookOption) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if hook == nil {
+			return nil, donothing, errors.New("nil before shutdown hook")
+		}
+		g.beforeHooks = append(g.beforeHooks, newShutdownHook(name, hook, opts))
+		return nil, donothing, nil
+	})
+}
+
+// WithAfterShutdown configure a hook run, in the order configured, once every server of a running
+// instance is drained, e.g. to flush the buffered metrics or close a database. The hooks run even
+// if the shutdown context is canceled, but their context keeps the remaining shutdown deadline,
+// see ShutdownBudget. A failed hook is logged and its error returned by the shutdown, which goes
+// on regardless.
+func WithAfterShutdown(name string, hook Hook, opts ...HookOption) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if hook == nil {
+			return nil, donothing, errors.New("nil after shutdown hook")
+		}
+		g.afterHooks = append(g.afterHooks, newShutdownHook(name, hook, opts))
+		return nil, donothing, nil
+	})
+}
+
+// runHooks runs hooks in order with ctx, recording each attempt as a phase named after kind.
+func (g *Graceful) runHooks(ctx context.Context, reason, kind string, hooks []shutdownHook) ([]ShutdownPhase, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	g.lock.Lock()
+	info := HookInfo{Reason: reason, Signal: g.stopSignal, Cause: g.stopCause, Phase: kind}
+	g.lock.Unlock()
+	info.Deadline, _ = ctx.Deadline()
+	ctx = context.WithValue(ctx, hookInfoKey{}, info)
+
+	var phases []ShutdownPhase
+	l := lifecycle.New([]lifecycle.Phase{{Name: kind}}, lifecycle.WithObserver(func(r lifecycle.Result) {
+		phase := ShutdownPhase{Name: kind + " " + r.Hook, Duration: r.Duration}
+		if r.Attempts > 1 {
+			phase.Attempt = r.Attempt
+		}
+		switch {
+		case r.Err == nil:
+		case r.Retry:
+			phase.Error = r.Err.Error()
+			g.log().Warn("shutdown hook failed, retrying", "hook", r.Hook, "attempt", r.Attempt, "error", r.Err)
+		default:
+			phase.Error = r.Err.Error()
+			g.log().Error("shutdown hook failed", "hook", r.Hook, "attempts", r.Attempt, "error", r.Err)
+		}
+		phases = append(phases, phase)
+	}))
+	for _, hook := range hooks {
+		_ = l.Add(kind, hook.name, lifecycle.Hook(hook.fn), lifecycle.WithRetry(hook.attempts, hook.backoff))
+	}
+	err := l.Run(ctx)
+	// Every failed hook is also sent on the Errors channel, the joined errors one by one.
+	failed := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		failed = joined.Unwrap()
+	}
+	for _, e := range failed {
+		if e != nil {
+			g.notifyError(e)
+		}
+	}
+	return phases, err
+}
+
+// Flusher is an error reporter, or any client, buffering events to be delivered before the
+// process exits, e.g. a *sentry.Hub or a *sentry.Client.
+type Flusher interface {
+	// Flush waits at most timeout for the buffered events to be delivered, and reports whether
+	// they were.
+	Flush(timeout time.Duration) bool
+}
+
+// FlusherFunc adapts a function to the Flusher interface.
+type FlusherFunc func(timeout time.Duration) bool
+
+// Flush calls f.
+func (f FlusherFunc) Flush(timeout time.Duration) bool {
+	return f(timeout)
+}
+
+// FlushHook returns an after shutdown hook flushing flusher, waiting at most d, or less if the
+// shutdown deadline is closer, so that the buffered error reports are delivered before the process
+// exits. The sentryhook module adapts it to a Sentry hub, see sentryhook.WithSentry.
+func FlushHook(flusher Flusher, d time.Duration) Hook {
+	return func(ctx context.Context) error {
+		timeout := d
+		if deadline, ok := ctx.Deadline(); ok {
+			if left := time.Until(deadline); left < timeout {
+				timeout = left
+			}
+		}
+		if timeout <= 0 || !flusher.Flush(timeout) {
+			return errors.New("flush timed out")
+		}
+		return nil
+	}
+}
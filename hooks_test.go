@@ -0,0 +1,225 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownHooks(t *testing.T) {
+	var steps []string
+	var reports []ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8121"),
+		WithBeforeShutdown("flag", func(context.Context) error {
+			steps = append(steps, "before")
+			return nil
+		}),
+		WithAfterShutdown("flush", func(ctx context.Context) error {
+			steps = append(steps, "after")
+			return ctx.Err()
+		}),
+		WithAfterShutdown("close", func(context.Context) error {
+			return errors.New("already closed")
+		}),
+		WithShutdownReportFunc(func(r ShutdownReport) { reports = append(reports, r) }),
+	)
+	assert.NoError(t, err)
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+
+	err = router.Shutdown(context.Background())
+	assert.ErrorContains(t, err, `after shutdown "close": already closed`)
+	var hookErr *HookError
+	if assert.ErrorAs(t, err, &hookErr) {
+		assert.Equal(t, "close", hookErr.Name)
+		assert.Equal(t, "after shutdown", hookErr.Phase)
+	}
+	assert.Equal(t, []string{"before", "after"}, steps)
+	if assert.Len(t, reports, 1) {
+		var names []string
+		for _, phase := range reports[0].Phases {
+			names = append(names, phase.Name)
+		}
+		assert.Equal(t, []string{"before shutdown flag", "drain 127.0.0.1:8121", "after shutdown flush", "after shutdown close"}, names)
+		assert.Equal(t, `after shutdown "close": already closed`, reports[0].Phases[3].Error)
+	}
+
+	// The hooks do not run again when the instance is no longer running.
+	router.Close()
+	assert.Len(t, steps, 2)
+
+	_, err = Default(WithBeforeShutdown("nil", nil))
+	assert.EqualError(t, err, "nil before shutdown hook")
+}
+
+func TestAfterShutdownDeadline(t *testing.T) {
+	var deadline time.Time
+	var hookErr error
+	router, err := Default(
+		WithAddr("127.0.0.1:8123"),
+		WithAfterShutdown("flush", func(ctx context.Context) error {
+			deadline, _ = ctx.Deadline()
+			hookErr = ctx.Err()
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	assert.NoError(t, router.Shutdown(ctx))
+	assert.Equal(t, want, deadline)
+	assert.NoError(t, hookErr)
+}
+
+func TestWithHookRetry(t *testing.T) {
+	attempts := 0
+	var reports []ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8124"),
+		WithBeforeShutdown("deregister", func(context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("load balancer unavailable")
+			}
+			return nil
+		}, WithHookRetry(5, 10*time.Millisecond)),
+		WithAfterShutdown("flush", func(context.Context) error {
+			return errors.New("collector unavailable")
+		}, WithHookRetry(2, time.Millisecond)),
+		WithShutdownReportFunc(func(r ShutdownReport) { reports = append(reports, r) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	err = router.Shutdown(context.Background())
+	assert.EqualError(t, err, `after shutdown "flush": collector unavailable`)
+	assert.Equal(t, 3, attempts)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	if assert.Len(t, reports, 1) {
+		var attempted []string
+		for _, phase := range reports[0].Phases {
+			attempted = append(attempted, fmt.Sprintf("%s #%d %s", phase.Name, phase.Attempt, phase.Error))
+		}
+		assert.Equal(t, []string{
+			`before shutdown deregister #1 before shutdown "deregister": load balancer unavailable`,
+			`before shutdown deregister #2 before shutdown "deregister": load balancer unavailable`,
+			"before shutdown deregister #3 ",
+			"drain 127.0.0.1:8124 #0 ",
+			`after shutdown flush #1 after shutdown "flush": collector unavailable`,
+			`after shutdown flush #2 after shutdown "flush": collector unavailable`,
+		}, attempted)
+	}
+}
+
+func TestFlushHook(t *testing.T) {
+	var timeouts []time.Duration
+	flusher := FlusherFunc(func(timeout time.Duration) bool {
+		timeouts = append(timeouts, timeout)
+		return timeout > time.Second
+	})
+
+	assert.NoError(t, FlushHook(flusher, 2*time.Second)(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, FlushHook(flusher, 2*time.Second)(ctx), "flush timed out")
+	if assert.Len(t, timeouts, 2) {
+		assert.Equal(t, 2*time.Second, timeouts[0])
+		assert.LessOrEqual(t, timeouts[1], 500*time.Millisecond)
+	}
+}
+
+func TestShutdownHookErrors(t *testing.T) {
+	router, err := Default(
+		WithAddr("127.0.0.1:8163"),
+		WithBeforeShutdown("flag", func(context.Context) error { return errors.New("unreachable") }),
+		WithAfterShutdown("flush", func(context.Context) error { return nil }),
+		WithAfterShutdown("close", func(context.Context) error { return errors.New("already closed") }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	errs := router.Errors()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	var names []string
+	for len(errs) > 0 {
+		var hookErr *HookError
+		if err := <-errs; assert.ErrorAs(t, err, &hookErr) {
+			names = append(names, hookErr.Phase+" "+hookErr.Name)
+		}
+	}
+	assert.Equal(t, []string{"before shutdown flag", "after shutdown close"}, names)
+}
+
+func TestContextDoneShutdown(t *testing.T) {
+	hookErr := make(chan error, 1)
+	router, err := Default(
+		WithAddr("127.0.0.1:8168"),
+		WithShutdownBudget(ShutdownBudget{Total: 2 * time.Second, BeforeShutdown: 0.2, Drain: 0.6, AfterShutdown: 0.2}),
+		WithBeforeShutdown("flag", func(ctx context.Context) error {
+			hookErr <- ctx.Err()
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	started := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- router.RunWithContext(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	responded := make(chan string, 1)
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://127.0.0.1:8168/slow", nil)
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			responded <- ""
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responded <- string(body)
+	}()
+	<-started
+
+	// The shutdown of a canceled context still runs the hooks and drains the request in flight.
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.NoError(t, <-hookErr)
+	assert.Equal(t, "done", <-responded)
+}
@@ -0,0 +1,58 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithIdleShutdown configure the instance to gracefully shut down once no request has been
+// served for d, e.g. on scale-to-zero platforms or for on-demand preview environments. A
+// request in flight keeps the instance busy. RunWithContext then returns nil, as if its context
+// was canceled.
+func WithIdleShutdown(d time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if d <= 0 {
+			return nil, donothing, errors.New("invalid idle timeout")
+		}
+		g.idleTimeout = d
+		return nil, donothing, nil
+	})
+}
+
+// trackActivity records a request as in flight, and returns the function recording its end.
+func (g *Graceful) trackActivity() func() {
+	shard := g.activeRequests.add(1)
+	g.lastRequest.Store(time.Now().UnixNano())
+
+	return func() {
+		g.lastRequest.Store(time.Now().UnixNano())
+		shard.Add(-1)
+	}
+}
+
+// watchIdle shuts the run down once no request has been served for the idle timeout, unless ctx
+// is done first.
+func (g *Graceful) watchIdle(ctx context.Context) {
+	timer := time.NewTimer(g.idleTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		wait := g.idleTimeout
+		if g.activeRequests.load() == 0 {
+			wait = time.Until(time.Unix(0, g.lastRequest.Load()).Add(g.idleTimeout))
+		}
+		if wait <= 0 {
+			g.log().Info("idle timeout reached", "timeout", g.idleTimeout)
+			g.cancelRun("idle")
+			return
+		}
+		timer.Reset(wait)
+	}
+}
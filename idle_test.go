@@ -0,0 +1,46 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIdleShutdown(t *testing.T) {
+	var reasons []string
+	router, err := Default(
+		WithAddr(":8120"),
+		WithIdleShutdown(100*time.Millisecond),
+		WithShutdownReportFunc(func(r ShutdownReport) { reasons = append(reasons, r.Reason) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(150 * time.Millisecond)
+		c.String(http.StatusOK, "it worked")
+	})
+
+	start := time.Now()
+	done := make(chan error)
+	go func() { done <- router.RunWithContext(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+
+	// The request in flight, then the one completed, keep the instance busy.
+	assert.True(t, reachable(t, "http://localhost:8120/slow"))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("run not shut down when idle")
+	}
+	assert.Contains(t, reasons, "idle")
+
+	_, err = Default(WithIdleShutdown(0))
+	assert.EqualError(t, err, "invalid idle timeout")
+}
@@ -0,0 +1,97 @@
+package graceful
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// overseerFirstFd is the first file descriptor passed by overseer, after stdin, stdout and stderr.
+const overseerFirstFd = 3
+
+// WithEinhornSockets configure a http.Server for every listener passed by the Einhorn socket
+// manager, so that services deployed under Einhorn keep their process manager. The file
+// descriptors are read from the EINHORN_FD_COUNT and EINHORN_FD_<n> environment variables, or
+// from the legacy space-separated EINHORN_FDS one. It fails if no listener was passed.
+func WithEinhornSockets() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		fds, err := einhornFds(os.Getenv)
+		if err != nil {
+			return nil, donothing, err
+		}
+		return nil, donothing, g.applyFds(fds)
+	})
+}
+
+// WithOverseerSockets configure a http.Server for every listener passed by the overseer master
+// process to its child, so that services deployed under overseer keep their process manager. The
+// number of file descriptors, passed from 3 on, is read from the OVERSEER_NUM_FDS environment
+// variable. It fails if no listener was passed.
+func WithOverseerSockets() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		fds, err := overseerFds(os.Getenv)
+		if err != nil {
+			return nil, donothing, err
+		}
+		return nil, donothing, g.applyFds(fds)
+	})
+}
+
+// applyFds configures a http.Server listening on every given file descriptor.
+func (g *Graceful) applyFds(fds []uintptr) error {
+	for _, fd := range fds {
+		if err := g.apply(WithFd(fd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// einhornFds returns the file descriptors passed by Einhorn in the environment.
+func einhornFds(getenv func(string) string) ([]uintptr, error) {
+	var values []string
+	if count := getenv("EINHORN_FD_COUNT"); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid EINHORN_FD_COUNT %q", count)
+		}
+		for i := 0; i < n; i++ {
+			values = append(values, getenv(fmt.Sprintf("EINHORN_FD_%d", i)))
+		}
+	} else {
+		values = strings.Fields(getenv("EINHORN_FDS"))
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no einhorn sockets")
+	}
+
+	fds := make([]uintptr, 0, len(values))
+	for _, v := range values {
+		fd, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid einhorn file descriptor %q", v)
+		}
+		fds = append(fds, uintptr(fd))
+	}
+	return fds, nil
+}
+
+// overseerFds returns the file descriptors passed by overseer in the environment.
+func overseerFds(getenv func(string) string) ([]uintptr, error) {
+	count := getenv("OVERSEER_NUM_FDS")
+	if count == "" {
+		return nil, errors.New("no overseer sockets")
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid OVERSEER_NUM_FDS %q", count)
+	}
+
+	fds := make([]uintptr, 0, n)
+	for i := 0; i < n; i++ {
+		fds = append(fds, uintptr(overseerFirstFd+i))
+	}
+	return fds, nil
+}
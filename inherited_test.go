@@ -0,0 +1,69 @@
+package graceful
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEinhornFds(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	fds, err := einhornFds(env(map[string]string{"EINHORN_FD_COUNT": "2", "EINHORN_FD_0": "3", "EINHORN_FD_1": "5"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []uintptr{3, 5}, fds)
+
+	fds, err = einhornFds(env(map[string]string{"EINHORN_FDS": "4 6"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []uintptr{4, 6}, fds)
+
+	_, err = einhornFds(env(nil))
+	assert.EqualError(t, err, "no einhorn sockets")
+	_, err = einhornFds(env(map[string]string{"EINHORN_FD_COUNT": "1"}))
+	assert.EqualError(t, err, `invalid einhorn file descriptor ""`)
+	_, err = einhornFds(env(map[string]string{"EINHORN_FD_COUNT": "many"}))
+	assert.EqualError(t, err, `invalid EINHORN_FD_COUNT "many"`)
+}
+
+func TestOverseerFds(t *testing.T) {
+	fds, err := overseerFds(func(string) string { return "2" })
+	assert.NoError(t, err)
+	assert.Equal(t, []uintptr{3, 4}, fds)
+
+	_, err = overseerFds(func(string) string { return "" })
+	assert.EqualError(t, err, "no overseer sockets")
+}
+
+func TestWithEinhornSockets(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	socketFile, err := listener.(*net.TCPListener).File()
+	if isWindows() {
+		assert.Error(t, err)
+		return
+	}
+	assert.NoError(t, err)
+	defer socketFile.Close()
+
+	t.Setenv("EINHORN_FD_COUNT", "1")
+	t.Setenv("EINHORN_FD_0", strconv.Itoa(int(socketFile.Fd())))
+	testRouterConstructor(t, func() (*Graceful, error) {
+		return Default(WithEinhornSockets())
+	}, fmt.Sprintf("http://localhost:%d/example", listener.Addr().(*net.TCPAddr).Port))
+}
+
+func TestWithLaunchdSocketsUnsupported(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("launchd sockets are supported on macOS")
+	}
+
+	_, err := Default(WithLaunchdSockets("Listeners"))
+	assert.EqualError(t, err, "launchd sockets: unsupported platform")
+}
@@ -0,0 +1,108 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// WithIPFilter configure the peers allowed to connect to the listeners managed by the Graceful
+// instance, checked at accept time, before any byte is read: a peer in one of the deny CIDRs is
+// rejected, and if allow is not empty, so is a peer in none of the allow CIDRs. It is cheaper than
+// a middleware, and applies to the probes which are not HTTP requests. A plain IP is a CIDR of a
+// single address. The rejected peers are recorded by the graceful.listener.rejected metric, see
+// WithMeterProvider. The connections of unix sockets are never filtered.
+func WithIPFilter(allow, deny []string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		filter := &ipFilter{}
+		var err error
+		if filter.allow, err = parseCIDRs(allow); err != nil {
+			return nil, donothing, err
+		}
+		if filter.deny, err = parseCIDRs(deny); err != nil {
+			return nil, donothing, err
+		}
+		g.ipFilter = filter
+		return nil, donothing, nil
+	})
+}
+
+// parseCIDRs parses the given CIDRs, or plain IPs.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid cidr %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", cidr)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// ipFilter is the allow and deny lists of WithIPFilter.
+type ipFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// allowed reports whether the peer at addr may connect.
+func (f *ipFilter) allowed(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return true
+	}
+	for _, n := range f.deny {
+		if n.Contains(tcp.IP) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, n := range f.allow {
+		if n.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipFilterListener closes the accepted connections of the peers rejected by its filter.
+type ipFilterListener struct {
+	net.Listener
+	g      *Graceful
+	server *managedServer
+}
+
+// Accept waits for and returns the next connection of an allowed peer.
+func (l *ipFilterListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.g.ipFilter.allowed(conn.RemoteAddr()) {
+			return conn, nil
+		}
+
+		conn.Close()
+		l.g.debugLog("connection rejected", "peer", conn.RemoteAddr().String(), "addr", l.server.status().Addr)
+		if l.g.metrics != nil {
+			l.g.metrics.rejectedConns.Add(context.Background(), 1, listenerAttrs(l.server))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package graceful
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestIPFilterAllowed(t *testing.T) {
+	filter := &ipFilter{}
+	var err error
+	filter.allow, err = parseCIDRs([]string{"10.0.0.0/8", "192.168.1.10", "::1"})
+	assert.NoError(t, err)
+	filter.deny, err = parseCIDRs([]string{"10.1.0.0/16"})
+	assert.NoError(t, err)
+
+	for addr, allowed := range map[string]bool{
+		"10.2.3.4":     true,
+		"10.1.2.3":     false,
+		"192.168.1.10": true,
+		"192.168.1.11": false,
+		"::1":          true,
+		"2001:db8::1":  false,
+	} {
+		assert.Equal(t, allowed, filter.allowed(&net.TCPAddr{IP: net.ParseIP(addr)}), addr)
+	}
+	assert.True(t, filter.allowed(&net.UnixAddr{Name: "/tmp/graceful.sock", Net: "unix"}))
+
+	_, err = parseCIDRs([]string{"10.0.0.0/33"})
+	assert.EqualError(t, err, `invalid cidr "10.0.0.0/33"`)
+	_, err = parseCIDRs([]string{"localhost"})
+	assert.EqualError(t, err, `invalid cidr "localhost"`)
+}
+
+func TestWithIPFilter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	router, err := Default(
+		WithAddr("127.0.0.1:8149"),
+		WithIPFilter(nil, []string{"127.0.0.0/8"}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:8149")
+	assert.NoError(t, err)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NoError(t, router.Stop())
+
+	metrics := collect(t, reader)
+	if sum, ok := metrics["graceful.listener.rejected"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+	}
+	_, ok := metrics["graceful.listener.accepted"]
+	assert.False(t, ok)
+
+	_, err = Default(WithIPFilter([]string{"10.0.0.0"}, []string{"bad"}))
+	assert.EqualError(t, err, `invalid cidr "bad"`)
+}
@@ -0,0 +1,20 @@
+package graceful
+
+import "fmt"
+
+// WithLaunchdSockets configure a http.Server for every listener of the socket with the given
+// name in the Sockets dictionary of the launchd job, so that macOS daemons get their listeners
+// from launchd, with socket activation, and are still gracefully shut down. The listeners are
+// retrieved with launch_activate_socket, which requires cgo and fails on other platforms.
+func WithLaunchdSockets(name string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		fds, err := launchdFds(name)
+		if err != nil {
+			return nil, donothing, err
+		}
+		if len(fds) == 0 {
+			return nil, donothing, fmt.Errorf("no launchd sockets %q", name)
+		}
+		return nil, donothing, g.applyFds(fds)
+	})
+}
@@ -0,0 +1,34 @@
+//go:build darwin && cgo
+
+package graceful
+
+/*
+#include <launch.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// launchdFds returns the file descriptors of the launchd socket with the given name.
+func launchdFds(name string) ([]uintptr, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var fds *C.int
+	var count C.size_t
+	if errno := C.launch_activate_socket(cName, &fds, &count); errno != 0 {
+		return nil, fmt.Errorf("launchd socket %q: %w", name, syscall.Errno(errno))
+	}
+	defer C.free(unsafe.Pointer(fds))
+
+	result := make([]uintptr, 0, int(count))
+	for _, fd := range unsafe.Slice(fds, int(count)) {
+		result = append(result, uintptr(fd))
+	}
+	return result, nil
+}
@@ -0,0 +1,10 @@
+//go:build !darwin || !cgo
+
+package graceful
+
+import "errors"
+
+// launchdFds is not supported on this platform.
+func launchdFds(string) ([]uintptr, error) {
+	return nil, errors.New("launchd sockets: unsupported platform")
+}
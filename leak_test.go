@@ -0,0 +1,44 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+func TestStartStopNoGoroutineLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	router, err := Default(
+		WithAddr("127.0.0.1:8128"),
+		WithHealthEndpoints(),
+		WithIdleShutdown(time.Hour),
+		WithMemoryLimitRestart(1<<40),
+		WithScheduledRestart("@yearly", 0),
+		WithBeforeShutdown("before", func(context.Context) error { return nil }),
+		WithWarmup(func(context.Context) error { return nil }, time.Second),
+		WithStreamCutoff(time.Hour),
+		WithAdmin(AdminConfig{Addr: "127.0.0.1:8129", Token: "secret"}),
+		WithHAProxyAgent("127.0.0.1:8130"),
+		WithControlSocket(filepath.Join(t.TempDir(), "control.sock")),
+		WithReload(func(context.Context) ([]Option, error) {
+			return []Option{WithAddr("127.0.0.1:8131")}, nil
+		}),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, router.Start())
+		assert.Eventually(t, func() bool {
+			return reachable(t, "http://127.0.0.1:8128/healthz")
+		}, time.Second, time.Millisecond)
+		assert.NoError(t, router.Stop())
+	}
+	http.DefaultClient.CloseIdleConnections()
+	router.Close()
+}
@@ -0,0 +1,310 @@
+// Package lifecycle runs named hooks in ordered phases, with timeouts, priorities, retries and
+// error policies, e.g. the before and after shutdown hooks of a graceful.Graceful instance, or the
+// shutdown of a worker binary without an HTTP server:
+//
+//	l := lifecycle.New([]lifecycle.Phase{{Name: "stop"}, {Name: "flush", Timeout: 5 * time.Second}})
+//	l.Add("stop", "consumer", consumer.Stop, lifecycle.WithPriority(10))
+//	l.Add("flush", "metrics", metrics.Flush, lifecycle.WithRetry(3, 100*time.Millisecond))
+//	err := l.Run(ctx)
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Hook is a function run in a phase of the lifecycle.
+type Hook func(ctx context.Context) error
+
+// Phase is a step of the lifecycle, running its hooks one after the other.
+type Phase struct {
+	// Name identifies the phase.
+	Name string
+	// Timeout bounds the whole phase, zero for no timeout of its own.
+	Timeout time.Duration
+}
+
+// ErrorPolicy tells what a failed hook does to the rest of the lifecycle.
+type ErrorPolicy int
+
+const (
+	// Continue runs the next hooks regardless, and returns the error of the hook from Run. It is the
+	// default policy.
+	Continue ErrorPolicy = iota
+	// Abort skips the next hooks and phases, and returns the error of the hook from Run.
+	Abort
+	// Ignore runs the next hooks regardless, and only reports the error of the hook to the observer.
+	Ignore
+)
+
+// HookError is returned when a hook fails.
+type HookError struct {
+	// Name is the name of the hook.
+	Name string
+	// Phase is the phase the hook ran in.
+	Phase string
+	// Err is the error returned by the hook.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Phase, e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the hook.
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Result is the outcome of one attempt of a hook, passed to the observer.
+type Result struct {
+	// Phase is the phase the hook ran in.
+	Phase string
+	// Hook is the name of the hook.
+	Hook string
+	// Attempt numbers the attempts of the hook, from 1.
+	Attempt int
+	// Attempts is the number of attempts the hook is configured with, see WithRetry.
+	Attempts int
+	// Duration is how long the attempt took.
+	Duration time.Duration
+	// Err is the *HookError the attempt failed with, if any.
+	Err error
+	// Retry tells whether the failed attempt is retried.
+	Retry bool
+}
+
+// HookOption configures a hook added with Add.
+type HookOption func(*hook)
+
+// WithTimeout bounds every attempt of the hook to d.
+func WithTimeout(d time.Duration) HookOption {
+	return func(h *hook) {
+		h.timeout = d
+	}
+}
+
+// WithPriority configure the priority of the hook in its phase: the hooks with a higher priority
+// run first, and the hooks of the same priority in the order they were added. Defaults to 0.
+func WithPriority(priority int) HookOption {
+	return func(h *hook) {
+		h.priority = priority
+	}
+}
+
+// WithRetry runs the hook up to attempts times until it succeeds, waiting backoff before the first
+// retry, doubled before every next one. The retries stop once the context of the phase is done.
+func WithRetry(attempts int, backoff time.Duration) HookOption {
+	return func(h *hook) {
+		h.attempts = attempts
+		h.backoff = backoff
+	}
+}
+
+// WithErrorPolicy configure what a failure of the hook does to the rest of the lifecycle. Defaults
+// to Continue.
+func WithErrorPolicy(policy ErrorPolicy) HookOption {
+	return func(h *hook) {
+		h.policy = policy
+	}
+}
+
+// hook is a named Hook of a phase.
+type hook struct {
+	name     string
+	fn       Hook
+	timeout  time.Duration
+	priority int
+	attempts int
+	backoff  time.Duration
+	policy   ErrorPolicy
+}
+
+// Option configures a Lifecycle.
+type Option func(*Lifecycle)
+
+// WithObserver configure fn to receive the result of every attempt of a hook, e.g. to log or
+// record them.
+func WithObserver(fn func(Result)) Option {
+	return func(l *Lifecycle) {
+		l.observer = fn
+	}
+}
+
+// Lifecycle runs the hooks of its phases, in order. It is safe for concurrent use.
+type Lifecycle struct {
+	mu       sync.Mutex
+	phases   []Phase
+	hooks    map[string][]hook
+	observer func(Result)
+}
+
+// New creates a Lifecycle with the given phases, run in order.
+func New(phases []Phase, opts ...Option) *Lifecycle {
+	l := &Lifecycle{
+		phases:   phases,
+		hooks:    make(map[string][]hook, len(phases)),
+		observer: func(Result) {},
+	}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// Add adds the hook named name to the given phase.
+func (l *Lifecycle) Add(phase, name string, fn Hook, opts ...HookOption) error {
+	if fn == nil {
+		return errors.New("nil hook")
+	}
+	h := hook{name: name, fn: fn, attempts: 1}
+	for _, o := range opts {
+		o(&h)
+	}
+	if h.attempts < 1 {
+		h.attempts = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.hasPhase(phase) {
+		return fmt.Errorf("unknown phase %q", phase)
+	}
+	l.hooks[phase] = append(l.hooks[phase], h)
+	return nil
+}
+
+// hasPhase reports whether the lifecycle has the given phase.
+func (l *Lifecycle) hasPhase(name string) bool {
+	for _, p := range l.phases {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs every phase in order with ctx, and returns the errors of the failed hooks, joined. The
+// hooks run even if ctx is done, so that they can release what they can, and the error of a hook
+// with the Abort policy skips the next hooks and phases.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	l.mu.Lock()
+	phases := l.phases
+	l.mu.Unlock()
+
+	var errs []error
+	for _, p := range phases {
+		aborted, err := l.runPhase(ctx, p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if aborted {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunPhase runs the hooks of the given phase with ctx, and returns the errors of the failed hooks,
+// joined.
+func (l *Lifecycle) RunPhase(ctx context.Context, phase string) error {
+	l.mu.Lock()
+	var p Phase
+	found := false
+	for _, candidate := range l.phases {
+		if candidate.Name == phase {
+			p, found = candidate, true
+			break
+		}
+	}
+	l.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("unknown phase %q", phase)
+	}
+	_, err := l.runPhase(ctx, p)
+	return err
+}
+
+// runPhase runs the hooks of p by priority, and reports whether a hook aborted the lifecycle.
+func (l *Lifecycle) runPhase(ctx context.Context, p Phase) (bool, error) {
+	l.mu.Lock()
+	hooks := append([]hook(nil), l.hooks[p.Name]...)
+	l.mu.Unlock()
+	if len(hooks) == 0 {
+		return false, nil
+	}
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].priority > hooks[j].priority })
+
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for _, h := range hooks {
+		err := l.runHook(ctx, p.Name, h)
+		if err == nil || h.policy == Ignore {
+			continue
+		}
+		errs = append(errs, err)
+		if h.policy == Abort {
+			return true, errors.Join(errs...)
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// runHook runs h with ctx until it succeeds or its attempts are exhausted, and returns the error of
+// the last attempt.
+func (l *Lifecycle) runHook(ctx context.Context, phase string, h hook) error {
+	backoff := h.backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := h.call(ctx)
+		result := Result{Phase: phase, Hook: h.name, Attempt: attempt, Attempts: h.attempts, Duration: time.Since(start)}
+		if err == nil {
+			l.observer(result)
+			return nil
+		}
+
+		result.Err = &HookError{Name: h.name, Phase: phase, Err: err}
+		result.Retry = attempt < h.attempts && WaitBackoff(ctx, backoff)
+		l.observer(result)
+		if !result.Retry {
+			return result.Err
+		}
+		backoff *= 2
+	}
+}
+
+// call runs one attempt of h, bounded by its timeout.
+func (h hook) call(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	return h.fn(ctx)
+}
+
+// WaitBackoff blocks for d, the backoff before the next attempt of a retried call. It returns
+// false if ctx is done first.
+func WaitBackoff(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
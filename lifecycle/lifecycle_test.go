@@ -0,0 +1,99 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	var order []string
+	record := func(name string, err error) Hook {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	l := New([]Phase{{Name: "stop"}, {Name: "flush"}})
+	assert.NoError(t, l.Add("flush", "metrics", record("metrics", nil)))
+	assert.NoError(t, l.Add("stop", "http", record("http", nil)))
+	assert.NoError(t, l.Add("stop", "consumer", record("consumer", errors.New("busy")), WithPriority(10)))
+	assert.NoError(t, l.Add("stop", "cache", record("cache", errors.New("gone")), WithErrorPolicy(Ignore)))
+
+	err := l.Run(context.Background())
+	assert.EqualError(t, err, `stop "consumer": busy`)
+	var hookErr *HookError
+	if assert.ErrorAs(t, err, &hookErr) {
+		assert.Equal(t, "consumer", hookErr.Name)
+		assert.Equal(t, "stop", hookErr.Phase)
+	}
+	assert.Equal(t, []string{"consumer", "http", "cache", "metrics"}, order)
+
+	assert.EqualError(t, l.Add("start", "http", record("http", nil)), `unknown phase "start"`)
+	assert.EqualError(t, l.Add("stop", "http", nil), "nil hook")
+	assert.EqualError(t, l.RunPhase(context.Background(), "start"), `unknown phase "start"`)
+}
+
+func TestAbort(t *testing.T) {
+	var ran []string
+	l := New([]Phase{{Name: "stop"}, {Name: "flush"}})
+	assert.NoError(t, l.Add("stop", "leader", func(context.Context) error {
+		ran = append(ran, "leader")
+		return errors.New("lease lost")
+	}, WithErrorPolicy(Abort)))
+	assert.NoError(t, l.Add("stop", "http", func(context.Context) error {
+		ran = append(ran, "http")
+		return nil
+	}))
+	assert.NoError(t, l.Add("flush", "metrics", func(context.Context) error {
+		ran = append(ran, "metrics")
+		return nil
+	}))
+
+	assert.EqualError(t, l.Run(context.Background()), `stop "leader": lease lost`)
+	assert.Equal(t, []string{"leader"}, ran)
+}
+
+func TestRetryAndTimeouts(t *testing.T) {
+	var results []Result
+	l := New([]Phase{{Name: "stop", Timeout: 100 * time.Millisecond}, {Name: "flush"}},
+		WithObserver(func(r Result) { results = append(results, r) }))
+
+	attempts := 0
+	assert.NoError(t, l.Add("flush", "metrics", func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	}, WithRetry(3, time.Millisecond)))
+	assert.NoError(t, l.Add("stop", "slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond)))
+	assert.NoError(t, l.Add("stop", "deadline", func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 100*time.Millisecond)
+		return nil
+	}))
+
+	err := l.Run(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 3, attempts)
+	if assert.Len(t, results, 5) {
+		assert.Equal(t, "slow", results[0].Hook)
+		assert.False(t, results[0].Retry)
+		assert.Equal(t, "deadline", results[1].Hook)
+		for i, r := range results[2:] {
+			assert.Equal(t, "metrics", r.Hook)
+			assert.Equal(t, i+1, r.Attempt)
+			assert.Equal(t, 3, r.Attempts)
+			assert.Equal(t, i < 2, r.Retry)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// connStartKey is the connection context key of the time the connection was accepted.
+type connStartKey struct{}
+
+// WithMaxConnLifetime configure the maximum lifetime of the connections of the http.Servers of the
+// Graceful instance: the response to a request on a connection older than d closes it, by a
+// Connection: close header, or a GOAWAY frame over HTTP/2. It rebalances the load across the
+// replicas behind the long-lived keep-alive clients, and shortens the next drains.
+func WithMaxConnLifetime(d time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if d <= 0 {
+			return nil, donothing, errors.New("invalid max connection lifetime")
+		}
+		g.maxConnLifetime = d
+		return nil, donothing, nil
+	})
+}
+
+// connContext returns an http.Server ConnContext hook recording the time every connection is
+// accepted, on top of the context returned by next, if any.
+func (g *Graceful) connContext(
+	next func(context.Context, net.Conn) context.Context,
+) func(context.Context, net.Conn) context.Context {
+	return func(ctx context.Context, conn net.Conn) context.Context {
+		if next != nil {
+			ctx = next(ctx, conn)
+		}
+		return context.WithValue(ctx, connStartKey{}, time.Now())
+	}
+}
+
+// limitConnLifetime closes the connection of req after the response if it outlived the maximum
+// connection lifetime.
+func (g *Graceful) limitConnLifetime(w http.ResponseWriter, req *http.Request) {
+	start, ok := req.Context().Value(connStartKey{}).(time.Time)
+	if ok && time.Since(start) > g.maxConnLifetime {
+		w.Header().Set("Connection", "close")
+	}
+}
@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type connNameKey struct{}
+
+func TestWithMaxConnLifetime(t *testing.T) {
+	srv := &http.Server{
+		Addr:              "127.0.0.1:8148",
+		ReadHeaderTimeout: time.Second,
+		ConnContext: func(ctx context.Context, _ net.Conn) context.Context {
+			return context.WithValue(ctx, connNameKey{}, "client")
+		},
+	}
+	router, err := Default(WithServer(srv), WithMaxConnLifetime(100*time.Millisecond))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Context().Value(connNameKey{}).(string))
+	})
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	client := &http.Client{Transport: &http.Transport{}}
+	get := func() *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://127.0.0.1:8148/example", nil)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "client", string(body))
+		return resp
+	}
+
+	assert.False(t, get().Close)
+	time.Sleep(150 * time.Millisecond)
+	// The connection outlived its lifetime, the response closes it.
+	assert.True(t, get().Close)
+	assert.False(t, get().Close)
+	assert.NoError(t, router.Stop())
+
+	_, err = Default(WithMaxConnLifetime(0))
+	assert.EqualError(t, err, "invalid max connection lifetime")
+}
@@ -0,0 +1,142 @@
+package graceful
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The modes of the loopback guard, see WithLoopback and WithDebugLoopback.
+const (
+	loopbackOff = iota
+	loopbackDebug
+	loopbackAlways
+)
+
+// WithLoopback configure the TCP listeners managed by the Graceful instance to bind the loopback
+// interface instead of every interface when their address has no host, or an unspecified one such
+// as "0.0.0.0", logging a warning for every rewritten address.
+func WithLoopback() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.loopback = loopbackAlways
+		return nil, donothing, nil
+	})
+}
+
+// WithDebugLoopback configure the loopback guard of WithLoopback only while gin runs in debug
+// mode, so that a development server is not exposed on every interface by accident.
+func WithDebugLoopback() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.loopback = loopbackDebug
+		return nil, donothing, nil
+	})
+}
+
+// listenTCP creates a TCP listener for s on the given address, or on fallback if addr is
+// empty, with the configured net.ListenConfig, and wraps it with the connection settings
+// configured on the Graceful instance.
+func (g *Graceful) listenTCP(ctx context.Context, s *managedServer, addr, fallback string) (net.Listener, error) {
+	if addr == "" {
+		addr = fallback
+	}
+	if g.loopback == loopbackAlways || g.loopback == loopbackDebug && gin.IsDebugging() {
+		if rewritten, ok := loopbackAddr(addr); ok {
+			g.log().Warn("wildcard address bound to loopback", "addr", addr, "loopback", rewritten)
+			addr = rewritten
+		}
+	}
+
+	l, err := g.listenConfig.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, bindError("tcp", addr, err)
+	}
+
+	return g.wrapListener(s, l), nil
+}
+
+// loopbackAddr returns addr with its host replaced by the loopback address of the same family,
+// and whether addr was a wildcard address.
+func loopbackAddr(addr string) (string, bool) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, false
+	}
+	if host == "" {
+		return net.JoinHostPort("127.0.0.1", port), true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsUnspecified() {
+		return addr, false
+	}
+	if ip.To4() == nil {
+		return net.JoinHostPort("::1", port), true
+	}
+	return net.JoinHostPort("127.0.0.1", port), true
+}
+
+// wrapListener records the given net.Listener as bound for s and wraps it so that accepted
+// connections receive the connection settings configured on the Graceful instance.
+func (g *Graceful) wrapListener(s *managedServer, l net.Listener) net.Listener {
+	s.bound(l.Addr().String())
+	g.debugLog("listener created", serverAttrs(s)...)
+	l = newPauseListener(g, l)
+	if g.acceptRate > 0 {
+		l = newAcceptRateListener(g, l)
+	}
+	if g.ipFilter != nil {
+		l = &ipFilterListener{Listener: l, g: g, server: s}
+	}
+	if g.metrics != nil {
+		l = &metricsListener{Listener: l, metrics: g.metrics, attrs: listenerAttrs(s)}
+	}
+	l = &statusListener{Listener: l, server: s}
+
+	if g.slowStart > 0 {
+		l = newSlowStartListener(l, g.slowStart, g.slowStartRate)
+	}
+
+	if g.tcpKeepAlive != 0 {
+		l = &keepAliveListener{Listener: l, period: g.tcpKeepAlive}
+	}
+
+	// The connections are wrapped last, so that the other listeners see the accepted ones.
+	if g.bandwidth != nil {
+		l = &bandwidthListener{Listener: l, g: g}
+	}
+	if g.trafficStats {
+		s.traffic.CompareAndSwap(nil, &traffic{})
+		l = &trafficListener{Listener: l, traffic: s.traffic.Load()}
+	}
+
+	return l
+}
+
+// keepAliveListener applies a keep-alive period to every accepted TCP connection.
+type keepAliveListener struct {
+	net.Listener
+	period time.Duration
+}
+
+// Accept waits for and returns the next connection, with its keep-alive settings applied.
+func (l *keepAliveListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return conn, nil
+	}
+
+	if l.period < 0 {
+		_ = tc.SetKeepAlive(false)
+		return conn, nil
+	}
+
+	_ = tc.SetKeepAlive(true)
+	_ = tc.SetKeepAlivePeriod(l.period)
+	return conn, nil
+}
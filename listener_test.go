@@ -0,0 +1,160 @@
+package graceful
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTCPKeepAlive(t *testing.T) {
+	router, err := New(nil, WithTCPKeepAlive(30*time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, keepAliveOf(t, router))
+
+	router, err = New(nil, WithoutTCPKeepAlive())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, keepAliveOf(t, router))
+}
+
+func TestLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		":8080":         "127.0.0.1:8080",
+		"0.0.0.0:8080":  "127.0.0.1:8080",
+		"[::]:8080":     "[::1]:8080",
+		":http":         "127.0.0.1:http",
+		"10.0.0.1:8080": "",
+		"localhost:80":  "",
+		"invalid":       "",
+	} {
+		got, ok := loopbackAddr(addr)
+		if want == "" {
+			assert.False(t, ok, addr)
+			assert.Equal(t, addr, got)
+			continue
+		}
+		assert.True(t, ok, addr)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestWithLoopback(t *testing.T) {
+	router, err := New(nil, WithLoopback())
+	assert.NoError(t, err)
+	l, err := router.listenTCP(context.Background(), &managedServer{}, ":0", "")
+	assert.NoError(t, err)
+	defer l.Close()
+	assert.True(t, l.Addr().(*net.TCPAddr).IP.IsLoopback())
+
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(gin.DebugMode)
+	router, err = New(nil, WithDebugLoopback())
+	assert.NoError(t, err)
+	l, err = router.listenTCP(context.Background(), &managedServer{}, ":0", "")
+	assert.NoError(t, err)
+	defer l.Close()
+	assert.True(t, l.Addr().(*net.TCPAddr).IP.IsUnspecified())
+}
+
+// keepAliveOf returns the SO_KEEPALIVE value of a connection accepted through the
+// listener wrapped by the given Graceful instance.
+func keepAliveOf(t *testing.T, g *Graceful) int {
+	l, err := g.listenTCP(context.Background(), &managedServer{}, "localhost:0", "")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	raw, err := conn.(*net.TCPConn).SyscallConn()
+	assert.NoError(t, err)
+
+	var value int
+	assert.NoError(t, raw.Control(func(fd uintptr) {
+		value, err = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_KEEPALIVE)
+	}))
+	assert.NoError(t, err)
+
+	return value
+}
+
+func TestWithSlowStart(t *testing.T) {
+	router, err := New(nil, WithSlowStart(time.Second, 100))
+	assert.NoError(t, err)
+
+	l, err := router.listenTCP(context.Background(), &managedServer{}, "localhost:0", "")
+	assert.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		client, err := net.Dial("tcp", l.Addr().String())
+		assert.NoError(t, err)
+		defer client.Close()
+	}
+
+	start := time.Now()
+	conn, err := l.Accept()
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	conn, err = l.Accept()
+	assert.NoError(t, err)
+	conn.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestSlowStartAllowance(t *testing.T) {
+	l := newSlowStartListener(nil, time.Second, 100)
+	assert.Zero(t, l.allowedAt(0))
+	assert.InDelta(t, 141*time.Millisecond, l.allowedAt(1), float64(time.Millisecond))
+	assert.Equal(t, 200*time.Millisecond, l.allowedAt(2))
+	assert.Equal(t, time.Second, l.allowedAt(50))
+	assert.Equal(t, time.Second, l.allowedAt(1000))
+}
+
+func TestSlowStartClose(t *testing.T) {
+	inner, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	l := newSlowStartListener(inner, time.Hour, 1)
+	l.accepted = 1
+
+	done := make(chan error)
+	go func() {
+		_, err := l.Accept()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, l.Close())
+	assert.ErrorIs(t, <-done, net.ErrClosed)
+}
+
+func TestWithListenConfig(t *testing.T) {
+	var networks []string
+	lc := net.ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+		networks = append(networks, network)
+		return nil
+	}}
+
+	unixSocket := filepath.Join(t.TempDir(), "graceful.sock")
+	router, err := New(nil, WithUnix(unixSocket), WithListenConfig(lc))
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.Equal(t, []string{"unix"}, networks)
+
+	l, err := router.listenTCP(context.Background(), &managedServer{}, "localhost:0", "")
+	assert.NoError(t, err)
+	l.Close()
+	assert.Equal(t, []string{"unix", "tcp4"}, networks)
+}
@@ -0,0 +1,142 @@
+package graceful
+
+import (
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+)
+
+// Logger is the logging interface of the package. Every method takes a message followed by
+// alternating keys and values, so that a *slog.Logger can be used as is.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+var _ Logger = (*slog.Logger)(nil)
+
+// loggerOption is the Option returned by WithLogger.
+type loggerOption struct {
+	logger Logger
+}
+
+// applyEarly configures the logger.
+func (o loggerOption) applyEarly(g *Graceful) {
+	g.logger = o.logger
+}
+
+// apply configures the logger.
+func (o loggerOption) apply(g *Graceful) (listenAndServe, cleanup, error) {
+	o.applyEarly(g)
+	return nil, donothing, nil
+}
+
+// WithLogger configure the logger receiving the lifecycle events of the Graceful instance,
+// such as server failures, restarts and shutdowns. Nothing is logged by default. A logger with a
+// Sync method, such as the one returned by ZapLogger, is synced at the end of every shutdown.
+func WithLogger(l Logger) Option {
+	return loggerOption{logger: l}
+}
+
+// log returns the configured logger, or a logger discarding everything. In debug mode, the
+// default logger writes to the standard error instead.
+func (g *Graceful) log() Logger {
+	if g.logger != nil {
+		return g.logger
+	}
+	if g.debug {
+		return stderrDebugLogger
+	}
+	return nopLogger{}
+}
+
+// syncer is implemented by the loggers buffering their logs, such as the zap loggers.
+type syncer interface {
+	Sync() error
+}
+
+// syncLogger flushes the buffered logs of the configured logger, if it buffers them, so that the
+// logs of a shutdown reach their destination before the process exits. The error is ignored, the
+// logger being unable to log it.
+func (g *Graceful) syncLogger() {
+	if l, ok := g.logger.(syncer); ok {
+		_ = l.Sync()
+	}
+}
+
+// nopLogger discards everything.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// ZapLogger returns a Logger writing to the given zap logger.
+func ZapLogger(z *zap.Logger) Logger {
+	return zapLogger{z.Sugar()}
+}
+
+// zapLogger adapts a zap logger to the Logger interface.
+type zapLogger struct {
+	s *zap.SugaredLogger
+}
+
+func (l zapLogger) Debug(msg string, keysAndValues ...any) { l.s.Debugw(msg, keysAndValues...) }
+func (l zapLogger) Info(msg string, keysAndValues ...any)  { l.s.Infow(msg, keysAndValues...) }
+func (l zapLogger) Warn(msg string, keysAndValues ...any)  { l.s.Warnw(msg, keysAndValues...) }
+func (l zapLogger) Error(msg string, keysAndValues ...any) { l.s.Errorw(msg, keysAndValues...) }
+
+// Sync flushes the buffered logs of the zap logger.
+func (l zapLogger) Sync() error { return l.s.Sync() }
+
+// LogrusLogger returns a Logger writing to the given logrus logger or entry, with the keys and
+// values as fields.
+func LogrusLogger(l logrus.FieldLogger) Logger {
+	return logrusLogger{l}
+}
+
+// logrusLogger adapts a logrus logger to the Logger interface.
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+func (l logrusLogger) Debug(msg string, keysAndValues ...any) {
+	l.l.WithFields(logrusFields(keysAndValues)).Debug(msg)
+}
+
+func (l logrusLogger) Info(msg string, keysAndValues ...any) {
+	l.l.WithFields(logrusFields(keysAndValues)).Info(msg)
+}
+
+func (l logrusLogger) Warn(msg string, keysAndValues ...any) {
+	l.l.WithFields(logrusFields(keysAndValues)).Warn(msg)
+}
+
+func (l logrusLogger) Error(msg string, keysAndValues ...any) {
+	l.l.WithFields(logrusFields(keysAndValues)).Error(msg)
+}
+
+// logrusFields turns alternating keys and values into logrus fields. Like slog, a value
+// without a key is recorded under the "!BADKEY" key.
+func logrusFields(keysAndValues []any) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			fields["!BADKEY"] = keysAndValues[i]
+			continue
+		}
+		fields[key] = keysAndValues[i+1]
+		i++
+	}
+	return fields
+}
+
+// serverAttrs returns the keys and values identifying s in the logs.
+func serverAttrs(s *managedServer, keysAndValues ...any) []any {
+	return append([]any{"server", s.label()}, keysAndValues...)
+}
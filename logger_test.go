@@ -0,0 +1,97 @@
+package graceful
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	router, err := Default(
+		withFailingAddr(":8096", 1),
+		WithRestartPolicy(RestartPolicy{Backoff: time.Millisecond}),
+		WithLogger(ZapLogger(zap.New(core))),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	restarts := logs.FilterMessage("restarting server").All()
+	if assert.Len(t, restarts, 1) {
+		assert.Equal(t, zap.WarnLevel, restarts[0].Level)
+		assert.Equal(t, "transient", restarts[0].ContextMap()["error"])
+	}
+	assert.Equal(t, 1, logs.FilterMessage("shutdown complete").Len())
+}
+
+func TestLogrusLogger(t *testing.T) {
+	logger, hook := logrustest.NewNullLogger()
+	router, err := Default(
+		withFailingAddr(":8096", 1),
+		WithLogger(LogrusLogger(logger)),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "transient")
+
+	if entry := hook.LastEntry(); assert.NotNil(t, entry) {
+		assert.Equal(t, logrus.ErrorLevel, entry.Level)
+		assert.Equal(t, "server failed", entry.Message)
+		assert.EqualError(t, entry.Data["error"].(error), "transient")
+	}
+}
+
+func TestLogrusFields(t *testing.T) {
+	assert.Equal(t, logrus.Fields{"a": 1, "!BADKEY": "b"}, logrusFields([]any{"a", 1, "b"}))
+}
+
+// syncBuffer is a zapcore.WriteSyncer counting its syncs.
+type syncBuffer struct {
+	bytes.Buffer
+	syncs atomic.Int32
+}
+
+func (b *syncBuffer) Sync() error {
+	b.syncs.Add(1)
+	return nil
+}
+
+func TestSyncLogger(t *testing.T) {
+	buf := &syncBuffer{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), buf, zap.InfoLevel)
+	hookSyncer := &syncBuffer{}
+	router, err := Default(
+		WithAddr("127.0.0.1:8161"),
+		WithLogger(ZapLogger(zap.New(core))),
+		WithAfterShutdown("sync", SyncHook(hookSyncer)),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	assert.Equal(t, int32(1), hookSyncer.syncs.Load())
+	// The logger is synced once the shutdown is logged.
+	assert.Equal(t, int32(1), buf.syncs.Load())
+	assert.Contains(t, buf.String(), "shutdown complete")
+
+	_, err = Default(WithAfterShutdown("sync", SyncHook(nil)))
+	assert.EqualError(t, err, "nil after shutdown hook")
+}
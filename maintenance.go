@@ -0,0 +1,61 @@
+package graceful
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetMaintenance turns the maintenance mode on or off. In maintenance mode, every request is
+// answered by the maintenance handler, except for the exempted paths and the paths exempted
+// from draining such as the health endpoints. Unlike Drain, the listeners and the lifecycle of
+// the Graceful instance are left untouched, so the mode can last indefinitely.
+func (g *Graceful) SetMaintenance(on bool) {
+	g.maintenance.Store(on)
+}
+
+// InMaintenance reports whether the maintenance mode is on.
+func (g *Graceful) InMaintenance() bool {
+	return g.maintenance.Load()
+}
+
+// WithMaintenanceHandler configure the handler answering the requests in maintenance mode, e.g.
+// with a maintenance page or a JSON error. The handler must write a response, otherwise 404 Not
+// Found is returned. Defaults to 503 Service Unavailable.
+func WithMaintenanceHandler(handler gin.HandlerFunc) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		engine := gin.New()
+		engine.NoRoute(handler)
+		g.maintenanceHandler = engine
+		return nil, donothing, nil
+	})
+}
+
+// WithMaintenanceExempt exempt the paths matching the given patterns from the maintenance mode.
+// A pattern ending with "*" matches every path with the preceding prefix, e.g. "/admin/*".
+func WithMaintenanceExempt(patterns ...string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.maintenanceExempt = append(g.maintenanceExempt, patterns...)
+		return nil, donothing, nil
+	})
+}
+
+// isMaintenanceExempt reports whether the given path is exempted from the maintenance mode.
+func (g *Graceful) isMaintenanceExempt(path string) bool {
+	for _, pattern := range g.maintenanceExempt {
+		if matchRoute(pattern, path) {
+			return true
+		}
+	}
+	return g.isDrainExempt(path)
+}
+
+// serveMaintenance answers a request in maintenance mode.
+func (g *Graceful) serveMaintenance(w http.ResponseWriter, req *http.Request) {
+	if g.maintenanceHandler != nil {
+		g.maintenanceHandler.ServeHTTP(w, req)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMaintenance(t *testing.T) {
+	router, err := New(gin.New(),
+		WithHealthEndpoints(),
+		WithMaintenanceExempt("/admin/*"),
+		WithMaintenanceHandler(func(c *gin.Context) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "maintenance"})
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/users", func(c *gin.Context) { c.String(http.StatusOK, "users") })
+	router.GET("/admin/users", func(c *gin.Context) { c.String(http.StatusOK, "admin") })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/users").Code)
+
+	router.SetMaintenance(true)
+	assert.True(t, router.InMaintenance())
+	w := serve("/users")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"error":"maintenance"}`, w.Body.String())
+	assert.Equal(t, http.StatusOK, serve("/admin/users").Code)
+	assert.NotEqual(t, http.StatusServiceUnavailable, serve("/healthz").Code)
+	assert.False(t, router.Draining())
+
+	router.SetMaintenance(false)
+	assert.Equal(t, http.StatusOK, serve("/users").Code)
+}
+
+func TestSetMaintenanceDefaultHandler(t *testing.T) {
+	router, err := New(gin.New())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.SetMaintenance(true)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("Connection"))
+}
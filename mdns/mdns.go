@@ -0,0 +1,159 @@
+// Package mdns announces a graceful.Graceful instance on the local network with mDNS, also
+// known as Bonjour or DNS-SD, so that LAN tools and dashboards can discover it.
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/grandcat/zeroconf"
+)
+
+const (
+	defaultService = "_http._tcp"
+	defaultDomain  = "local."
+)
+
+// Option configures the announcement of WithMDNS.
+type Option func(*Registry)
+
+// WithService configure the DNS-SD service type announced. Defaults to "_http._tcp".
+func WithService(service string) Option {
+	return func(r *Registry) {
+		r.service = service
+	}
+}
+
+// WithDomain configure the domain of the announcement. Defaults to "local.".
+func WithDomain(domain string) Option {
+	return func(r *Registry) {
+		r.domain = domain
+	}
+}
+
+// WithText configure the TXT records of the announcement, e.g. "version=1.2.0".
+func WithText(text ...string) Option {
+	return func(r *Registry) {
+		r.text = append(r.text, text...)
+	}
+}
+
+// WithMDNS configure the Graceful instance to announce itself with mDNS under the given
+// service instance name once every server is bound and the warmup is complete, and to withdraw
+// the announcement as the first step of a shutdown, see graceful.WithRegistry. The port of the
+// first server bound to a TCP address is announced.
+func WithMDNS(name string, opts ...Option) graceful.Option {
+	return graceful.WithRegistry(New(name, opts...))
+}
+
+// server is the part of zeroconf.Server used by Registry.
+type server interface {
+	Shutdown()
+}
+
+// register announces a service, see zeroconf.Register.
+type register func(instance, service, domain string, port int, text []string, ifaces []net.Interface) (server, error)
+
+// zeroconfRegister announces a service with zeroconf.
+func zeroconfRegister(
+	instance, service, domain string,
+	port int,
+	text []string,
+	ifaces []net.Interface,
+) (server, error) {
+	return zeroconf.Register(instance, service, domain, port, text, ifaces)
+}
+
+// Registry is a graceful.Registry announcing the instance with mDNS.
+type Registry struct {
+	name    string
+	service string
+	domain  string
+	text    []string
+
+	register register
+
+	mu     sync.Mutex
+	server server
+}
+
+var _ graceful.Registry = (*Registry)(nil)
+
+// New returns a Registry announcing the instance with mDNS under the given service instance
+// name, the hostname if empty.
+func New(name string, opts ...Option) *Registry {
+	r := &Registry{
+		name:     name,
+		service:  defaultService,
+		domain:   defaultDomain,
+		register: zeroconfRegister,
+	}
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Register announces the port of the first TCP address of instance.
+func (r *Registry) Register(_ context.Context, instance graceful.Instance) error {
+	port, err := firstPort(instance.Addrs)
+	if err != nil {
+		return err
+	}
+
+	name := r.name
+	if name == "" {
+		if name, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+
+	srv, err := r.register(name, r.service, r.domain, port, r.text, nil)
+	if err != nil {
+		return fmt.Errorf("mdns: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.server != nil {
+		r.server.Shutdown()
+	}
+	r.server = srv
+
+	return nil
+}
+
+// Deregister withdraws the announcement.
+func (r *Registry) Deregister(context.Context, graceful.Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.server != nil {
+		r.server.Shutdown()
+		r.server = nil
+	}
+
+	return nil
+}
+
+// firstPort returns the port of the first TCP address of addrs.
+func firstPort(addrs []string) (int, error) {
+	for _, addr := range addrs {
+		_, p, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		if port, err := strconv.Atoi(p); err == nil && port > 0 {
+			return port, nil
+		}
+	}
+
+	return 0, errors.New("mdns: no tcp address to announce")
+}
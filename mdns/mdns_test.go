@@ -0,0 +1,81 @@
+package mdns
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer records whether the announcement was withdrawn.
+type fakeServer struct {
+	shutdown bool
+}
+
+func (s *fakeServer) Shutdown() {
+	s.shutdown = true
+}
+
+// announcement is a service announced with a fake register.
+type announcement struct {
+	instance, service, domain string
+	port                      int
+	text                      []string
+	server                    *fakeServer
+}
+
+func fakeRegister(announcements *[]*announcement) register {
+	return func(instance, service, domain string, port int, text []string, _ []net.Interface) (server, error) {
+		a := &announcement{instance: instance, service: service, domain: domain, port: port, text: text, server: &fakeServer{}}
+		*announcements = append(*announcements, a)
+		return a.server, nil
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var announcements []*announcement
+	r := New("dashboard", WithService("_dashboard._tcp"), WithText("version=1"))
+	r.register = fakeRegister(&announcements)
+
+	assert.EqualError(t, r.Register(context.Background(), graceful.Instance{}), "mdns: no tcp address to announce")
+
+	instance := graceful.Instance{Addrs: []string{"/tmp/graceful.sock", "[::]:8080"}}
+	assert.NoError(t, r.Register(context.Background(), instance))
+	if assert.Len(t, announcements, 1) {
+		a := announcements[0]
+		assert.Equal(t, "dashboard", a.instance)
+		assert.Equal(t, "_dashboard._tcp", a.service)
+		assert.Equal(t, "local.", a.domain)
+		assert.Equal(t, 8080, a.port)
+		assert.Equal(t, []string{"version=1"}, a.text)
+		assert.False(t, a.server.shutdown)
+	}
+
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+	assert.True(t, announcements[0].server.shutdown)
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+}
+
+func TestWithMDNS(t *testing.T) {
+	var announcements []*announcement
+	r := New("")
+	r.register = fakeRegister(&announcements)
+
+	router, err := graceful.Default(graceful.WithAddr(":8115"), graceful.WithRegistry(r))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	if assert.Len(t, announcements, 1) {
+		assert.NotEmpty(t, announcements[0].instance)
+		assert.Equal(t, "_http._tcp", announcements[0].service)
+		assert.Equal(t, 8115, announcements[0].port)
+		assert.True(t, announcements[0].server.shutdown)
+	}
+}
@@ -0,0 +1,93 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// memoryCheckInterval is the interval between two checks of the memory limit.
+const memoryCheckInterval = time.Second
+
+// RecycleAction is run with the reason once the instance recycled itself and every server is
+// drained, before RunWithContext returns ErrRecycled, see WithRecycleAction.
+type RecycleAction func(reason string) error
+
+// ExitWithCode returns a RecycleAction exiting the process with the given code, e.g. so that a
+// supervisor restarting only the failed processes restarts it.
+func ExitWithCode(code int) RecycleAction {
+	return func(string) error {
+		os.Exit(code)
+		return nil
+	}
+}
+
+// ExecSelf returns a RecycleAction replacing the process with a new instance of its executable,
+// with the same arguments and environment, so that the process is recycled without a supervisor.
+// It is only supported on unix platforms.
+func ExecSelf() RecycleAction {
+	return func(string) error {
+		path, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("exec self: %w", err)
+		}
+		return execSelf(path)
+	}
+}
+
+// WithRecycleAction configure the action run once the instance recycled itself, for whatever
+// reason, and every server is drained. The error of the action, if any, is returned by
+// RunWithContext along with ErrRecycled.
+func WithRecycleAction(action RecycleAction) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.recycleAction = action
+		return nil, donothing, nil
+	})
+}
+
+// WithMemoryLimitRestart configure the instance to recycle itself once the memory of the process
+// exceeds limit bytes: the resident set size where available, or else the memory obtained from
+// the system by the Go runtime. The memory is checked at the start of every run then every
+// second. The run is gracefully shut down, then the action configured with WithRecycleAction,
+// if any, is run, see WithMaxRequests.
+func WithMemoryLimitRestart(limit uint64) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if limit == 0 {
+			return nil, donothing, errors.New("invalid memory limit")
+		}
+		g.memoryLimit = limit
+		return nil, donothing, nil
+	})
+}
+
+// watchMemory recycles the instance once the memory of the process exceeds the limit, until ctx
+// is done.
+func (g *Graceful) watchMemory(ctx context.Context) {
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if usage := memoryUsage(); usage > g.memoryLimit {
+			g.log().Warn("memory limit exceeded", "usage", usage, "limit", g.memoryLimit)
+			g.recycle("memory limit")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runtimeMemory returns the memory obtained from the system by the Go runtime, less the memory
+// released back to it.
+func runtimeMemory() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys - stats.HeapReleased
+}
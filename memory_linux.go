@@ -0,0 +1,20 @@
+package graceful
+
+import (
+	"fmt"
+	"os"
+)
+
+// memoryUsage returns the resident set size of the process.
+func memoryUsage() uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return runtimeMemory()
+	}
+
+	var size, resident uint64
+	if _, err := fmt.Sscan(string(data), &size, &resident); err != nil {
+		return runtimeMemory()
+	}
+	return resident * uint64(os.Getpagesize())
+}
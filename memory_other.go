@@ -0,0 +1,8 @@
+//go:build !linux
+
+package graceful
+
+// memoryUsage returns the memory obtained from the system by the Go runtime.
+func memoryUsage() uint64 {
+	return runtimeMemory()
+}
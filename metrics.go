@@ -0,0 +1,222 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope of the OpenTelemetry instruments of the package.
+const meterName = "github.com/gin-contrib/graceful"
+
+// otelMetrics holds the OpenTelemetry instruments recorded by the Graceful instance.
+type otelMetrics struct {
+	shutdownDuration metric.Float64Histogram
+	drainedRequests  metric.Int64Counter
+	acceptedConns    metric.Int64Counter
+	acceptErrors     metric.Int64Counter
+	rejectedConns    metric.Int64Counter
+}
+
+// WithMeterProvider configure the OpenTelemetry meter provider used to record metrics:
+//   - graceful.connections.active, the number of connections with a request in flight,
+//   - graceful.shutdown.duration, the duration of every completed shutdown, with its reason,
+//   - graceful.requests.drained, the number of requests completed while draining,
+//   - graceful.listener.accepted, the number of connections accepted, by listener address,
+//   - graceful.listener.accept_errors, the number of failed accepts, by listener address,
+//   - graceful.listener.rejected, the number of connections rejected by WithIPFilter, by
+//     listener address,
+//   - graceful.listener.connections, the number of open connections, by listener address,
+//   - graceful.listener.bytes_read and graceful.listener.bytes_written, the traffic of the
+//     connections, by listener address, with WithTrafficStats.
+//
+// The listener metrics also have the label given with Labeled, if any. Comparing the accepted
+// connections with the accept errors and the open connections helps to tell an accept queue
+// overflow from a slow application.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		meter := mp.Meter(meterName)
+
+		shutdownDuration, err := meter.Float64Histogram("graceful.shutdown.duration",
+			metric.WithDescription("Duration of the shutdowns."),
+			metric.WithUnit("s"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		drainedRequests, err := meter.Int64Counter("graceful.requests.drained",
+			metric.WithDescription("Number of requests completed while draining."),
+			metric.WithUnit("{request}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		acceptedConns, err := meter.Int64Counter("graceful.listener.accepted",
+			metric.WithDescription("Number of connections accepted."),
+			metric.WithUnit("{connection}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		acceptErrors, err := meter.Int64Counter("graceful.listener.accept_errors",
+			metric.WithDescription("Number of failed accepts."),
+			metric.WithUnit("{error}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		rejectedConns, err := meter.Int64Counter("graceful.listener.rejected",
+			metric.WithDescription("Number of connections rejected by the IP filter."),
+			metric.WithUnit("{connection}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		openConnections, err := meter.Int64ObservableUpDownCounter("graceful.listener.connections",
+			metric.WithDescription("Number of open connections."),
+			metric.WithUnit("{connection}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		activeConnections, err := meter.Int64ObservableGauge("graceful.connections.active",
+			metric.WithDescription("Number of connections with a request in flight."),
+			metric.WithUnit("{connection}"))
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		bytesRead, err := meter.Int64ObservableCounter("graceful.listener.bytes_read",
+			metric.WithDescription("Number of bytes read from the connections."),
+			metric.WithUnit("By"))
+		if err != nil {
+			return nil, donothing, err
+		}
+		bytesWritten, err := meter.Int64ObservableCounter("graceful.listener.bytes_written",
+			metric.WithDescription("Number of bytes written to the connections."),
+			metric.WithUnit("By"))
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		registration, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			o.ObserveInt64(activeConnections, int64(g.activeConnections()))
+			for _, s := range g.servers.load() {
+				attrs := listenerAttrs(s)
+				if s.conns.Load() {
+					o.ObserveInt64(openConnections, s.open.Load(), attrs)
+				}
+				if g.trafficStats {
+					t := s.traffic.Load()
+					o.ObserveInt64(bytesRead, t.read(), attrs)
+					o.ObserveInt64(bytesWritten, t.written(), attrs)
+				}
+			}
+			return nil
+		}, activeConnections, openConnections, bytesRead, bytesWritten)
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		g.metrics = &otelMetrics{
+			shutdownDuration: shutdownDuration,
+			drainedRequests:  drainedRequests,
+			acceptedConns:    acceptedConns,
+			acceptErrors:     acceptErrors,
+			rejectedConns:    rejectedConns,
+		}
+
+		return nil, func() {
+			_ = registration.Unregister()
+		}, nil
+	})
+}
+
+// activeConnections returns the number of connections with a request in flight over all servers.
+func (g *Graceful) activeConnections() int {
+	active := 0
+	for _, s := range g.servers.load() {
+		active += s.activeConnections()
+	}
+
+	return active
+}
+
+// recordShutdown records the duration of a completed shutdown.
+func (g *Graceful) recordShutdown(reason string, d time.Duration) {
+	if g.statsd != nil {
+		if err := g.statsd.flush(g, reason, d); err != nil {
+			g.notifyError(fmt.Errorf("statsd: %w", err))
+		}
+	}
+	if g.metrics == nil {
+		return
+	}
+
+	g.metrics.shutdownDuration.Record(context.Background(), d.Seconds(),
+		metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+// recordDrained records a request that started before the drain and completed while draining.
+func (g *Graceful) recordDrained(req *http.Request) {
+	if g.metrics == nil {
+		return
+	}
+
+	g.metrics.drainedRequests.Add(req.Context(), 1)
+}
+
+// listenerAttrs returns the attributes identifying the listener of s in the metrics.
+func listenerAttrs(s *managedServer) metric.MeasurementOption {
+	status := s.status()
+	if status.Label != "" {
+		return metric.WithAttributes(attribute.String("listener", status.Addr), attribute.String("label", status.Label))
+	}
+	return metric.WithAttributes(attribute.String("listener", status.Addr))
+}
+
+// recordConnState returns an http.Server ConnState hook counting the open connections of s,
+// then calling next, if any. It returns next as is if no meter provider is configured. The
+// hook only updates the counter of s, observed with the attributes of s once per collection.
+func (g *Graceful) recordConnState(
+	s *managedServer,
+	next func(net.Conn, http.ConnState),
+) func(net.Conn, http.ConnState) {
+	if g.metrics == nil {
+		return next
+	}
+
+	s.conns.Store(true)
+	return func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			s.open.Add(1)
+		case http.StateHijacked, http.StateClosed:
+			s.open.Add(-1)
+		case http.StateActive, http.StateIdle:
+		}
+
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// metricsListener records the accepted connections and the accept errors of a listener.
+type metricsListener struct {
+	net.Listener
+	metrics *otelMetrics
+	attrs   metric.MeasurementOption
+}
+
+// Accept accepts the next connection and records the outcome.
+func (l *metricsListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	switch {
+	case err == nil:
+		l.metrics.acceptedConns.Add(context.Background(), 1, l.attrs)
+	case !errors.Is(err, net.ErrClosed):
+		l.metrics.acceptErrors.Add(context.Background(), 1, l.attrs)
+	}
+	return conn, err
+}
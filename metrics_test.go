@@ -0,0 +1,81 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWithMeterProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	router, err := Default(
+		WithAddr(":8095"),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	release := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8095/slow", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	metrics := collect(t, reader)
+	if gauge, ok := metrics["graceful.connections.active"].(metricdata.Gauge[int64]); assert.True(t, ok) {
+		assert.Equal(t, int64(1), gauge.DataPoints[0].Value)
+	}
+	if sum, ok := metrics["graceful.listener.connections"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+	}
+
+	router.Drain()
+	close(release)
+	<-requestDone
+	assert.NoError(t, router.Stop())
+
+	metrics = collect(t, reader)
+	if sum, ok := metrics["graceful.requests.drained"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+	}
+	if sum, ok := metrics["graceful.listener.accepted"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+		listener, _ := sum.DataPoints[0].Attributes.Value("listener")
+		assert.Contains(t, listener.AsString(), ":8095")
+	}
+	if histogram, ok := metrics["graceful.shutdown.duration"].(metricdata.Histogram[float64]); assert.True(t, ok) {
+		assert.Equal(t, uint64(1), histogram.DataPoints[0].Count)
+	}
+}
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
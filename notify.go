@@ -0,0 +1,201 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notifyTimeout bounds the delivery of a notification without a deadline of its own.
+const notifyTimeout = 5 * time.Second
+
+// The lifecycle events announced by NotifyHook.
+const (
+	// NotifyStarted is announced once every server is bound and the warmup is complete.
+	NotifyStarted = "started"
+	// NotifyDraining is announced when a shutdown starts, before the drain.
+	NotifyDraining = "draining"
+	// NotifyStopped is announced once a shutdown completes.
+	NotifyStopped = "stopped"
+)
+
+// Notification is a lifecycle event of the Graceful instance, announced by NotifyHook.
+type Notification struct {
+	// Event is the lifecycle event, NotifyStarted, NotifyDraining or NotifyStopped.
+	Event string `json:"event"`
+	// Time is when the event happened.
+	Time time.Time `json:"time"`
+	// Hostname and PID identify the process of the instance.
+	Hostname string `json:"hostname"`
+	PID      int    `json:"pid"`
+	// Addrs are the addresses the servers of the instance are bound to.
+	Addrs []string `json:"addrs,omitempty"`
+	// Reason is what triggered the shutdown, for the NotifyDraining and NotifyStopped events.
+	Reason string `json:"reason,omitempty"`
+	// Duration is the duration of the shutdown, for the NotifyStopped event.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Error is the error of the shutdown, if any, for the NotifyStopped event.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes n with Duration in its string form, e.g. "1.5s".
+func (n Notification) MarshalJSON() ([]byte, error) {
+	type plain Notification
+	return json.Marshal(struct {
+		plain
+		Duration duration `json:"duration,omitempty"`
+	}{plain: plain(n), Duration: duration(n.Duration)})
+}
+
+// UnmarshalJSON decodes n with Duration in its string form, see time.ParseDuration.
+func (n *Notification) UnmarshalJSON(data []byte) error {
+	type plain Notification
+	v := struct {
+		*plain
+		Duration duration `json:"duration,omitempty"`
+	}{plain: (*plain)(n), Duration: duration(n.Duration)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.Duration = time.Duration(v.Duration)
+	return nil
+}
+
+// Notifier delivers the lifecycle notifications, e.g. to a chat channel or a webhook.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotifierFunc is an adapter to allow the use of ordinary functions as Notifier.
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+// Notify calls f(ctx, n).
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// NotifyHook configure notifier to announce when the Graceful instance starts, when its drain
+// begins and when its shutdown completes, along with the hostname, process and addresses of the
+// instance, e.g. for small teams without a full observability stack. A failed notification is
+// logged and does not affect the instance.
+func NotifyHook(notifier Notifier) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if notifier == nil {
+			return nil, donothing, errors.New("nil notifier")
+		}
+		n := &notifications{g: g, notifier: notifier}
+		g.registries = append(g.registries, n)
+		g.beforeHooks = append(g.beforeHooks, newShutdownHook("notify", n.draining, nil))
+		g.reporters = append(g.reporters, n.stopped)
+		return nil, donothing, nil
+	})
+}
+
+// notifications announces the lifecycle events of a Graceful instance to a Notifier.
+type notifications struct {
+	g        *Graceful
+	notifier Notifier
+
+	mu    sync.Mutex
+	addrs []string
+}
+
+// Register announces the start of the instance, as the registries are registered with once the
+// instance is ready.
+func (n *notifications) Register(ctx context.Context, instance Instance) error {
+	n.mu.Lock()
+	n.addrs = instance.Addrs
+	n.mu.Unlock()
+
+	n.send(ctx, Notification{Event: NotifyStarted})
+	return nil
+}
+
+// Deregister does nothing, the drain being announced by a before shutdown hook.
+func (n *notifications) Deregister(context.Context, Instance) error {
+	return nil
+}
+
+// draining announces the start of the drain.
+func (n *notifications) draining(ctx context.Context) error {
+	info, _ := HookInfoFromContext(ctx)
+	n.send(ctx, Notification{Event: NotifyDraining, Reason: info.Reason})
+	return nil
+}
+
+// stopped announces the completion of the shutdown described by report.
+func (n *notifications) stopped(report ShutdownReport) {
+	n.send(context.Background(), Notification{
+		Event:    NotifyStopped,
+		Reason:   report.Reason,
+		Duration: report.Duration,
+		Error:    report.Error,
+	})
+}
+
+// send completes and delivers the notification, logging a failure.
+func (n *notifications) send(ctx context.Context, notification Notification) {
+	notification.Time = time.Now()
+	notification.Hostname, _ = os.Hostname()
+	notification.PID = os.Getpid()
+	n.mu.Lock()
+	notification.Addrs = n.addrs
+	n.mu.Unlock()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, notifyTimeout)
+		defer cancel()
+	}
+	if err := n.notifier.Notify(ctx, notification); err != nil {
+		n.g.log().Error("notification failed", "event", notification.Event, "error", err)
+	}
+}
+
+// WebhookNotifier returns a Notifier posting every notification as JSON to the given URL.
+func WebhookNotifier(url string) Notifier {
+	return NotifierFunc(func(ctx context.Context, n Notification) error {
+		return postValue(ctx, url, n)
+	})
+}
+
+// SlackNotifier returns a Notifier posting every notification as a message to the Slack incoming
+// webhook at the given URL.
+func SlackNotifier(webhookURL string) Notifier {
+	return NotifierFunc(func(ctx context.Context, n Notification) error {
+		return postValue(ctx, webhookURL, map[string]string{"text": n.text()})
+	})
+}
+
+// text returns a human readable summary of the notification.
+func (n Notification) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s* (pid %d) %s", n.Hostname, n.PID, n.Event)
+	if len(n.Addrs) > 0 {
+		fmt.Fprintf(&b, " on %s", strings.Join(n.Addrs, ", "))
+	}
+	if n.Reason != "" {
+		fmt.Fprintf(&b, ", reason: %s", n.Reason)
+	}
+	if n.Duration > 0 {
+		fmt.Fprintf(&b, ", in %s", n.Duration.Round(time.Millisecond))
+	}
+	if n.Error != "" {
+		fmt.Fprintf(&b, ", error: %s", n.Error)
+	}
+	return b.String()
+}
+
+// postValue posts v as JSON to url.
+func postValue(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, url, body)
+}
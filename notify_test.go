@@ -0,0 +1,96 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyHook(t *testing.T) {
+	var mu sync.Mutex
+	var notifications []Notification
+	notifier := NotifierFunc(func(_ context.Context, n Notification) error {
+		mu.Lock()
+		notifications = append(notifications, n)
+		mu.Unlock()
+		return errors.New("unreachable")
+	})
+
+	router, err := Default(WithAddr("127.0.0.1:8141"), NotifyHook(notifier))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(notifications) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, notifications, 3) {
+		hostname, _ := os.Hostname()
+		for i, event := range []string{NotifyStarted, NotifyDraining, NotifyStopped} {
+			n := notifications[i]
+			assert.Equal(t, event, n.Event)
+			assert.Equal(t, hostname, n.Hostname)
+			assert.Equal(t, os.Getpid(), n.PID)
+			assert.Equal(t, []string{"127.0.0.1:8141"}, n.Addrs)
+		}
+		assert.Empty(t, notifications[0].Reason)
+		assert.Equal(t, "shutdown", notifications[1].Reason)
+		assert.Equal(t, "shutdown", notifications[2].Reason)
+		assert.Positive(t, notifications[2].Duration)
+	}
+
+	_, err = Default(NotifyHook(nil))
+	assert.EqualError(t, err, "nil notifier")
+}
+
+func TestNotifiers(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received <- body
+	}))
+	defer hook.Close()
+
+	n := Notification{
+		Event:    NotifyStopped,
+		Hostname: "web-1",
+		PID:      42,
+		Addrs:    []string{":8080"},
+		Reason:   "shutdown",
+		Duration: 1500 * time.Millisecond,
+		Error:    "drain timeout",
+	}
+
+	assert.NoError(t, SlackNotifier(hook.URL).Notify(context.Background(), n))
+	assert.Equal(t, map[string]any{
+		"text": "*web-1* (pid 42) stopped on :8080, reason: shutdown, in 1.5s, error: drain timeout",
+	}, <-received)
+
+	assert.NoError(t, WebhookNotifier(hook.URL).Notify(context.Background(), n))
+	body := <-received
+	assert.Equal(t, "stopped", body["event"])
+	assert.Equal(t, "web-1", body["hostname"])
+	assert.Equal(t, "1.5s", body["duration"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	assert.EqualError(t, SlackNotifier(failing.URL).Notify(context.Background(), n), "unexpected status 403 Forbidden")
+}
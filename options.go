@@ -1,11 +1,18 @@
 package graceful
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 // Option specifies instrumentation configuration options.
@@ -15,6 +22,11 @@ type Option interface {
 
 var _ Option = (*optionFunc)(nil)
 
+// earlyOption is implemented by the options applied before every other option by New.
+type earlyOption interface {
+	applyEarly(*Graceful)
+}
+
 type optionFunc func(*Graceful) (listenAndServe, cleanup, error)
 
 // apply applies the option function to the Graceful instance.
@@ -25,56 +37,184 @@ func (o optionFunc) apply(g *Graceful) (listenAndServe, cleanup, error) {
 
 // WithAddr configure a http.Server to listen on the given address.
 func WithAddr(addr string) Option {
-	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
-		return func() error {
-			srv := g.appendHTTPServer()
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := validateAddr(addr); err != nil {
+			return nil, donothing, err
+		}
+		if err := g.claimTCP(addr, ":http"); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", addr, ":http")
+		return func(ctx context.Context, s *managedServer) error {
+			srv := g.appendHTTPServer(s)
 			srv.Addr = addr
 
-			return srv.ListenAndServe()
+			l, err := g.listenTCP(ctx, s, addr, ":http")
+			if err != nil {
+				return err
+			}
+			return srv.Serve(l)
 		}, donothing, nil
 	})
+	return keyedOption{Option: option, key: listenerKey("http", "tcp", addr, ":http")}
 }
 
 // WithTLS configure a http.Server to listen on the given address and serve HTTPS requests.
+// The certificate files are loaded right away, so that invalid files fail New, and can be
+// reloaded while serving with ReloadCertificates.
 func WithTLS(addr string, certFile string, keyFile string) Option {
-	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
-		return func() error {
-			srv := g.appendHTTPServer()
+	certificate := &certificateFiles{certFile: certFile, keyFile: keyFile}
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := validateAddr(addr); err != nil {
+			return nil, donothing, err
+		}
+		if err := certificate.load(); err != nil {
+			return nil, donothing, fmt.Errorf("tls certificate: %w", err)
+		}
+		if err := g.claimTCP(addr, ":https"); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", addr, ":https")
+		g.checkCertificates(func() []tls.Certificate {
+			return []tls.Certificate{*certificate.cert.Load()}
+		})
+		g.lock.Lock()
+		g.certificates = append(g.certificates, certificate)
+		g.lock.Unlock()
+
+		return func(ctx context.Context, s *managedServer) error {
+			if err := certificate.load(); err != nil {
+				return err
+			}
+
+			srv := g.appendHTTPServer(s)
 			srv.Addr = addr
-			g.lock.Lock()
-			g.servers = append(g.servers, srv)
-			g.lock.Unlock()
+			srv.TLSConfig = &tls.Config{
+				GetCertificate: certificate.getCertificate,
+				MinVersion:     tls.VersionTLS12,
+			}
 
-			return srv.ListenAndServeTLS(certFile, keyFile)
+			l, err := g.listenTCP(ctx, s, addr, ":https")
+			if err != nil {
+				return err
+			}
+			return s.serveTLS(srv, l)
 		}, donothing, nil
 	})
+	return keyedOption{Option: option, key: listenerKey("tls", "tcp", addr, ":https"), files: certificate}
+}
+
+// WithTLSCertificates configure a http.Server to listen on the given address and serve HTTPS
+// requests with the given certificates. The certificate presented to a client is selected
+// through SNI, so a single listener can serve several domains.
+func WithTLSCertificates(addr string, certs ...tls.Certificate) Option {
+	var certificates *certificateSet
+	if len(certs) > 0 {
+		certificates = newCertificateSet(certs)
+	}
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if certificates == nil {
+			return nil, donothing, errors.New("no tls certificates")
+		}
+		if err := validateAddr(addr); err != nil {
+			return nil, donothing, err
+		}
+		if err := g.claimTCP(addr, ":https"); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", addr, ":https")
+		g.checkCertificates(certificates.certificates)
+		return func(ctx context.Context, s *managedServer) error {
+			srv := g.appendHTTPServer(s)
+			srv.Addr = addr
+			srv.TLSConfig = &tls.Config{
+				GetCertificate: certificates.getCertificate,
+				MinVersion:     tls.VersionTLS12,
+			}
+
+			l, err := g.listenTCP(ctx, s, addr, ":https")
+			if err != nil {
+				return err
+			}
+			return s.serveTLS(srv, l)
+		}, donothing, nil
+	})
+	return keyedOption{Option: option, key: listenerKey("tls certificates", "tcp", addr, ":https"), certs: certificates}
 }
 
 // WithServer configure an existing http.Server to serve HTTP or HTTPS requests.
 // This allows for a more complete customization of the http.Server,
-// and srv Handler will be set to the current gin.Engine.
+// and srv Handler will be set to the Graceful instance, serving the current gin.Engine.
 // If srv contains TLSConfig, ListenAndServeTLS will be used;
 // otherwise, ListenAndServe will be used.
+// The server is owned by the Graceful instance, which shuts it down, see WithServerOwnership.
 func WithServer(srv *http.Server) Option {
-	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
-		if srv == nil {
-			return nil, donothing, errors.New("nil http server")
-		}
-		return func() error {
-			g.appendExistHTTPServer(srv)
-			if srv.TLSConfig == nil {
-				return srv.ListenAndServe()
-			} else {
-				return srv.ListenAndServeTLS("", "")
-			}
-		}, donothing, nil
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		return g.serverOption(srv, Owned)
 	})
+	return keyedOption{Option: option, key: serverKey(srv)}
+}
+
+// serverKey returns the key of the listener of srv, empty if srv is nil.
+func serverKey(srv *http.Server) string {
+	if srv == nil {
+		return ""
+	}
+	fallback := ":http"
+	if srv.TLSConfig != nil {
+		fallback = ":https"
+	}
+	return listenerKey(fmt.Sprintf("server %p", srv), "tcp", srv.Addr, fallback)
+}
+
+// serverOption applies srv as configured by WithServer or WithServerOwnership.
+func (g *Graceful) serverOption(srv *http.Server, ownership Ownership) (listenAndServe, cleanup, error) {
+	if srv == nil {
+		return nil, donothing, errors.New("nil http server")
+	}
+	if err := validateAddr(srv.Addr); err != nil {
+		return nil, donothing, err
+	}
+	fallback := ":http"
+	if srv.TLSConfig != nil {
+		fallback = ":https"
+		g.checkCertificates(func() []tls.Certificate { return srv.TLSConfig.Certificates })
+	}
+	if err := g.claimTCP(srv.Addr, fallback); err != nil {
+		return nil, donothing, err
+	}
+	g.checkBind("tcp", srv.Addr, fallback)
+	connState, baseContext, connContext := srv.ConnState, srv.BaseContext, srv.ConnContext
+	return func(ctx context.Context, s *managedServer) error {
+		g.appendExistHTTPServer(s, srv, connState, baseContext, connContext)
+		if ownership == Borrowed {
+			served := make(chan struct{})
+			defer close(served)
+			s.setServer(borrowedServer{served: served})
+		}
+		if srv.TLSConfig == nil {
+			l, err := g.listenTCP(ctx, s, srv.Addr, ":http")
+			if err != nil {
+				return err
+			}
+			return srv.Serve(l)
+		}
+
+		l, err := g.listenTCP(ctx, s, srv.Addr, ":https")
+		if err != nil {
+			return err
+		}
+		return s.serveTLS(srv, l)
+	}, donothing, nil
 }
 
 // WithUnix configure a http.Server to listen on the given unix socket file.
 func WithUnix(file string) Option {
-	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
-		listener, err := net.Listen("unix", file)
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := g.claimUnix(file); err != nil {
+			return nil, donothing, err
+		}
+		listener, err := g.listenConfig.Listen(context.Background(), "unix", file)
 		if err != nil {
 			return nil, donothing, err
 		}
@@ -84,6 +224,7 @@ func WithUnix(file string) Option {
 			listener.Close()
 		})
 	})
+	return keyedOption{Option: option, key: listenerKey("http", "unix", filepath.Clean(file), "")}
 }
 
 // WithFd configure a http.Server to listen on the given file descriptor.
@@ -102,18 +243,218 @@ func WithFd(fd uintptr) Option {
 	})
 }
 
-// WithListener configure a http.Server to listen on the given net.Listener.
+// WithListener configure a http.Server to listen on the given net.Listener. The listener is owned
+// by the Graceful instance, which closes it, see WithListenerOwnership.
 func WithListener(l net.Listener) Option {
 	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
-		return listen(g, l, donothing)
+		return g.listenerOption(l, Owned)
+	})
+}
+
+// WithListenerFactory configure a http.Server to listen on the net.Listener created by factory
+// every time the server starts, so that custom transports, such as TLS wrappers, throttled
+// listeners or test fakes, need not be created before New. The given context is canceled once
+// the run ends, and the listener is closed when the server shuts down.
+func WithListenerFactory(factory func(ctx context.Context) (net.Listener, error)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if factory == nil {
+			return nil, donothing, errors.New("nil listener factory")
+		}
+		return func(ctx context.Context, s *managedServer) error {
+			l, err := factory(ctx)
+			if err != nil {
+				return err
+			}
+
+			srv := g.appendHTTPServer(s)
+			return srv.Serve(g.wrapListener(s, l))
+		}, donothing, nil
+	})
+}
+
+// Labeled labels the servers of the given listener option, e.g. Labeled("admin", WithAddr(":9090")),
+// so that the instances with several listeners tell them apart: the label identifies the servers in
+// the logs, the events and the errors, unless they are named with WithComponent, and is added to
+// their status and metrics.
+func Labeled(label string, opt Option) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if label == "" {
+			return nil, donothing, errors.New("empty listener label")
+		}
+
+		servers, err := g.applyServers(opt)
+		if err != nil {
+			return nil, donothing, err
+		}
+		for _, s := range servers {
+			s.tag = label
+		}
+		return nil, donothing, nil
+	})
+}
+
+// WithListenConfig configure the net.ListenConfig creating the TCP and unix socket listeners,
+// such as the ones of WithAddr and WithUnix, e.g. to set socket options with its Control
+// function. It applies whatever its position among the options.
+func WithListenConfig(lc net.ListenConfig) Option {
+	return listenConfigOption{lc: lc}
+}
+
+// listenConfigOption is the Option returned by WithListenConfig.
+type listenConfigOption struct {
+	lc net.ListenConfig
+}
+
+// applyEarly configures the net.ListenConfig.
+func (o listenConfigOption) applyEarly(g *Graceful) {
+	g.listenConfig = o.lc
+}
+
+// apply configures the net.ListenConfig.
+func (o listenConfigOption) apply(g *Graceful) (listenAndServe, cleanup, error) {
+	o.applyEarly(g)
+	return nil, donothing, nil
+}
+
+// WithTCPKeepAlive configure the keep-alive period applied to every connection accepted on
+// the TCP listeners managed by the Graceful instance. A negative period disables keep-alives.
+func WithTCPKeepAlive(period time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.tcpKeepAlive = period
+		return nil, donothing, nil
+	})
+}
+
+// WithoutTCPKeepAlive disable keep-alives on every connection accepted on the TCP listeners
+// managed by the Graceful instance.
+func WithoutTCPKeepAlive() Option {
+	return WithTCPKeepAlive(-1)
+}
+
+// ErrNoListener is returned when running an instance configured with WithStrict without any
+// listener.
+var ErrNoListener = errors.New("no listener configured")
+
+// WithStrict enable the strict mode: running the Graceful instance without any listener configured
+// fails with ErrNoListener instead of serving on the default ":8080" address, which may be public.
+func WithStrict() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.strict = true
+		return nil, donothing, nil
+	})
+}
+
+// WithCleanupOnShutdown release the resources of a server created by its option, such as the file
+// of WithFd, once the server is fully drained by a shutdown, instead of waiting for Close. A server
+// whose resources were released can not be run again.
+func WithCleanupOnShutdown() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.cleanupOnShutdown = true
+		return nil, donothing, nil
 	})
 }
 
+// WithDisableGeneralOptionsHandler configure every http.Server of the Graceful instance to pass
+// the "OPTIONS *" requests to the handler instead of answering them with an empty 200 response.
+func WithDisableGeneralOptionsHandler() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.ConfigureServers(func(srv *http.Server) { srv.DisableGeneralOptionsHandler = true })
+		return nil, donothing, nil
+	})
+}
+
+// WithoutHTTP2 disable the HTTP/2 support of every http.Server of the Graceful instance, by setting
+// an empty TLSNextProto, so that the TLS listeners only negotiate HTTP/1.1.
+func WithoutHTTP2() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.ConfigureServers(func(srv *http.Server) {
+			srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		})
+		return nil, donothing, nil
+	})
+}
+
+// WithPanicRestart configure the restart policy applied to a server whose serve goroutine
+// panicked. Without it, a panic shuts down every server of the Graceful instance.
+func WithPanicRestart(policy RestartPolicy) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.panicRestart = &policy
+		return nil, donothing, nil
+	})
+}
+
+// WithRestartPolicy configure the restart policy applied to a server that stopped with an
+// error other than http.ErrServerClosed, e.g. a transient file descriptor exhaustion. Without
+// it, or once the restarts are exhausted, every other server is shut down right away and the
+// error is returned by RunWithContext.
+func WithRestartPolicy(policy RestartPolicy) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.restartPolicy = &policy
+		return nil, donothing, nil
+	})
+}
+
+// WithOnServePanic configure a callback invoked with the recovered value whenever a serve
+// goroutine panics.
+func WithOnServePanic(fn func(recovered any)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.onServePanic = fn
+		return nil, donothing, nil
+	})
+}
+
+// WithOnPanic configure a callback invoked with every recovered panic, so that the panics of the
+// handlers and of the serve goroutines feed one reporting pipeline: with the gin.Context of the
+// request when a handler panics, recovered by the Recovery middleware which Default and the admin
+// server install, or with a nil gin.Context when a serve goroutine panics.
+func WithOnPanic(fn func(c *gin.Context, recovered any)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.onPanic = fn
+		return nil, donothing, nil
+	})
+}
+
+// WithOnServeError configure a callback invoked with the server address and the error whenever
+// a server stops serving with an error other than http.ErrServerClosed, including a panic, so
+// that the failure of one listener can be told from that of another. The address is empty if
+// the server listener was never bound.
+func WithOnServeError(fn func(addr string, err error)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.onServeError = fn
+		return nil, donothing, nil
+	})
+}
+
+// listen serves the pre-created listener l. As http.Server.Serve closes its listener when it
+// returns, a listener supporting deadlines is served like a borrowed one, see borrowedListener,
+// so that it stays open for a restart after a failure, and is closed once the server is shut
+// down. Another listener is closed by Serve, and the server can then not be restarted after a
+// failure.
 func listen(g *Graceful, l net.Listener, close cleanup) (listenAndServe, cleanup, error) {
-	return func() error {
-			srv := g.appendHTTPServer()
+	var lost atomic.Bool
+	return func(_ context.Context, s *managedServer) error {
+			if lost.Load() {
+				return errListenerLost
+			}
+			srv := g.appendHTTPServer(s)
 
-			return srv.Serve(l)
+			d, ok := l.(deadliner)
+			if !ok {
+				err := srv.Serve(g.wrapListener(s, l))
+				if !errors.Is(err, http.ErrServerClosed) {
+					lost.Store(true)
+				}
+				return err
+			}
+			err := srv.Serve(g.wrapListener(s, newBorrowedListener(l, d)))
+			switch {
+			case errors.Is(err, http.ErrServerClosed):
+				l.Close()
+			case errors.Is(err, net.ErrClosed):
+				// The listener was closed by the caller.
+				lost.Store(true)
+			}
+			return err
 		}, func() {
 			close()
 		}, nil
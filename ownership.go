@@ -0,0 +1,142 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Ownership tells whether the Graceful instance releases an http.Server or a net.Listener given
+// by the caller, see WithServerOwnership and WithListenerOwnership.
+type Ownership int
+
+const (
+	// Owned objects are released by the Graceful instance: an owned http.Server is shut down when
+	// the instance shuts down, and an owned net.Listener is closed when its server shuts down, or
+	// by Close if it was never served. It is the ownership of WithServer and WithListener.
+	Owned Ownership = iota
+	// Borrowed objects are left to the caller: the shutdown of the instance waits for the caller
+	// to shut a borrowed http.Server down, and a borrowed net.Listener stops being served when its
+	// server shuts down but is never closed, so that it can be served again by the next run.
+	Borrowed
+)
+
+// WithServerOwnership configure an existing http.Server like WithServer, owned by the Graceful
+// instance or borrowed from the caller.
+func WithServerOwnership(srv *http.Server, ownership Ownership) Option {
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		return g.serverOption(srv, ownership)
+	})
+	return keyedOption{Option: option, key: serverKey(srv)}
+}
+
+// WithListenerOwnership configure a http.Server to listen on the given net.Listener like
+// WithListener, owned by the Graceful instance or borrowed from the caller. A borrowed listener
+// must support deadlines, as the *net.TCPListener and *net.UnixListener do, so that its server
+// stops accepting connections without closing it.
+func WithListenerOwnership(l net.Listener, ownership Ownership) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		return g.listenerOption(l, ownership)
+	})
+}
+
+// listenerOption applies l as configured by WithListener or WithListenerOwnership.
+func (g *Graceful) listenerOption(l net.Listener, ownership Ownership) (listenAndServe, cleanup, error) {
+	if l == nil {
+		return nil, donothing, errors.New("nil listener")
+	}
+	if ownership == Owned {
+		return listen(g, l, func() { _ = l.Close() })
+	}
+
+	d, ok := l.(deadliner)
+	if !ok {
+		return nil, donothing, errors.New("borrowed listener does not support deadlines")
+	}
+	return func(_ context.Context, s *managedServer) error {
+		srv := g.appendHTTPServer(s)
+		return srv.Serve(g.wrapListener(s, newBorrowedListener(l, d)))
+	}, donothing, nil
+}
+
+// borrowedListener is a borrowed net.Listener whose Close interrupts Accept with a deadline instead
+// of closing the listener.
+type borrowedListener struct {
+	net.Listener
+	deadliner deadliner
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// newBorrowedListener returns l, served until the returned listener is closed.
+func newBorrowedListener(l net.Listener, d deadliner) *borrowedListener {
+	// The deadline set by the Close of a previous run, if any, is cleared.
+	_ = d.SetDeadline(time.Time{})
+	return &borrowedListener{Listener: l, deadliner: d}
+}
+
+// Accept waits for and returns the next connection, or net.ErrClosed once the listener is closed.
+func (l *borrowedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		if conn != nil {
+			conn.Close()
+		}
+		// The deadline is cleared for the caller, who may accept connections again.
+		_ = l.deadliner.SetDeadline(time.Time{})
+		return nil, net.ErrClosed
+	}
+	return conn, err
+}
+
+// Close interrupts Accept, leaving the listener open.
+func (l *borrowedListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return l.deadliner.SetDeadline(time.Now())
+}
+
+// SetDeadline sets the deadline of the listener, so that PauseAccept interrupts an Accept in
+// progress, unless the listener is closed: the deadline set by Close then stays.
+func (l *borrowedListener) SetDeadline(t time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	return l.deadliner.SetDeadline(t)
+}
+
+// borrowedServer is the stopper of a borrowed http.Server, shut down by the caller.
+type borrowedServer struct {
+	served <-chan struct{}
+}
+
+// Shutdown waits until the caller shut the server down, or ctx is done.
+func (s borrowedServer) Shutdown(ctx context.Context) error {
+	select {
+	case <-s.served:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close leaves the server to the caller.
+func (borrowedServer) Close() error {
+	return nil
+}
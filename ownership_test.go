@@ -0,0 +1,122 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnedListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	router, err := Default(WithListener(listener))
+	assert.NoError(t, err)
+	router.Close()
+
+	_, err = listener.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+
+	_, err = Default(WithListener(nil))
+	assert.EqualError(t, err, "nil listener")
+}
+
+func TestBorrowedListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	url := fmt.Sprintf("http://%s/example", listener.Addr())
+
+	router, err := Default(WithListenerOwnership(listener, Borrowed))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, router.Start())
+		assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+		assert.NoError(t, router.Stop())
+	}
+	router.Close()
+
+	// The listener is still open, and accepts connections for the caller.
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := listener.Accept()
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+
+	_, err = Default(WithListenerOwnership(&fakeListener{}, Borrowed))
+	assert.EqualError(t, err, "borrowed listener does not support deadlines")
+}
+
+func TestBorrowedServer(t *testing.T) {
+	srv := &http.Server{Addr: "127.0.0.1:8136", ReadHeaderTimeout: time.Second}
+	router, err := Default(WithServerOwnership(srv, Borrowed))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, "http://127.0.0.1:8136/example") },
+		time.Second, 5*time.Millisecond)
+
+	shutdown := make(chan error)
+	go func() { shutdown <- router.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before the caller shut the borrowed server down")
+	case <-time.After(50 * time.Millisecond):
+	}
+	conn, err := net.Dial("tcp", "127.0.0.1:8136")
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+
+	assert.NoError(t, srv.Shutdown(context.Background()))
+	assert.NoError(t, <-shutdown)
+}
+
+// fakeListener is a net.Listener without deadlines.
+type fakeListener struct {
+	net.Listener
+}
+
+func TestBorrowedListenerPause(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	url := fmt.Sprintf("http://%s/example", listener.Addr())
+
+	router, err := Default(WithListenerOwnership(listener, Borrowed))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+
+	// The Accept in progress is interrupted, no connection is accepted until resumed.
+	router.PauseAccept()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	router.ResumeAccept()
+	assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+}
@@ -0,0 +1,132 @@
+package graceful
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// PauseAccept stops accepting connections on every listener managed by the Graceful instance,
+// the new connections queuing in the kernel backlog, until ResumeAccept is called. Unlike Drain,
+// readiness is not affected and no connection is dropped, which makes it a fit for brief
+// backpressure. The in-flight requests and the open connections are served as usual.
+func (g *Graceful) PauseAccept() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.acceptResumed != nil {
+		return
+	}
+
+	g.acceptResumed = make(chan struct{})
+	for l := range g.pauseListeners {
+		l.interrupt()
+	}
+}
+
+// ResumeAccept resumes accepting connections after PauseAccept.
+func (g *Graceful) ResumeAccept() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.acceptResumed == nil {
+		return
+	}
+
+	for l := range g.pauseListeners {
+		l.resume()
+	}
+	close(g.acceptResumed)
+	g.acceptResumed = nil
+}
+
+// acceptPaused returns a channel closed once accepting is resumed, or nil if it is not paused.
+func (g *Graceful) acceptPaused() <-chan struct{} {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.acceptResumed
+}
+
+// deadliner is implemented by the listeners whose Accept can be interrupted, such as the TCP and
+// unix listeners.
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// pauseListener stops accepting connections while the Graceful instance is paused. An Accept in
+// progress is interrupted if the listener supports deadlines, otherwise it may accept one more
+// connection.
+type pauseListener struct {
+	net.Listener
+	g *Graceful
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newPauseListener returns a listener pausing l along with g.
+func newPauseListener(g *Graceful, l net.Listener) *pauseListener {
+	pl := &pauseListener{Listener: l, g: g, closed: make(chan struct{})}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.pauseListeners == nil {
+		g.pauseListeners = map[*pauseListener]struct{}{}
+	}
+	g.pauseListeners[pl] = struct{}{}
+	if g.acceptResumed != nil {
+		pl.interrupt()
+	}
+
+	return pl
+}
+
+// Accept waits for the accepting to be resumed, if paused, then accepts the next connection.
+func (l *pauseListener) Accept() (net.Conn, error) {
+	for {
+		if resumed := l.g.acceptPaused(); resumed != nil {
+			select {
+			case <-resumed:
+			case <-l.closed:
+				return nil, net.ErrClosed
+			}
+		}
+
+		conn, err := l.Listener.Accept()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			// The deadline is only set to interrupt the Accept on pause.
+			continue
+		}
+		return conn, err
+	}
+}
+
+// interrupt interrupts an Accept in progress. The caller must hold l.g.lock.
+func (l *pauseListener) interrupt() {
+	if d, ok := l.Listener.(deadliner); ok {
+		_ = d.SetDeadline(time.Now())
+	}
+}
+
+// resume clears the deadline set by interrupt. The caller must hold l.g.lock.
+func (l *pauseListener) resume() {
+	if d, ok := l.Listener.(deadliner); ok {
+		_ = d.SetDeadline(time.Time{})
+	}
+}
+
+// Close closes the listener, interrupting a paused Accept.
+func (l *pauseListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+
+		l.g.lock.Lock()
+		delete(l.g.pauseListeners, l)
+		l.g.lock.Unlock()
+	})
+	return l.Listener.Close()
+}
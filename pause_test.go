@@ -0,0 +1,68 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseAccept(t *testing.T) {
+	router, err := Default(WithAddr(":8101"), WithHealthEndpoints())
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8101/example")
+
+	router.PauseAccept()
+	router.PauseAccept()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8101/example", nil)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	code, _ := probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	pending := make(chan int, 1)
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8101/example", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			pending <- 0
+			return
+		}
+		resp.Body.Close()
+		pending <- resp.StatusCode
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	router.ResumeAccept()
+	router.ResumeAccept()
+	assert.Equal(t, http.StatusOK, <-pending)
+	testRequest(t, "http://localhost:8101/example")
+
+	assert.NoError(t, router.Stop())
+}
+
+func TestPauseAcceptShutdown(t *testing.T) {
+	router, err := Default(WithAddr(":8101"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+
+	router.PauseAccept()
+	defer router.ResumeAccept()
+	assert.NoError(t, router.Stop())
+}
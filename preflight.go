@@ -0,0 +1,132 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// preflightCheck is a check run by Validate.
+type preflightCheck func(ctx context.Context) error
+
+// Validate runs the preflight checks of the configuration without serving: the TLS certificates
+// must be currently valid, the TCP addresses and unix socket paths must be bindable, and the
+// component dependencies must be consistent. Every failed check is reported in the returned
+// error. It must be called before starting the Graceful instance, since the listeners of a
+// running instance are no longer bindable, e.g. as a configuration lint step in CI or at boot.
+func (g *Graceful) Validate(ctx context.Context) error {
+	g.lock.Lock()
+	checks := append([]preflightCheck(nil), g.preflight...)
+	for _, e := range g.reloaded {
+		checks = append(checks, e.preflight...)
+	}
+	g.lock.Unlock()
+
+	var errs []error
+	for _, check := range checks {
+		if err := check(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, _, err := g.checkDependencies(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := g.validateResources(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// addPreflight registers a check run by Validate.
+func (g *Graceful) addPreflight(check preflightCheck) {
+	g.lock.Lock()
+	g.preflight = append(g.preflight, check)
+	g.lock.Unlock()
+}
+
+// checkBind registers a check that a listener can be bound on the given network and address,
+// or fallback if addr is empty.
+func (g *Graceful) checkBind(network, addr, fallback string) {
+	if addr == "" {
+		addr = fallback
+	}
+
+	g.addPreflight(func(ctx context.Context) error {
+		l, err := g.listenConfig.Listen(ctx, network, addr)
+		if err != nil {
+			return bindError(network, addr, err)
+		}
+		return l.Close()
+	})
+}
+
+// checkCertificates registers a check that the certificates returned by certs are currently valid.
+func (g *Graceful) checkCertificates(certs func() []tls.Certificate) {
+	g.lock.Lock()
+	g.certSources = append(g.certSources, certs)
+	g.lock.Unlock()
+	g.addPreflight(func(context.Context) error {
+		var errs []error
+		for _, cert := range certs() {
+			if err := checkCertificate(cert, time.Now()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// checkCertificate checks that the leaf of cert is valid at the given time.
+func checkCertificate(cert tls.Certificate, now time.Time) error {
+	leaf, name, err := parseLeaf(cert)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case now.After(leaf.NotAfter):
+		return fmt.Errorf("tls certificate %q expired on %s", name, leaf.NotAfter.Format(time.RFC3339))
+	case now.Before(leaf.NotBefore):
+		return fmt.Errorf("tls certificate %q is not valid before %s", name, leaf.NotBefore.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// parseLeaf parses the leaf of cert, and returns it with the name it is reported by.
+func parseLeaf(cert tls.Certificate) (*x509.Certificate, string, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, "", errors.New("empty tls certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("tls certificate: %w", err)
+	}
+
+	name := leaf.Subject.CommonName
+	if name == "" && len(leaf.DNSNames) > 0 {
+		name = leaf.DNSNames[0]
+	}
+	return leaf, name, nil
+}
+
+// checkUnixSocket registers a check that a unix socket can be bound on path. The probe socket
+// is removed once closed.
+func (g *Graceful) checkUnixSocket(path string) {
+	g.addPreflight(func(ctx context.Context) error {
+		l, err := g.listenConfig.Listen(ctx, "unix", path)
+		if err != nil {
+			return bindError("unix", path, err)
+		}
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(true)
+		}
+		return l.Close()
+	})
+}
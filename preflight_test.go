@@ -0,0 +1,76 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	router, err := New(nil,
+		WithAddr("localhost:8103"),
+		WithTLS("localhost:8104", "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"),
+		WithControlSocket(filepath.Join(t.TempDir(), "control.sock")),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Validate(context.Background()))
+	assert.NoError(t, router.Validate(context.Background()), "the probes must release their listeners")
+}
+
+func TestValidateFailures(t *testing.T) {
+	busy, err := net.Listen("tcp", "localhost:8103")
+	assert.NoError(t, err)
+	defer busy.Close()
+
+	dir := t.TempDir()
+
+	router, err := New(nil,
+		WithAddr("localhost:8103"),
+		WithControlSocket(filepath.Join(dir, "sub", "control.sock")),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	err = router.Validate(context.Background())
+	assert.ErrorContains(t, err, "bind tcp localhost:8103: listen tcp 127.0.0.1:8103: bind: address already in use")
+	assert.ErrorContains(t, err, "bind unix "+filepath.Join(dir, "sub", "control.sock"))
+}
+
+func TestCheckCertificate(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")
+	assert.NoError(t, err)
+
+	assert.NoError(t, checkCertificate(cert, time.Now()))
+	assert.ErrorContains(t, checkCertificate(cert, time.Now().AddDate(20, 0, 0)), `tls certificate "localhost" expired on`)
+	assert.ErrorContains(t, checkCertificate(cert, time.Now().AddDate(-20, 0, 0)), `tls certificate "localhost" is not valid before`)
+	assert.EqualError(t, checkCertificate(tls.Certificate{}, time.Now()), "empty tls certificate")
+}
+
+func TestValidateDependencies(t *testing.T) {
+	backend := &readyRunner{ready: make(chan struct{}), stopped: make(chan struct{})}
+	router, err := Default(
+		WithComponent("frontend", WithAddr("127.0.0.1:8164"), "backend"),
+		WithRunner("backend", backend),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	// The validation only checks the dependencies, the start gates are left as New resolved them.
+	assert.NoError(t, router.Validate(context.Background()))
+	assert.NoError(t, router.Validate(context.Background()))
+	assert.Len(t, router.servers.load()[0].after, 1)
+
+	assert.NoError(t, router.Start())
+	close(backend.ready)
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+	<-backend.stopped
+}
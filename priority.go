@@ -0,0 +1,199 @@
+package graceful
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DrainPriority is the priority of the in-flight requests on a route while draining.
+type DrainPriority int
+
+const (
+	// DrainPriorityLow cancels the in-flight requests as soon as the drain starts: their context
+	// is canceled and, if nothing was written yet, they are answered like the requests rejected
+	// while draining, with 503 Service Unavailable by default.
+	DrainPriorityLow DrainPriority = iota
+	// DrainPriorityHigh lets the in-flight requests finish. It is the priority of the routes
+	// without a configured priority.
+	DrainPriorityHigh
+)
+
+// priorityRoute is a route pattern with its drain priority.
+type priorityRoute struct {
+	pattern  string
+	priority DrainPriority
+}
+
+// WithDrainPriorities configure the drain priority of routes, by pattern. A pattern ending with
+// "*" matches every path with the preceding prefix, e.g. "/reports/*", and the longest matching
+// pattern applies, the length of a wildcard pattern being that of its prefix: an exact pattern
+// applies before a wildcard one of the same length, then the first in lexical order. Routes
+// without a priority have DrainPriorityHigh.
+func WithDrainPriorities(priorities map[string]DrainPriority) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		for pattern, priority := range priorities {
+			g.priorityRoutes = append(g.priorityRoutes, priorityRoute{pattern: pattern, priority: priority})
+		}
+		sort.Slice(g.priorityRoutes, func(i, j int) bool {
+			a, b := g.priorityRoutes[i].pattern, g.priorityRoutes[j].pattern
+			// A wildcard pattern is as long as its prefix.
+			pa, pb := strings.TrimSuffix(a, "*"), strings.TrimSuffix(b, "*")
+			if len(pa) != len(pb) {
+				return len(pa) > len(pb)
+			}
+			if wa, wb := len(pa) != len(a), len(pb) != len(b); wa != wb {
+				return wb
+			}
+			return a < b
+		})
+		return nil, donothing, nil
+	})
+}
+
+// drainPriority returns the drain priority of the given path.
+func (g *Graceful) drainPriority(path string) DrainPriority {
+	for _, r := range g.priorityRoutes {
+		if matchRoute(r.pattern, path) {
+			return r.priority
+		}
+	}
+	return DrainPriorityHigh
+}
+
+// serveLowPriority serves a low priority request, which is canceled once the drain starts.
+func (g *Graceful) serveLowPriority(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	cw := &cancelableWriter{ResponseWriter: w, cancel: cancel}
+	g.lock.Lock()
+	if g.lowPriority == nil {
+		g.lowPriority = map[*cancelableWriter]struct{}{}
+	}
+	g.lowPriority[cw] = struct{}{}
+	g.lock.Unlock()
+
+	defer func() {
+		g.lock.Lock()
+		delete(g.lowPriority, cw)
+		g.lock.Unlock()
+	}()
+
+	// A drain started while registering the request cancels it right away.
+	if g.draining.Load() {
+		cw.cancelRequest()
+	}
+
+	g.serveEngine(cw, req.WithContext(ctx))
+
+	if cw.canceledBeforeWrite() {
+		g.rejectDraining(w, req)
+	}
+}
+
+// cancelLowPriority cancels every in-flight low priority request.
+func (g *Graceful) cancelLowPriority() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for cw := range g.lowPriority {
+		cw.cancelRequest()
+	}
+}
+
+// cancelableWriter is the http.ResponseWriter of a low priority request. Once the request is
+// canceled, everything the handler writes is discarded.
+type cancelableWriter struct {
+	http.ResponseWriter
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	written  bool
+	canceled bool
+}
+
+// cancelRequest cancels the context of the request and discards its response from now on.
+func (w *cancelableWriter) cancelRequest() {
+	w.mu.Lock()
+	w.canceled = true
+	w.mu.Unlock()
+
+	w.cancel()
+}
+
+// canceledBeforeWrite reports whether the request was canceled before its response was started.
+func (w *cancelableWriter) canceledBeforeWrite() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.canceled && !w.written
+}
+
+// WriteHeader sends the response header, unless the request was canceled.
+func (w *cancelableWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.canceled {
+		return
+	}
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the response body, unless the request was canceled.
+func (w *cancelableWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.canceled {
+		return 0, context.Canceled
+	}
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered response, unless the request was canceled.
+func (w *cancelableWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.canceled {
+		w.written = true
+		f.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, which is then no longer answered on cancel.
+func (w *cancelableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.written = true
+	return h.Hijack()
+}
+
+// CloseNotify implements http.CloseNotifier, which gin.Context.Stream relies on.
+func (w *cancelableWriter) CloseNotify() <-chan bool {
+	//nolint:staticcheck // http.CloseNotifier is deprecated but still used by gin.
+	if cn, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool, 1)
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (w *cancelableWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,105 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDrainPriorities(t *testing.T) {
+	router, err := Default(
+		WithAddr(":8100"),
+		WithDrainPriorities(map[string]DrainPriority{
+			"/reports/*":      DrainPriorityLow,
+			"/reports/urgent": DrainPriorityHigh,
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/reports/:name", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+			c.String(http.StatusOK, "report")
+		}
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+
+	get := func(path string) <-chan int {
+		code := make(chan int, 1)
+		go func() {
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8100"+path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				code <- 0
+				return
+			}
+			resp.Body.Close()
+			code <- resp.StatusCode
+		}()
+		return code
+	}
+
+	low := get("/reports/daily")
+	high := get("/reports/urgent")
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	router.Drain()
+	assert.Equal(t, http.StatusServiceUnavailable, <-low)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, http.StatusOK, <-high)
+
+	assert.NoError(t, router.Stop())
+}
+
+func TestDrainPriority(t *testing.T) {
+	router, err := New(gin.New(), WithDrainPriorities(map[string]DrainPriority{"/a/*": DrainPriorityLow}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.Equal(t, DrainPriorityLow, router.drainPriority("/a/b"))
+	assert.Equal(t, DrainPriorityHigh, router.drainPriority("/b"))
+}
+
+func TestDrainPriorityTies(t *testing.T) {
+	// The map is ranged in a random order, the ties are broken the same way every time.
+	for i := 0; i < 20; i++ {
+		router, err := New(gin.New(), WithDrainPriorities(map[string]DrainPriority{
+			"/ab*": DrainPriorityHigh,
+			"/a/*": DrainPriorityLow,
+			"/ap*": DrainPriorityLow,
+			"/api": DrainPriorityHigh,
+		}))
+		assert.NoError(t, err)
+
+		patterns := make([]string, 0, len(router.priorityRoutes))
+		for _, r := range router.priorityRoutes {
+			patterns = append(patterns, r.pattern)
+		}
+		assert.Equal(t, []string{"/api", "/a/*", "/ab*", "/ap*"}, patterns)
+		assert.Equal(t, DrainPriorityHigh, router.drainPriority("/api"))
+		assert.Equal(t, DrainPriorityLow, router.drainPriority("/apx"))
+		router.Close()
+	}
+}
+
+func TestDrainPriorityExactBeforeWildcard(t *testing.T) {
+	router, err := New(gin.New(), WithDrainPriorities(map[string]DrainPriority{
+		"/api*": DrainPriorityLow,
+		"/api":  DrainPriorityHigh,
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.Equal(t, DrainPriorityHigh, router.drainPriority("/api"))
+	assert.Equal(t, DrainPriorityLow, router.drainPriority("/api/v1"))
+}
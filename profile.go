@@ -0,0 +1,90 @@
+package graceful
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// profileTimeFormat is the time format of the profile file names.
+const profileTimeFormat = "20060102T150405.000"
+
+// shutdownProfile is the configuration of WithShutdownProfile.
+type shutdownProfile struct {
+	dir string
+	cpu time.Duration
+	wg  sync.WaitGroup
+}
+
+// WithShutdownProfile configure a directory receiving the goroutine and heap profiles of the
+// instance once the drain of a running instance starts, then again if the drain times out, so
+// that a slow shutdown leaves artifacts to analyze. If cpu is positive, a CPU profile of that
+// duration is also recorded from the start of the drain, without delaying it. The files are named
+// after the time and the stage of the shutdown, e.g. 20240102T150405.000-drain-heap.pprof.
+func WithShutdownProfile(dir string, cpu time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, donothing, fmt.Errorf("shutdown profile: %w", err)
+		}
+		p := &shutdownProfile{dir: dir, cpu: cpu}
+		g.profile = p
+		return nil, p.wg.Wait, nil
+	})
+}
+
+// captureProfiles writes the profiles of the given shutdown stage, if configured, and starts the
+// CPU profile when asked to.
+func (g *Graceful) captureProfiles(stage string, cpu bool) {
+	p := g.profile
+	if p == nil {
+		return
+	}
+
+	prefix := filepath.Join(p.dir, time.Now().Format(profileTimeFormat)+"-"+stage+"-")
+	for _, name := range []string{"goroutine", "heap"} {
+		if err := writeProfile(prefix+name+".pprof", name); err != nil {
+			g.log().Error("shutdown profile failed", "profile", name, "error", err)
+		}
+	}
+	if cpu && p.cpu > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			if err := writeCPUProfile(prefix+"cpu.pprof", p.cpu); err != nil {
+				g.log().Error("shutdown profile failed", "profile", "cpu", "error", err)
+			}
+		}()
+	}
+	g.debugLog("shutdown profiles written", "stage", stage)
+}
+
+// writeProfile writes the named runtime profile to path.
+func writeProfile(path, name string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = pprof.Lookup(name).WriteTo(f, 0)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeCPUProfile records a CPU profile of duration d to path.
+func writeCPUProfile(path string, d time.Duration) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	time.Sleep(d)
+	pprof.StopCPUProfile()
+	return f.Close()
+}
@@ -0,0 +1,52 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithShutdownProfile(t *testing.T) {
+	dir := t.TempDir()
+	router, err := Default(WithAddr("127.0.0.1:8127"), WithShutdownProfile(dir, 20*time.Millisecond))
+	assert.NoError(t, err)
+	release := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		reachable(t, "http://127.0.0.1:8127/slow")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, router.Shutdown(ctx), ErrDrainTimeout)
+	close(release)
+	<-requestDone
+	router.Close()
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var stages []string
+	for _, e := range entries {
+		_, stage, _ := strings.Cut(e.Name(), "-")
+		stages = append(stages, stage)
+	}
+	assert.ElementsMatch(t, []string{
+		"drain-goroutine.pprof", "drain-heap.pprof", "drain-cpu.pprof",
+		"timeout-goroutine.pprof", "timeout-heap.pprof",
+	}, stages)
+}
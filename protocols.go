@@ -0,0 +1,14 @@
+//go:build go1.24
+
+package graceful
+
+import "net/http"
+
+// WithProtocols configure the protocols served by every http.Server of the Graceful instance, e.g.
+// unencrypted HTTP/2 alongside HTTP/1.1. The servers keep their default protocols if it is nil.
+func WithProtocols(protocols *http.Protocols) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.ConfigureServers(func(srv *http.Server) { srv.Protocols = protocols })
+		return nil, donothing, nil
+	})
+}
@@ -0,0 +1,24 @@
+//go:build go1.24
+
+package graceful
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithProtocols(t *testing.T) {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	router, err := Default(WithProtocols(&protocols))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	srv := &http.Server{}
+	router.configureServer(srv)
+	assert.Same(t, &protocols, srv.Protocols)
+}
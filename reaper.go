@@ -0,0 +1,98 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithIdleConnReaper configure a background reaper closing, every interval, the connections of the
+// http.Servers of the Graceful instance idle for longer than maxIdle, between two requests or
+// before the first one, so that the file descriptors in use stay bounded independently of the
+// IdleTimeout of the servers, only checked when a connection goes idle. The clients retry their
+// idempotent requests on the closed connections.
+func WithIdleConnReaper(maxIdle, interval time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if maxIdle <= 0 {
+			return nil, donothing, errors.New("invalid max idle duration")
+		}
+		if interval <= 0 {
+			return nil, donothing, errors.New("invalid reaper interval")
+		}
+		g.reaper = &idleReaper{maxIdle: maxIdle, interval: interval, idle: make(map[net.Conn]time.Time)}
+		return nil, donothing, nil
+	})
+}
+
+// idleReaper records when the idle connections went idle, and closes the stale ones.
+type idleReaper struct {
+	maxIdle  time.Duration
+	interval time.Duration
+
+	mu   sync.Mutex
+	idle map[net.Conn]time.Time
+}
+
+// trackIdle returns an http.Server ConnState hook recording the idle connections for the reaper,
+// then calling next, if any. It returns next as is if no reaper is configured.
+func (g *Graceful) trackIdle(next func(net.Conn, http.ConnState)) func(net.Conn, http.ConnState) {
+	r := g.reaper
+	if r == nil {
+		return next
+	}
+
+	return func(conn net.Conn, state http.ConnState) {
+		r.mu.Lock()
+		switch state {
+		case http.StateNew, http.StateIdle:
+			r.idle[conn] = time.Now()
+		case http.StateActive, http.StateHijacked, http.StateClosed:
+			delete(r.idle, conn)
+		}
+		r.mu.Unlock()
+
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// watchIdleConns closes the stale idle connections every interval until ctx is done.
+func (g *Graceful) watchIdleConns(ctx context.Context) {
+	r := g.reaper
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if reaped := r.reap(time.Now()); reaped > 0 {
+			g.debugLog("idle connections reaped", "connections", reaped)
+		}
+	}
+}
+
+// reap closes the connections idle since before now minus maxIdle, and returns how many.
+func (r *idleReaper) reap(now time.Time) int {
+	var stale []net.Conn
+	r.mu.Lock()
+	for conn, since := range r.idle {
+		if now.Sub(since) > r.maxIdle {
+			stale = append(stale, conn)
+			delete(r.idle, conn)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, conn := range stale {
+		_ = conn.Close()
+	}
+	return len(stale)
+}
@@ -0,0 +1,53 @@
+package graceful
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIdleConnReaper(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8147"), WithIdleConnReaper(50*time.Millisecond, 10*time.Millisecond))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	// A connection without a request, and a kept alive one, are both reaped.
+	silent, err := net.Dial("tcp", "127.0.0.1:8147")
+	assert.NoError(t, err)
+	defer silent.Close()
+	kept, err := net.Dial("tcp", "127.0.0.1:8147")
+	assert.NoError(t, err)
+	defer kept.Close()
+	_, err = io.WriteString(kept, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	assert.NoError(t, err)
+	reader := bufio.NewReader(kept)
+	resp, err := http.ReadResponse(reader, nil)
+	if assert.NoError(t, err) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	start := time.Now()
+	_ = silent.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = silent.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	_ = kept.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = reader.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	_, err = Default(WithIdleConnReaper(0, time.Second))
+	assert.EqualError(t, err, "invalid max idle duration")
+	_, err = Default(WithIdleConnReaper(time.Second, 0))
+	assert.EqualError(t, err, "invalid reaper interval")
+}
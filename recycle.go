@@ -0,0 +1,103 @@
+package graceful
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRecycled is returned by RunWithContext when the instance gracefully shut down to be
+// recycled, e.g. by WithMaxRequests, so that the process can exit and be restarted by its
+// supervisor. The returned error tells the reason.
+var ErrRecycled = errors.New("recycled")
+
+// WithMaxRequests configure the instance to recycle itself once it served n requests, to
+// contain the leaks of long-lived processes: the run is gracefully shut down and RunWithContext
+// returns ErrRecycled, unless a callback is configured with WithOnRecycle.
+func WithMaxRequests(n int) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if n <= 0 {
+			return nil, donothing, errors.New("invalid max requests")
+		}
+		g.maxRequests = uint64(n)
+		return nil, donothing, nil
+	})
+}
+
+// WithOnRecycle configure fn to be invoked with the reason when the instance must be recycled,
+// instead of gracefully shutting down the run, e.g. to ask an orchestrator for a replacement
+// first. fn is invoked at most once per run.
+func WithOnRecycle(fn func(reason string)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.onRecycle = fn
+		return nil, donothing, nil
+	})
+}
+
+// countRequest counts a request towards the limit configured with WithMaxRequests.
+func (g *Graceful) countRequest() {
+	if g.maxRequests > 0 && g.requests.Add(1) == g.maxRequests {
+		g.recycle("max requests")
+	}
+}
+
+// recycle recycles the instance for the given reason, once per run: the callback configured
+// with WithOnRecycle is invoked, or else the run is gracefully shut down.
+func (g *Graceful) recycle(reason string) {
+	if !g.recycling.CompareAndSwap(false, true) {
+		return
+	}
+	g.log().Info("recycling", "reason", reason)
+
+	if g.onRecycle != nil {
+		go g.onRecycle(reason)
+		return
+	}
+
+	g.lock.Lock()
+	g.recycleReason = reason
+	g.lock.Unlock()
+	g.cancelRun("recycle: " + reason)
+}
+
+// cancelRun cancels the current run, so that its servers are gracefully shut down for the given
+// reason.
+func (g *Graceful) cancelRun(reason string) {
+	g.lock.Lock()
+	g.cancelReason = reason
+	cancel := g.cancel
+	g.lock.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// recycled runs the recycle action, if any, once the servers of the recycled run are drained. It
+// returns ErrRecycled, with the error of the action, if any.
+func (g *Graceful) recycled(reason string) error {
+	err := fmt.Errorf("%w: %s", ErrRecycled, reason)
+	if g.recycleAction == nil {
+		return err
+	}
+
+	if actionErr := g.recycleAction(reason); actionErr != nil {
+		return errors.Join(err, actionErr)
+	}
+	return err
+}
+
+// recycledReason returns the reason the current run was recycled for, if any.
+func (g *Graceful) recycledReason() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.recycleReason
+}
+
+// canceledReason returns the shutdown reason the current run was canceled for with cancelRun,
+// if any.
+func (g *Graceful) canceledReason() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.cancelReason
+}
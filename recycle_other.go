@@ -0,0 +1,10 @@
+//go:build !unix
+
+package graceful
+
+import "errors"
+
+// execSelf is not supported on this platform.
+func execSelf(string) error {
+	return errors.New("exec self: unsupported platform")
+}
@@ -0,0 +1,97 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxRequests(t *testing.T) {
+	var reports []ShutdownReport
+	router, err := Default(
+		WithAddr(":8119"),
+		WithMaxRequests(3),
+		WithShutdownReportFunc(func(r ShutdownReport) { reports = append(reports, r) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	done := make(chan error)
+	go func() { done <- router.RunWithContext(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// Keep-alives are disabled so that no spare connection delays the drain.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8119/example", nil)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, ErrRecycled))
+		assert.EqualError(t, err, "recycled: max requests")
+	case <-time.After(time.Second):
+		t.Fatal("run not recycled")
+	}
+	if assert.NotEmpty(t, reports) {
+		assert.Equal(t, "recycle: max requests", reports[0].Reason)
+	}
+}
+
+func TestWithOnRecycle(t *testing.T) {
+	recycled := make(chan string, 1)
+	router, err := Default(
+		WithAddr(":8119"),
+		WithMaxRequests(1),
+		WithOnRecycle(func(reason string) { recycled <- reason }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, reachable(t, "http://localhost:8119/example"))
+	assert.Equal(t, "max requests", <-recycled)
+	assert.True(t, reachable(t, "http://localhost:8119/example"))
+	assert.NoError(t, router.Stop())
+
+	_, err = Default(WithMaxRequests(0))
+	assert.EqualError(t, err, "invalid max requests")
+}
+
+func TestWithMemoryLimitRestart(t *testing.T) {
+	var actions []string
+	var router *Graceful
+	router, err := Default(
+		WithAddr(":8119"),
+		WithMemoryLimitRestart(1),
+		WithRecycleAction(func(reason string) error {
+			assert.Equal(t, ServerStopped, router.ServerStatus()[0].State)
+			actions = append(actions, reason)
+			return errors.New("exec failed")
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	err = router.RunWithContext(context.Background())
+	assert.True(t, errors.Is(err, ErrRecycled))
+	assert.ErrorContains(t, err, "recycled: memory limit")
+	assert.ErrorContains(t, err, "exec failed")
+	assert.Equal(t, []string{"memory limit"}, actions)
+
+	_, err = Default(WithMemoryLimitRestart(0))
+	assert.EqualError(t, err, "invalid memory limit")
+}
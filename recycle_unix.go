@@ -0,0 +1,13 @@
+//go:build unix
+
+package graceful
+
+import (
+	"os"
+	"syscall"
+)
+
+// execSelf replaces the process with the executable at path.
+func execSelf(path string) error {
+	return syscall.Exec(path, os.Args, os.Environ())
+}
@@ -0,0 +1,157 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// deregisterTimeout bounds the deregistration when the shutdown context is already done.
+const deregisterTimeout = 5 * time.Second
+
+// Instance describes the Graceful instance to a Registry.
+type Instance struct {
+	// Addrs are the addresses the servers of the instance are bound to.
+	Addrs []string
+}
+
+// Registry is a service discovery backend, such as a DNS-based one, the Graceful instance
+// registers with once it is ready, and deregisters from as the first step of a shutdown.
+type Registry interface {
+	// Register registers the instance.
+	Register(ctx context.Context, instance Instance) error
+	// Deregister deregisters the instance.
+	Deregister(ctx context.Context, instance Instance) error
+}
+
+// WithRegistry configure a Registry the Graceful instance registers with once every server is
+// bound and the warmup is complete. A failed registration shuts down every server and the error
+// is returned by RunWithContext. The instance deregisters as the first step of a shutdown,
+// before the drain starts.
+func WithRegistry(r Registry) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if r == nil {
+			return nil, donothing, errors.New("nil registry")
+		}
+		g.registries = append(g.registries, r)
+		return nil, donothing, nil
+	})
+}
+
+// WithDeregistrationWait configure the time waited after deregistering from the registries
+// before the drain starts, e.g. about the TTL of the DNS records, so that the clients with cached
+// records keep being served until they resolve the instance again. The wait ends early if the
+// shutdown context is done.
+func WithDeregistrationWait(d time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.deregistrationWait = d
+		return nil, donothing, nil
+	})
+}
+
+// register waits for every server to be bound and the warmup to complete, then registers the
+// instance with the registries. A failed registration cancels the run.
+func (g *Graceful) register(ctx context.Context, cancel context.CancelFunc, servers []*managedServer) error {
+	if len(g.registries) == 0 {
+		return nil
+	}
+
+	for _, s := range servers {
+		select {
+		case <-s.startedChan():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	g.lock.Lock()
+	warmed := g.warmed
+	g.lock.Unlock()
+	select {
+	case <-warmed:
+	case <-ctx.Done():
+		return nil
+	}
+
+	instance := Instance{}
+	for _, s := range servers {
+		if addr := s.status().Addr; addr != "" {
+			instance.Addrs = append(instance.Addrs, addr)
+		}
+	}
+
+	for _, r := range g.registries {
+		if err := r.Register(ctx, instance); err != nil {
+			g.log().Error("registration failed", "error", err)
+			cancel()
+			return fmt.Errorf("registry: %w", err)
+		}
+
+		g.lock.Lock()
+		g.registered = append(g.registered, registration{registry: r, instance: instance})
+		g.lock.Unlock()
+	}
+
+	return nil
+}
+
+// registration is a registration of the instance with a Registry.
+type registration struct {
+	registry Registry
+	instance Instance
+}
+
+// deregister deregisters the instance from the registries it registered with, then waits for
+// the deregistration wait, skipped if ctx is done. It returns the shutdown phases of the
+// deregistration, if any.
+func (g *Graceful) deregister(ctx context.Context) []ShutdownPhase {
+	g.lock.Lock()
+	registered := g.registered
+	g.registered = nil
+	g.lock.Unlock()
+
+	if len(registered) == 0 {
+		return nil
+	}
+
+	// The instance still deregisters when the shutdown context is already done, e.g. on Stop.
+	deregisterCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		deregisterCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), deregisterTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	phase := ShutdownPhase{Name: "deregister"}
+	var errs []error
+	for _, r := range registered {
+		if err := r.registry.Deregister(deregisterCtx, r.instance); err != nil {
+			g.log().Error("deregistration failed", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		phase.Error = err.Error()
+	}
+	phase.Duration = time.Since(start)
+	phases := []ShutdownPhase{phase}
+
+	if g.deregistrationWait > 0 {
+		start = time.Now()
+		phase = ShutdownPhase{Name: "deregistration wait"}
+
+		timer := time.NewTimer(g.deregistrationWait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			phase.Error = ctx.Err().Error()
+		}
+		phase.Duration = time.Since(start)
+		phases = append(phases, phase)
+	}
+
+	return phases
+}
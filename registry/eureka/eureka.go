@@ -0,0 +1,233 @@
+// Package eureka registers a graceful.Graceful instance with a Eureka server, through its REST
+// API.
+package eureka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/gin-contrib/graceful/registry/internal/regutil"
+)
+
+const (
+	// defaultHeartbeatInterval is the default lease renewal interval of Eureka.
+	defaultHeartbeatInterval = 30 * time.Second
+	// leaseDurationFactor is the lease duration, in heartbeat intervals, after which Eureka
+	// evicts an instance that stopped sending heartbeats.
+	leaseDurationFactor = 3
+)
+
+// Config configures the registration of an instance with Eureka.
+type Config struct {
+	// ServerURL is the base URL of the Eureka REST API, e.g. "http://eureka:8761/eureka".
+	ServerURL string
+	// App is the name of the application the instance belongs to.
+	App string
+	// InstanceID identifies the instance, defaults to "<hostname>:<app>:<port>".
+	InstanceID string
+	// IP is the IP address registered, defaults to the host of the first TCP address of the
+	// instance, or the first IP address of the network interfaces if it is a wildcard address.
+	IP string
+	// HostName is the host name registered, defaults to IP.
+	HostName string
+	// Metadata is the metadata of the instance.
+	Metadata map[string]string
+	// HeartbeatInterval is the interval between two lease renewals. Defaults to 30 seconds.
+	HeartbeatInterval time.Duration
+	// Client is the http.Client sending the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// OnError, if not nil, is called with the errors of the heartbeats.
+	OnError func(error)
+}
+
+// Registry is a graceful.Registry registering the instance with Eureka, its lease renewed with
+// heartbeats until deregistered.
+type Registry struct {
+	cfg          Config
+	registration regutil.Registration
+}
+
+var _ graceful.Registry = (*Registry)(nil)
+
+// WithEureka configure the Graceful instance to register with Eureka once it is ready, and to
+// deregister as the first step of a shutdown, see graceful.WithRegistry.
+func WithEureka(cfg Config) graceful.Option {
+	return graceful.WithRegistry(New(cfg))
+}
+
+// New returns a Registry registering the instance with Eureka.
+func New(cfg Config) *Registry {
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	cfg.ServerURL = strings.TrimSuffix(cfg.ServerURL, "/")
+	cfg.App = strings.ToUpper(cfg.App)
+
+	return &Registry{cfg: cfg}
+}
+
+// Register registers the instance and starts renewing its lease. A lease unknown to Eureka,
+// e.g. after a restart of the server, is registered again.
+func (r *Registry) Register(ctx context.Context, instance graceful.Instance) error {
+	if r.cfg.ServerURL == "" || r.cfg.App == "" {
+		return errors.New("eureka: server url and app are required")
+	}
+	info, err := r.instanceInfo(instance)
+	if err != nil {
+		return err
+	}
+	if err := r.register(ctx, info); err != nil {
+		return err
+	}
+
+	r.registration.Start(regutil.StartHeartbeat(r.cfg.HeartbeatInterval, func(ctx context.Context) error {
+		status, err := r.do(ctx, http.MethodPut, r.instancePath(info), nil)
+		if status == http.StatusNotFound {
+			return r.register(ctx, info)
+		}
+		return err
+	}, r.cfg.OnError))
+	return nil
+}
+
+// Deregister stops renewing the lease and deregisters the instance.
+func (r *Registry) Deregister(ctx context.Context, instance graceful.Instance) error {
+	if !r.registration.Stop() {
+		return nil
+	}
+	info, err := r.instanceInfo(instance)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.do(ctx, http.MethodDelete, r.instancePath(info), nil)
+	return err
+}
+
+// register sends the registration of the instance.
+func (r *Registry) register(ctx context.Context, info *instanceInfo) error {
+	body, err := json.Marshal(map[string]any{"instance": info})
+	if err != nil {
+		return fmt.Errorf("eureka: %w", err)
+	}
+
+	_, err = r.do(ctx, http.MethodPost, "/apps/"+r.cfg.App, body)
+	return err
+}
+
+// instancePath returns the path of the instance in the Eureka REST API.
+func (r *Registry) instancePath(info *instanceInfo) string {
+	return "/apps/" + r.cfg.App + "/" + info.InstanceID
+}
+
+// instanceInfo is the instance description of the Eureka REST API.
+type instanceInfo struct {
+	InstanceID     string            `json:"instanceId"`
+	HostName       string            `json:"hostName"`
+	App            string            `json:"app"`
+	IPAddr         string            `json:"ipAddr"`
+	VIPAddress     string            `json:"vipAddress"`
+	Status         string            `json:"status"`
+	Port           port              `json:"port"`
+	SecurePort     port              `json:"securePort"`
+	DataCenterInfo dataCenterInfo    `json:"dataCenterInfo"`
+	LeaseInfo      leaseInfo         `json:"leaseInfo"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+type port struct {
+	Port    int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+type dataCenterInfo struct {
+	Class string `json:"@class"`
+	Name  string `json:"name"`
+}
+
+type leaseInfo struct {
+	RenewalIntervalInSecs int `json:"renewalIntervalInSecs"`
+	DurationInSecs        int `json:"durationInSecs"`
+}
+
+// instanceInfo returns the description of instance.
+func (r *Registry) instanceInfo(instance graceful.Instance) (*instanceInfo, error) {
+	ip, p, err := regutil.Endpoint(instance, r.cfg.IP)
+	if err != nil {
+		return nil, fmt.Errorf("eureka: %w", err)
+	}
+
+	hostName := r.cfg.HostName
+	if hostName == "" {
+		hostName = ip
+	}
+	id := r.cfg.InstanceID
+	if id == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = ip
+		}
+		id = host + ":" + strings.ToLower(r.cfg.App) + ":" + strconv.Itoa(p)
+	}
+	interval := int(r.cfg.HeartbeatInterval / time.Second)
+	if interval < 1 {
+		interval = 1
+	}
+
+	return &instanceInfo{
+		InstanceID: id,
+		HostName:   hostName,
+		App:        r.cfg.App,
+		IPAddr:     ip,
+		VIPAddress: strings.ToLower(r.cfg.App),
+		Status:     "UP",
+		Port:       port{Port: p, Enabled: "true"},
+		SecurePort: port{Port: 443, Enabled: "false"},
+		DataCenterInfo: dataCenterInfo{
+			Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+			Name:  "MyOwn",
+		},
+		LeaseInfo: leaseInfo{
+			RenewalIntervalInSecs: interval,
+			DurationInSecs:        interval * leaseDurationFactor,
+		},
+		Metadata: r.cfg.Metadata,
+	}, nil
+}
+
+// do sends a request to the Eureka REST API, and returns the response status code.
+func (r *Registry) do(ctx context.Context, method, path string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.cfg.ServerURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("eureka: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("eureka: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("eureka: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, nil
+}
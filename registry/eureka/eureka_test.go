@@ -0,0 +1,108 @@
+package eureka
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEureka records the requests sent to the Eureka REST API. The first heartbeat is answered
+// with 404 Not Found, as after a restart of the server.
+type fakeEureka struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   [][]byte
+	beats    int
+}
+
+func (f *fakeEureka) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req.Method+" "+req.URL.Path)
+	f.bodies = append(f.bodies, body)
+
+	switch req.Method {
+	case http.MethodPost:
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPut:
+		f.beats++
+		if f.beats == 1 {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func (f *fakeEureka) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func TestRegistry(t *testing.T) {
+	eureka := &fakeEureka{}
+	srv := httptest.NewServer(eureka)
+	defer srv.Close()
+
+	r := New(Config{
+		ServerURL:         srv.URL + "/eureka",
+		App:               "orders",
+		InstanceID:        "orders-1",
+		IP:                "10.0.0.1",
+		HeartbeatInterval: 20 * time.Millisecond,
+	})
+	instance := graceful.Instance{Addrs: []string{"[::]:8080"}}
+
+	assert.NoError(t, r.Register(context.Background(), instance))
+	time.Sleep(70 * time.Millisecond)
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+
+	recorded := eureka.recorded()
+	if assert.GreaterOrEqual(t, len(recorded), 5) {
+		assert.Equal(t, []string{
+			"POST /eureka/apps/ORDERS",
+			"PUT /eureka/apps/ORDERS/orders-1",
+			"POST /eureka/apps/ORDERS",
+			"PUT /eureka/apps/ORDERS/orders-1",
+		}, recorded[:4])
+		assert.Equal(t, "DELETE /eureka/apps/ORDERS/orders-1", recorded[len(recorded)-1])
+	}
+
+	var registration struct {
+		Instance instanceInfo `json:"instance"`
+	}
+	assert.NoError(t, json.Unmarshal(eureka.bodies[0], &registration))
+	assert.Equal(t, "orders-1", registration.Instance.InstanceID)
+	assert.Equal(t, "ORDERS", registration.Instance.App)
+	assert.Equal(t, "10.0.0.1", registration.Instance.IPAddr)
+	assert.Equal(t, "10.0.0.1", registration.Instance.HostName)
+	assert.Equal(t, "UP", registration.Instance.Status)
+	assert.Equal(t, 8080, registration.Instance.Port.Port)
+	assert.Equal(t, 1, registration.Instance.LeaseInfo.RenewalIntervalInSecs)
+	assert.Equal(t, 3, registration.Instance.LeaseInfo.DurationInSecs)
+}
+
+func TestRegistryErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	instance := graceful.Instance{Addrs: []string{"127.0.0.1:8080"}}
+	assert.EqualError(t, New(Config{ServerURL: srv.URL}).Register(context.Background(), instance),
+		"eureka: server url and app are required")
+	assert.EqualError(t, New(Config{ServerURL: srv.URL, App: "orders"}).Register(context.Background(), graceful.Instance{}),
+		"eureka: no tcp address to register")
+	assert.EqualError(t, New(Config{ServerURL: srv.URL, App: "orders"}).Register(context.Background(), instance),
+		"eureka: POST /apps/ORDERS: 503 Service Unavailable: unavailable")
+}
@@ -0,0 +1,140 @@
+// Package regutil holds the parts shared by the graceful.Registry implementations of the
+// registry packages.
+package regutil
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+)
+
+// Endpoint returns the IP and port to register for instance: the given IP, or the host of the
+// first TCP address of instance if it is not a wildcard address, or else the first IP address of
+// the network interfaces that is not a loopback address.
+func Endpoint(instance graceful.Instance, ip string) (string, int, error) {
+	for _, addr := range instance.Addrs {
+		host, p, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 {
+			continue
+		}
+
+		if ip == "" {
+			if addr := net.ParseIP(host); addr != nil && !addr.IsUnspecified() {
+				ip = host
+			} else if ip, err = interfaceIP(); err != nil {
+				return "", 0, err
+			}
+		}
+		return ip, port, nil
+	}
+
+	return "", 0, errors.New("no tcp address to register")
+}
+
+// interfaceIP returns the first IPv4 address, or else IPv6 address, of the network interfaces
+// that is not a loopback address.
+func interfaceIP() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+
+	var ipv6 string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+		if ipv6 == "" {
+			ipv6 = ipNet.IP.String()
+		}
+	}
+	if ipv6 != "" {
+		return ipv6, nil
+	}
+
+	return "", errors.New("no ip address to register")
+}
+
+// Heartbeat periodically renews a registration until stopped.
+type Heartbeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartHeartbeat starts calling beat every interval. The errors returned by beat are passed to
+// onError, if not nil.
+func StartHeartbeat(interval time.Duration, beat func(ctx context.Context) error, onError func(error)) *Heartbeat {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Heartbeat{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := beat(ctx); err != nil && ctx.Err() == nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	return h
+}
+
+// Stop stops the heartbeat and waits for a beat in progress to return.
+func (h *Heartbeat) Stop() {
+	h.cancel()
+	<-h.done
+}
+
+// Registration tracks the heartbeat of the current registration of a Registry.
+type Registration struct {
+	mu        sync.Mutex
+	heartbeat *Heartbeat
+}
+
+// Start records the heartbeat of a new registration, stopping the previous one, if any.
+func (r *Registration) Start(h *Heartbeat) {
+	r.mu.Lock()
+	previous := r.heartbeat
+	r.heartbeat = h
+	r.mu.Unlock()
+
+	if previous != nil {
+		previous.Stop()
+	}
+}
+
+// Stop stops the heartbeat of the current registration. It reports whether there was one.
+func (r *Registration) Stop() bool {
+	r.mu.Lock()
+	h := r.heartbeat
+	r.heartbeat = nil
+	r.mu.Unlock()
+
+	if h == nil {
+		return false
+	}
+	h.Stop()
+	return true
+}
@@ -0,0 +1,183 @@
+// Package nacos registers a graceful.Graceful instance with a Nacos naming service, through its
+// HTTP API.
+package nacos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/gin-contrib/graceful/registry/internal/regutil"
+)
+
+// defaultHeartbeatInterval is the heartbeat interval of the Nacos ephemeral instances.
+const defaultHeartbeatInterval = 5 * time.Second
+
+// Config configures the registration of an instance with Nacos.
+type Config struct {
+	// ServerAddr is the base URL of the Nacos server, e.g. "http://nacos:8848".
+	ServerAddr string
+	// ServiceName is the name of the service the instance belongs to.
+	ServiceName string
+	// IP is the IP address registered, defaults to the host of the first TCP address of the
+	// instance, or the first IP address of the network interfaces if it is a wildcard address.
+	IP string
+	// Namespace is the namespace ID of the service, the public namespace if empty.
+	Namespace string
+	// Group is the group of the service, the default group if empty.
+	Group string
+	// Cluster is the cluster of the instance, the default cluster if empty.
+	Cluster string
+	// Metadata is the metadata of the instance.
+	Metadata map[string]string
+	// HeartbeatInterval is the interval between two heartbeats. Defaults to five seconds.
+	HeartbeatInterval time.Duration
+	// Client is the http.Client sending the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// OnError, if not nil, is called with the errors of the heartbeats.
+	OnError func(error)
+}
+
+// Registry is a graceful.Registry registering the instance as an ephemeral Nacos instance,
+// kept alive with heartbeats until deregistered.
+type Registry struct {
+	cfg          Config
+	registration regutil.Registration
+}
+
+var _ graceful.Registry = (*Registry)(nil)
+
+// WithNacos configure the Graceful instance to register with Nacos once it is ready, and to
+// deregister as the first step of a shutdown, see graceful.WithRegistry.
+func WithNacos(cfg Config) graceful.Option {
+	return graceful.WithRegistry(New(cfg))
+}
+
+// New returns a Registry registering the instance with Nacos.
+func New(cfg Config) *Registry {
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	cfg.ServerAddr = strings.TrimSuffix(cfg.ServerAddr, "/")
+
+	return &Registry{cfg: cfg}
+}
+
+// Register registers the instance and starts its heartbeats.
+func (r *Registry) Register(ctx context.Context, instance graceful.Instance) error {
+	if r.cfg.ServerAddr == "" || r.cfg.ServiceName == "" {
+		return errors.New("nacos: server address and service name are required")
+	}
+	ip, port, err := regutil.Endpoint(instance, r.cfg.IP)
+	if err != nil {
+		return fmt.Errorf("nacos: %w", err)
+	}
+
+	params := r.params(ip, port)
+	params.Set("healthy", "true")
+	params.Set("weight", "1")
+	if len(r.cfg.Metadata) > 0 {
+		metadata, err := json.Marshal(r.cfg.Metadata)
+		if err != nil {
+			return fmt.Errorf("nacos: %w", err)
+		}
+		params.Set("metadata", string(metadata))
+	}
+	if err := r.do(ctx, http.MethodPost, "/nacos/v1/ns/instance", params); err != nil {
+		return err
+	}
+
+	r.registration.Start(regutil.StartHeartbeat(r.cfg.HeartbeatInterval, func(ctx context.Context) error {
+		return r.beat(ctx, ip, port)
+	}, r.cfg.OnError))
+	return nil
+}
+
+// Deregister stops the heartbeats and deregisters the instance.
+func (r *Registry) Deregister(ctx context.Context, instance graceful.Instance) error {
+	if !r.registration.Stop() {
+		return nil
+	}
+	ip, port, err := regutil.Endpoint(instance, r.cfg.IP)
+	if err != nil {
+		return fmt.Errorf("nacos: %w", err)
+	}
+
+	return r.do(ctx, http.MethodDelete, "/nacos/v1/ns/instance", r.params(ip, port))
+}
+
+// beat sends one heartbeat of the instance.
+func (r *Registry) beat(ctx context.Context, ip string, port int) error {
+	beat, err := json.Marshal(map[string]any{
+		"serviceName": r.serviceName(),
+		"ip":          ip,
+		"port":        port,
+		"cluster":     r.cfg.Cluster,
+		"metadata":    r.cfg.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("nacos: %w", err)
+	}
+
+	params := r.params(ip, port)
+	params.Set("beat", string(beat))
+	return r.do(ctx, http.MethodPut, "/nacos/v1/ns/instance/beat", params)
+}
+
+// serviceName returns the service name qualified with its group, as expected by Nacos.
+func (r *Registry) serviceName() string {
+	if r.cfg.Group == "" {
+		return r.cfg.ServiceName
+	}
+	return r.cfg.Group + "@@" + r.cfg.ServiceName
+}
+
+// params returns the parameters identifying the instance.
+func (r *Registry) params(ip string, port int) url.Values {
+	params := url.Values{}
+	params.Set("serviceName", r.serviceName())
+	params.Set("ip", ip)
+	params.Set("port", strconv.Itoa(port))
+	params.Set("ephemeral", "true")
+	if r.cfg.Namespace != "" {
+		params.Set("namespaceId", r.cfg.Namespace)
+	}
+	if r.cfg.Group != "" {
+		params.Set("groupName", r.cfg.Group)
+	}
+	if r.cfg.Cluster != "" {
+		params.Set("clusterName", r.cfg.Cluster)
+	}
+	return params
+}
+
+// do sends a request to the Nacos API with the given parameters.
+func (r *Registry) do(ctx context.Context, method, path string, params url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, method, r.cfg.ServerAddr+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("nacos: %w", err)
+	}
+
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("nacos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("nacos: %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
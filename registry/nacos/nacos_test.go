@@ -0,0 +1,113 @@
+package nacos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNacos records the requests sent to the Nacos API.
+type fakeNacos struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (f *fakeNacos) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	_, _ = w.Write([]byte("ok"))
+}
+
+// recorded returns the method and path of the recorded requests.
+func (f *fakeNacos) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var recorded []string
+	for _, req := range f.requests {
+		recorded = append(recorded, req.Method+" "+req.URL.Path)
+	}
+	return recorded
+}
+
+func TestRegistry(t *testing.T) {
+	nacos := &fakeNacos{}
+	srv := httptest.NewServer(nacos)
+	defer srv.Close()
+
+	r := New(Config{
+		ServerAddr:        srv.URL + "/",
+		ServiceName:       "orders",
+		IP:                "10.0.0.1",
+		Group:             "shop",
+		Metadata:          map[string]string{"version": "1"},
+		HeartbeatInterval: 10 * time.Millisecond,
+	})
+	instance := graceful.Instance{Addrs: []string{"[::]:8080"}}
+
+	assert.NoError(t, r.Register(context.Background(), instance))
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+	assert.NoError(t, r.Deregister(context.Background(), instance))
+
+	recorded := nacos.recorded()
+	if assert.GreaterOrEqual(t, len(recorded), 3) {
+		assert.Equal(t, "POST /nacos/v1/ns/instance", recorded[0])
+		assert.Equal(t, "PUT /nacos/v1/ns/instance/beat", recorded[1])
+		assert.Equal(t, "DELETE /nacos/v1/ns/instance", recorded[len(recorded)-1])
+	}
+
+	register := nacos.requests[0].URL.Query()
+	assert.Equal(t, "shop@@orders", register.Get("serviceName"))
+	assert.Equal(t, "shop", register.Get("groupName"))
+	assert.Equal(t, "10.0.0.1", register.Get("ip"))
+	assert.Equal(t, "8080", register.Get("port"))
+	assert.Equal(t, "true", register.Get("ephemeral"))
+	assert.JSONEq(t, `{"version":"1"}`, register.Get("metadata"))
+	assert.JSONEq(t, `{"serviceName":"shop@@orders","ip":"10.0.0.1","port":8080,"cluster":"","metadata":{"version":"1"}}`,
+		nacos.requests[1].URL.Query().Get("beat"))
+}
+
+func TestRegistryErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "caused: service not found", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	instance := graceful.Instance{Addrs: []string{"127.0.0.1:8080"}}
+	assert.EqualError(t, New(Config{ServerAddr: srv.URL}).Register(context.Background(), instance),
+		"nacos: server address and service name are required")
+	assert.EqualError(t, New(Config{ServerAddr: srv.URL, ServiceName: "orders"}).Register(context.Background(), graceful.Instance{}),
+		"nacos: no tcp address to register")
+	assert.EqualError(t, New(Config{ServerAddr: srv.URL, ServiceName: "orders"}).Register(context.Background(), instance),
+		"nacos: POST /nacos/v1/ns/instance: 500 Internal Server Error: caused: service not found")
+}
+
+func TestWithNacos(t *testing.T) {
+	nacos := &fakeNacos{}
+	srv := httptest.NewServer(nacos)
+	defer srv.Close()
+
+	router, err := graceful.Default(
+		graceful.WithAddr("127.0.0.1:8116"),
+		WithNacos(Config{ServerAddr: srv.URL, ServiceName: "orders"}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	assert.Equal(t, []string{"POST /nacos/v1/ns/instance", "DELETE /nacos/v1/ns/instance"}, nacos.recorded())
+	assert.Equal(t, "127.0.0.1", nacos.requests[0].URL.Query().Get("ip"))
+	assert.Equal(t, "8116", nacos.requests[0].URL.Query().Get("port"))
+}
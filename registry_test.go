@@ -0,0 +1,110 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRegistry records the registrations of an instance.
+type fakeRegistry struct {
+	mu         sync.Mutex
+	events     []string
+	instance   Instance
+	registered chan struct{}
+	err        error
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{registered: make(chan struct{})}
+}
+
+func (r *fakeRegistry) Register(_ context.Context, instance Instance) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "register")
+	r.instance = instance
+	close(r.registered)
+	return nil
+}
+
+func (r *fakeRegistry) Deregister(context.Context, Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "deregister")
+	return nil
+}
+
+func (r *fakeRegistry) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestWithRegistry(t *testing.T) {
+	registry := newFakeRegistry()
+	var report ShutdownReport
+	router, err := Default(
+		WithAddr(":8114"),
+		WithRegistry(registry),
+		WithDeregistrationWait(100*time.Millisecond),
+		WithShutdownReportFunc(func(r ShutdownReport) { report = r }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	go func() {
+		assert.NoError(t, router.RunWithContext(context.Background()))
+	}()
+
+	select {
+	case <-registry.registered:
+	case <-time.After(time.Second):
+		t.Fatal("instance not registered")
+	}
+	if assert.Len(t, registry.instance.Addrs, 1) {
+		assert.Contains(t, registry.instance.Addrs[0], ":8114")
+	}
+
+	start := time.Now()
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- router.Shutdown(context.Background())
+	}()
+
+	// The instance keeps serving while the clients stop resolving it.
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, []string{"register", "deregister"}, registry.recorded())
+	assert.False(t, router.Draining())
+	testRequest(t, "http://localhost:8114/example")
+
+	assert.NoError(t, <-shutdown)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	if assert.GreaterOrEqual(t, len(report.Phases), 2) {
+		assert.Equal(t, "deregister", report.Phases[0].Name)
+		assert.Equal(t, "deregistration wait", report.Phases[1].Name)
+	}
+}
+
+func TestWithRegistryError(t *testing.T) {
+	registry := newFakeRegistry()
+	registry.err = errors.New("unreachable")
+	router, err := Default(WithAddr(":8114"), WithRegistry(registry))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "registry: unreachable")
+	assert.Empty(t, registry.recorded())
+}
@@ -0,0 +1,279 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// keyedOption is an option identified by the kind and address of the server it configures, so
+// that WithReload can tell the servers kept, added and removed by a new configuration.
+type keyedOption struct {
+	Option
+	key string
+
+	// files and certs are the TLS material of the option, if any, swapped in place when the
+	// option is kept.
+	files *certificateFiles
+	certs *certificateSet
+	// managed options are added to the ServerManager, and are not reconciled by Reload.
+	managed bool
+}
+
+// listenerKey returns the key of an option serving kind requests on the given network and
+// address, or fallback if addr is empty.
+func listenerKey(kind, network, addr, fallback string) string {
+	if addr == "" {
+		addr = fallback
+	}
+	return kind + " " + network + " " + addr
+}
+
+// swap replaces the TLS material of o, a kept option, with the one of next.
+func (o keyedOption) swap(next keyedOption) error {
+	switch {
+	case o.files != nil && next.files != nil:
+		if err := o.files.replace(next.files.certFile, next.files.keyFile); err != nil {
+			return fmt.Errorf("tls certificate: %w", err)
+		}
+	case o.certs != nil && next.certs == nil:
+		return errors.New("no tls certificates")
+	case o.certs != nil:
+		o.certs.store(next.certs.certificates())
+	}
+	return nil
+}
+
+// reloadEntry is a server configured by the options returned by the WithReload function, or
+// added to the ServerManager.
+type reloadEntry struct {
+	option    keyedOption
+	server    *managedServer
+	cleanup   cleanup
+	listeners []configuredListener
+	preflight []preflightCheck
+	// certSources are the TLS certificates of the server, see WithResourceChecks.
+	certSources []func() []tls.Certificate
+}
+
+// WithReload configure fn to return the servers of the Graceful instance, which are reconciled
+// with the running ones, without a restart, on SIGHUP or on a call to Reload. fn is called once
+// by New for the initial servers. Only the WithAddr, WithTLS, WithTLSCertificates, WithServer
+// and WithUnix options are supported: the servers no longer returned are drained, the new ones
+// are started, and the kept ones have their TLS certificates swapped. The other options of New
+// are not reloaded.
+func WithReload(fn func(ctx context.Context) ([]Option, error)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if fn == nil {
+			return nil, donothing, errors.New("nil reload function")
+		}
+		if g.reloadFn != nil {
+			return nil, donothing, errors.New("reload function already configured")
+		}
+		g.reloadFn = fn
+		return nil, donothing, g.Reload(context.Background())
+	})
+}
+
+// Reload calls the function configured with WithReload and reconciles the servers it returns
+// with the current ones. The removed servers are drained first, until ctx is done, so that their
+// addresses can be reused by the added servers. If the Graceful instance is running, the added
+// servers are started and Reload waits for their listeners to be bound; a server that fails to
+// start is not kept, so that the next reload retries it. Every failure is reported in the
+// returned error, and does not prevent the rest of the configuration from being applied.
+func (g *Graceful) Reload(ctx context.Context) error {
+	g.reloadLock.Lock()
+	defer g.reloadLock.Unlock()
+
+	if g.reloadFn == nil {
+		return errors.New("no reload function")
+	}
+	opts, err := g.reloadFn(ctx)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+
+	next := make([]keyedOption, 0, len(opts))
+	keys := make(map[string]bool, len(opts))
+	for _, o := range opts {
+		k, ok := o.(keyedOption)
+		if !ok || k.key == "" {
+			return fmt.Errorf("reload: unsupported option %s", optionName(o))
+		}
+		if keys[k.key] {
+			return fmt.Errorf("reload: duplicate %s", k.key)
+		}
+		keys[k.key] = true
+		next = append(next, k)
+	}
+	g.debugLog("reload invoked", "options", len(next))
+
+	g.lock.Lock()
+	entries := append([]*reloadEntry(nil), g.reloaded...)
+	g.lock.Unlock()
+
+	var errs []error
+	current := make(map[string]*reloadEntry, len(entries))
+	for _, e := range entries {
+		if e.option.managed {
+			continue
+		}
+		if !keys[e.option.key] {
+			if err := g.removeEntry(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		current[e.option.key] = e
+	}
+
+	for _, o := range next {
+		if e, ok := current[o.key]; ok {
+			if err := e.option.swap(o); err != nil {
+				errs = append(errs, fmt.Errorf("reload %s: %w", o.key, err))
+			}
+			continue
+		}
+		if err := g.addEntry(ctx, o); err != nil {
+			errs = append(errs, fmt.Errorf("reload %s: %w", o.key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// addEntry applies o and records its server. If the Graceful instance is running, the server is
+// started and addEntry waits for its listener to be bound.
+func (g *Graceful) addEntry(ctx context.Context, o keyedOption) error {
+	// The option records its listeners and checks under g.lock, the reload lock keeping the
+	// other options out until they are moved to the entry.
+	g.lock.Lock()
+	listeners, preflight, certSources := len(g.listeners), len(g.preflight), len(g.certSources)
+	g.lock.Unlock()
+	run, cleanup, err := o.apply(g)
+
+	g.lock.Lock()
+	if err != nil {
+		g.listeners = g.listeners[:listeners]
+		g.preflight = g.preflight[:preflight]
+		g.certSources = g.certSources[:certSources]
+		g.lock.Unlock()
+		return err
+	}
+	e := &reloadEntry{
+		option:      o,
+		cleanup:     cleanup,
+		listeners:   append([]configuredListener(nil), g.listeners[listeners:]...),
+		preflight:   append([]preflightCheck(nil), g.preflight[preflight:]...),
+		certSources: append([]func() []tls.Certificate(nil), g.certSources[certSources:]...),
+	}
+	g.preflight = g.preflight[:preflight]
+	g.certSources = g.certSources[:certSources]
+	g.lock.Unlock()
+	if run != nil {
+		e.server = &managedServer{run: run, release: sync.OnceFunc(cleanup)}
+		e.cleanup = e.server.release
+	}
+
+	g.lock.Lock()
+	g.reloaded = append(g.reloaded, e)
+	if e.server == nil {
+		g.lock.Unlock()
+		return nil
+	}
+	g.servers.add(e.server)
+	runCtx := g.runCtx
+	running := runCtx != nil && runCtx.Err() == nil
+	if running {
+		e.server.reset()
+		g.reloadServes.Add(1)
+		go func() {
+			defer g.reloadServes.Done()
+			// A reloaded server failing does not cancel the run, its error is reported instead.
+			if err := g.serve(runCtx, func() {}, e.server); err != nil {
+				g.notifyError(err)
+			}
+		}()
+	}
+	g.lock.Unlock()
+	g.debugLog("reload server added", "key", o.key)
+
+	if !running {
+		return nil
+	}
+	select {
+	case <-e.server.startedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if status := e.server.status(); status.State == ServerFailed {
+		_ = g.removeEntry(ctx, e)
+		return status.Err
+	}
+	return nil
+}
+
+// removeEntry drains the server of e, if running, and releases its listeners.
+func (g *Graceful) removeEntry(ctx context.Context, e *reloadEntry) error {
+	var err error
+	if e.server != nil {
+		if _, err = e.server.shutdown(ctx); err != nil {
+			err = fmt.Errorf("reload %s: %w", e.option.key, err)
+		}
+	}
+	e.cleanup()
+
+	g.lock.Lock()
+	g.reloaded = removeItem(g.reloaded, e)
+	if e.server != nil {
+		g.servers.remove(e.server)
+	}
+	if e.option.files != nil {
+		g.certificates = removeItem(g.certificates, e.option.files)
+	}
+	for _, l := range e.listeners {
+		g.listeners = removeItem(g.listeners, l)
+	}
+	g.lock.Unlock()
+	g.debugLog("reload server removed", "key", e.option.key)
+
+	return err
+}
+
+// removeItem returns items without the first occurrence of item.
+func removeItem[T comparable](items []T, item T) []T {
+	for i, v := range items {
+		if v == item {
+			return append(items[:i:i], items[i+1:]...)
+		}
+	}
+	return items
+}
+
+// watchReload calls Reload on every SIGHUP until ctx is done or the shutdown starts.
+func (g *Graceful) watchReload(ctx context.Context, drainStarted <-chan struct{}) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-drainStarted:
+			return
+		case <-hup:
+			if err := g.Reload(ctx); err != nil {
+				g.log().Error("reload failed", "error", err)
+				g.notifyError(err)
+				continue
+			}
+			g.log().Info("reload complete")
+		}
+	}
+}
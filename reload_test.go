@@ -0,0 +1,102 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+	var lock sync.Mutex
+	addrs := []string{":8117"}
+	reload := func(context.Context) ([]Option, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		opts := make([]Option, 0, len(addrs))
+		for _, addr := range addrs {
+			opts = append(opts, WithAddr(addr))
+		}
+		return opts, nil
+	}
+
+	router, err := Default(WithReload(reload))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	defer router.Stop() //nolint:errcheck
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, reachable(t, "http://localhost:8117/example"))
+
+	lock.Lock()
+	addrs = []string{":8118"}
+	lock.Unlock()
+	assert.NoError(t, router.Reload(context.Background()))
+	assert.True(t, reachable(t, "http://localhost:8118/example"))
+	assert.False(t, reachable(t, "http://localhost:8117/example"))
+	assert.Len(t, router.ServerStatus(), 1)
+
+	lock.Lock()
+	addrs = []string{":8117", ":8118"}
+	lock.Unlock()
+	assert.NoError(t, router.Reload(context.Background()))
+	assert.True(t, reachable(t, "http://localhost:8117/example"))
+	assert.True(t, reachable(t, "http://localhost:8118/example"))
+}
+
+func TestReloadCertificateFiles(t *testing.T) {
+	certFile, keyFile := "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"
+	router, err := Default(WithReload(func(context.Context) ([]Option, error) {
+		return []Option{WithTLS(":8117", certFile, keyFile)}, nil
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.Equal(t, []string{"localhost"}, servedNames(t, router))
+
+	certFile, keyFile = "./testdata/certificate/example-cert.pem", "./testdata/certificate/example-key.pem"
+	assert.NoError(t, router.Reload(context.Background()))
+	assert.Equal(t, []string{"example.com"}, servedNames(t, router))
+	assert.Len(t, router.certificates, 1)
+
+	keyFile = filepath.Join(t.TempDir(), "missing.pem")
+	assert.Error(t, router.Reload(context.Background()))
+	assert.Equal(t, []string{"example.com"}, servedNames(t, router))
+}
+
+func TestReloadUnsupportedOption(t *testing.T) {
+	_, err := Default(WithReload(func(context.Context) ([]Option, error) {
+		return []Option{WithAddr(":8117"), WithListener(nil)}, nil
+	}))
+	assert.ErrorContains(t, err, "unsupported option WithListener")
+
+	_, err = Default(WithReload(func(context.Context) ([]Option, error) {
+		return []Option{WithAddr(":8117"), WithAddr(":8117")}, nil
+	}))
+	assert.ErrorContains(t, err, "duplicate http tcp :8117")
+
+	router, err := Default()
+	assert.NoError(t, err)
+	assert.EqualError(t, router.Reload(context.Background()), "no reload function")
+}
+
+// reachable reports whether a GET request to url succeeds.
+func reachable(t *testing.T, url string) bool {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
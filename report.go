@@ -0,0 +1,117 @@
+package graceful
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ShutdownReport is the structured record of one completed shutdown.
+type ShutdownReport struct {
+	// Reason is what triggered the shutdown, e.g. "shutdown" for a call to Shutdown, "context done"
+	// when the RunWithContext context is done, "admin" or "control".
+	Reason string `json:"reason"`
+	// Cause is the cause the shutdown was triggered with, if any, see StopWithCause.
+	Cause string `json:"cause,omitempty"`
+	// StartedAt is when the shutdown started.
+	StartedAt time.Time `json:"started_at"`
+	// Duration is how long the whole shutdown took.
+	Duration time.Duration `json:"duration"`
+	// Phases are the steps of the shutdown, in order.
+	Phases []ShutdownPhase `json:"phases"`
+	// Error is the error the shutdown returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes r with Duration in its string form, e.g. "1.5s".
+func (r ShutdownReport) MarshalJSON() ([]byte, error) {
+	type plain ShutdownReport
+	return json.Marshal(struct {
+		plain
+		Duration duration `json:"duration"`
+	}{plain: plain(r), Duration: duration(r.Duration)})
+}
+
+// UnmarshalJSON decodes r with Duration in its string form, see time.ParseDuration.
+func (r *ShutdownReport) UnmarshalJSON(data []byte) error {
+	type plain ShutdownReport
+	v := struct {
+		*plain
+		Duration duration `json:"duration"`
+	}{plain: (*plain)(r), Duration: duration(r.Duration)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.Duration = time.Duration(v.Duration)
+	return nil
+}
+
+// ShutdownPhase is one step of a shutdown, such as the drain of one server.
+type ShutdownPhase struct {
+	// Name identifies the phase, e.g. "drain :8080".
+	Name string `json:"name"`
+	// Duration is how long the phase took.
+	Duration time.Duration `json:"duration"`
+	// ActiveConnections is the number of connections with a request still in flight when the phase
+	// ended, e.g. when the drain timed out.
+	ActiveConnections int `json:"active_connections"`
+	// Error is the error the phase ended with, if any.
+	Error string `json:"error,omitempty"`
+	// Attempt numbers the attempts of a hook configured with WithHookRetry, from 1.
+	Attempt int `json:"attempt,omitempty"`
+	// Pool are the statistics of the connection pool a "close database" phase closed, see
+	// WithDatabase.
+	Pool *PoolStats `json:"pool,omitempty"`
+}
+
+// MarshalJSON encodes p with Duration in its string form, e.g. "1.5s".
+func (p ShutdownPhase) MarshalJSON() ([]byte, error) {
+	type plain ShutdownPhase
+	return json.Marshal(struct {
+		plain
+		Duration duration `json:"duration"`
+	}{plain: plain(p), Duration: duration(p.Duration)})
+}
+
+// UnmarshalJSON decodes p with Duration in its string form, see time.ParseDuration.
+func (p *ShutdownPhase) UnmarshalJSON(data []byte) error {
+	type plain ShutdownPhase
+	v := struct {
+		*plain
+		Duration duration `json:"duration"`
+	}{plain: (*plain)(p), Duration: duration(p.Duration)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	p.Duration = time.Duration(v.Duration)
+	return nil
+}
+
+// WithShutdownReport configure a writer receiving a JSON record, on a single line, of every
+// completed shutdown. See ShutdownReport.
+func WithShutdownReport(w io.Writer) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.reporters = append(g.reporters, func(report ShutdownReport) {
+			if err := json.NewEncoder(w).Encode(report); err != nil {
+				g.notifyError(fmt.Errorf("shutdown report: %w", err))
+			}
+		})
+		return nil, donothing, nil
+	})
+}
+
+// WithShutdownReportFunc configure a callback receiving the record of every completed shutdown.
+func WithShutdownReportFunc(fn func(ShutdownReport)) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.reporters = append(g.reporters, fn)
+		return nil, donothing, nil
+	})
+}
+
+// report passes the given shutdown report to every configured reporter.
+func (g *Graceful) report(report ShutdownReport) {
+	for _, fn := range g.reporters {
+		fn(report)
+	}
+}
@@ -0,0 +1,116 @@
+package graceful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithShutdownReport(t *testing.T) {
+	var buf bytes.Buffer
+	router, err := Default(WithAddr(":8094"), WithShutdownReport(&buf))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8094/example")
+	assert.NoError(t, router.Stop())
+
+	var report ShutdownReport
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, "context done", report.Reason)
+	assert.Empty(t, report.Error)
+	if assert.Len(t, report.Phases, 1) {
+		assert.Contains(t, report.Phases[0].Name, ":8094")
+		assert.Empty(t, report.Phases[0].Error)
+		assert.Zero(t, report.Phases[0].ActiveConnections)
+	}
+}
+
+func TestWithShutdownReportTimeout(t *testing.T) {
+	var reports []ShutdownReport
+	router, err := Default(WithAddr(":8094"), WithShutdownReportFunc(func(report ShutdownReport) {
+		reports = append(reports, report)
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	release := make(chan struct{})
+	router.GET("/slow", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = router.RunWithContext(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	requestDone := make(chan struct{})
+	go func() {
+		defer close(requestDone)
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8094/slow", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = router.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, ErrDrainTimeout)
+	var shutdownErr *ShutdownError
+	if assert.ErrorAs(t, err, &shutdownErr) {
+		assert.Equal(t, "[::]:8094", shutdownErr.Server)
+	}
+	close(release)
+	<-requestDone
+	<-done
+
+	if assert.NotEmpty(t, reports) {
+		report := reports[0]
+		assert.Equal(t, "shutdown", report.Reason)
+		assert.Equal(t, `shutdown "[::]:8094": context deadline exceeded`, report.Error)
+		if assert.Len(t, report.Phases, 1) {
+			assert.Equal(t, context.DeadlineExceeded.Error(), report.Phases[0].Error)
+			assert.Equal(t, 1, report.Phases[0].ActiveConnections)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWithShutdownReportWriteError(t *testing.T) {
+	router, err := Default(WithAddr(":8094"), WithShutdownReport(failingWriter{}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	errs := router.Errors()
+	assert.NoError(t, router.Start())
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "shutdown report: disk full")
+	default:
+		t.Fatal("no shutdown report error")
+	}
+}
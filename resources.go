@@ -0,0 +1,129 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// openFilesHeadroom is the number of file descriptors kept for the files of the process other
+// than the connections, such as the listeners, the logs and the outgoing connections.
+const openFilesHeadroom = 64
+
+// ResourceChecks describes the resources checked by WithResourceChecks before binding.
+type ResourceChecks struct {
+	// MaxConnections is the number of concurrent connections the instance is expected to serve.
+	// The soft RLIMIT_NOFILE limit must leave room for them, see openFilesHeadroom. Zero skips
+	// the check, as do the platforms without resource limits.
+	MaxConnections int
+	// WritableDirs are the directories which must be writable, in addition to the temporary
+	// directory and the directories of the unix socket files.
+	WritableDirs []string
+	// CertificateExpiry reports the TLS certificates expiring within this duration, e.g. 30
+	// days. Zero skips the check.
+	CertificateExpiry time.Duration
+	// Strict turns the failed checks into an error returned by RunWithContext and Validate,
+	// instead of warnings logged at the start of every run.
+	Strict bool
+}
+
+// WithResourceChecks configure the resource checks run before binding the listeners: the open
+// files limit, the writable directories and the TLS certificate expiry. Failed checks are logged
+// as warnings, or fail the run in strict mode.
+func WithResourceChecks(checks ResourceChecks) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.resourceChecks = &checks
+		return nil, donothing, nil
+	})
+}
+
+// checkResources runs the resource checks, if configured. The failed checks are logged, and
+// returned in strict mode.
+func (g *Graceful) checkResources() error {
+	if g.resourceChecks == nil {
+		return nil
+	}
+
+	problems := g.resourceProblems(time.Now())
+	if g.resourceChecks.Strict {
+		return errors.Join(problems...)
+	}
+	for _, p := range problems {
+		g.log().Warn("resource check failed", "error", p)
+	}
+	return nil
+}
+
+// resourceProblems returns the failed resource checks at the given time.
+func (g *Graceful) resourceProblems(now time.Time) []error {
+	checks := g.resourceChecks
+	var problems []error
+
+	if checks.MaxConnections > 0 {
+		if limit, ok := openFilesLimit(); ok && limit < uint64(checks.MaxConnections)+openFilesHeadroom {
+			problems = append(problems,
+				fmt.Errorf("open files limit %d is too low for %d connections", limit, checks.MaxConnections))
+		}
+	}
+
+	g.lock.Lock()
+	dirs := append([]string{os.TempDir()}, checks.WritableDirs...)
+	for _, l := range g.listeners {
+		if l.network == "unix" {
+			dirs = append(dirs, filepath.Dir(l.addr))
+		}
+	}
+	sources := append([]func() []tls.Certificate(nil), g.certSources...)
+	for _, e := range g.reloaded {
+		sources = append(sources, e.certSources...)
+	}
+	g.lock.Unlock()
+
+	checked := map[string]bool{}
+	for _, dir := range dirs {
+		if checked[dir] {
+			continue
+		}
+		checked[dir] = true
+		if err := checkWritable(dir); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if checks.CertificateExpiry > 0 {
+		for _, certs := range sources {
+			for _, cert := range certs() {
+				// Invalid certificates are reported by Validate and when serving.
+				leaf, name, err := parseLeaf(cert)
+				if err == nil && now.Add(checks.CertificateExpiry).After(leaf.NotAfter) {
+					problems = append(problems,
+						fmt.Errorf("tls certificate %q expires on %s", name, leaf.NotAfter.Format(time.RFC3339)))
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkWritable checks that a file can be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".graceful-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// validateResources returns the failed resource checks in strict mode, for Validate.
+func (g *Graceful) validateResources(context.Context) error {
+	if g.resourceChecks == nil || !g.resourceChecks.Strict {
+		return nil
+	}
+	return errors.Join(g.resourceProblems(time.Now())...)
+}
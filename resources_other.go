@@ -0,0 +1,8 @@
+//go:build !unix
+
+package graceful
+
+// openFilesLimit reports no limit of open files on this platform.
+func openFilesLimit() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,59 @@
+package graceful
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithResourceChecks(t *testing.T) {
+	logger, hook := logrustest.NewNullLogger()
+	missing := filepath.Join(t.TempDir(), "missing")
+	router, err := Default(
+		WithTLS(":8117", "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem"),
+		WithResourceChecks(ResourceChecks{
+			WritableDirs:      []string{t.TempDir(), missing},
+			CertificateExpiry: 100 * 365 * 24 * time.Hour,
+		}),
+		WithLogger(LogrusLogger(logger)),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.NoError(t, router.Validate(context.Background()))
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	var warnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "resource check failed" {
+			warnings = append(warnings, entry.Data["error"].(error).Error())
+		}
+	}
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[0], "directory "+missing+" is not writable")
+		assert.Contains(t, warnings[1], `tls certificate "localhost" expires on 2036`)
+	}
+}
+
+func TestWithResourceChecksStrict(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no open files limit on windows")
+	}
+
+	router, err := Default(
+		WithAddr(":8117"),
+		WithResourceChecks(ResourceChecks{MaxConnections: 1 << 40, Strict: true}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.ErrorContains(t, router.Validate(context.Background()), "is too low for 1099511627776 connections")
+	assert.ErrorContains(t, router.RunWithContext(context.Background()), "is too low for 1099511627776 connections")
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package graceful
+
+import "syscall"
+
+// openFilesLimit returns the soft limit of open files of the process.
+func openFilesLimit() (uint64, bool) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, false
+	}
+	return uint64(limit.Cur), true //nolint:unconvert // int64 on some platforms
+}
@@ -0,0 +1,166 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	defaultRestartBackoff    = time.Second
+	defaultRestartMaxBackoff = 30 * time.Second
+)
+
+// RestartPolicy describes how a server that stopped unexpectedly is restarted.
+type RestartPolicy struct {
+	// MaxRestarts limits the number of restarts of a server, zero means no limit.
+	MaxRestarts int
+	// Backoff is the delay before the first restart, it doubles after every restart.
+	// Defaults to one second.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between two restarts. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+	// ResetAfter is how long a server must serve before it stops for its restarts to be counted
+	// afresh, so that MaxRestarts limits a crash loop rather than the restarts over the lifetime of
+	// the instance. Defaults to MaxBackoff.
+	ResetAfter time.Duration
+}
+
+// errListenerLost is returned by the restart of a server whose pre-created listener was closed by
+// its previous run and can not be re-created, see listen.
+var errListenerLost = errors.New("listener closed, the server can not be restarted")
+
+// delay returns the backoff to wait before the given restart attempt.
+func (p RestartPolicy) delay(restarts int) time.Duration {
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultRestartBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRestartMaxBackoff
+	}
+
+	for i := 0; i < restarts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	return backoff
+}
+
+// resetAfter returns how long a server must serve for its restarts to be counted afresh.
+func (p RestartPolicy) resetAfter() time.Duration {
+	if p.ResetAfter > 0 {
+		return p.ResetAfter
+	}
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return defaultRestartMaxBackoff
+}
+
+// wait blocks for the backoff of the given restart attempt. It returns false if the server
+// must not be restarted, either because the restart limit is reached or the context is done.
+func (p RestartPolicy) wait(ctx context.Context, restarts int) bool {
+	if p.MaxRestarts > 0 && restarts >= p.MaxRestarts {
+		return false
+	}
+
+	timer := time.NewTimer(p.delay(restarts))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// PanicError is returned when a server panicked while serving.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("server panicked: %v", e.Value)
+}
+
+// serve runs the listenAndServe function of s until it stops. A panic is recovered and,
+// depending on the configured restart policy, the server is either restarted with backoff
+// or the whole run is canceled so that every other server shuts down. A server that fails
+// with an error is restarted according to the restart policy, if any, and otherwise cancels
+// the whole run the same way. The server of the failed run is shut down before the restart.
+func (g *Graceful) serve(ctx context.Context, cancel context.CancelFunc, s *managedServer) error {
+	if !g.waitStart(ctx, s) {
+		s.stopped(nil)
+		return nil
+	}
+
+	for restarts := 0; ; restarts++ {
+		g.debugLog("serve goroutine started", serverAttrs(s, "restarts", restarts)...)
+		start := time.Now()
+		err := g.recoverServe(ctx, s)
+		s.stopped(err)
+		g.debugLog("serve goroutine stopped", serverAttrs(s, "error", err)...)
+		if err == nil || errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		if g.onServeError != nil {
+			g.onServeError(s.status().Addr, err)
+		}
+
+		var panicErr *PanicError
+		policy := g.restartPolicy
+		if errors.As(err, &panicErr) {
+			policy = g.panicRestart
+		}
+		if policy != nil && time.Since(start) >= policy.resetAfter() {
+			// The server was healthy for a while, it is not crash looping.
+			restarts = 0
+		}
+		if errors.Is(err, errListenerLost) || policy == nil || !policy.wait(ctx, restarts) {
+			if panicErr != nil {
+				g.log().Error("server panicked", serverAttrs(s, "panic", panicErr.Value)...)
+			} else {
+				g.log().Error("server failed", serverAttrs(s, "error", err)...)
+			}
+			cancel()
+			return serveError(err)
+		}
+		if panicErr != nil {
+			g.log().Warn("restarting server after panic", serverAttrs(s, "panic", panicErr.Value, "restarts", restarts+1)...)
+		} else {
+			g.log().Warn("restarting server", serverAttrs(s, "error", err, "restarts", restarts+1)...)
+		}
+		g.notifyError(&RestartError{Server: s.label(), Restarts: restarts + 1, Err: err})
+		s.retire(policy.delay(restarts))
+	}
+}
+
+// recoverServe runs the listenAndServe function of s and turns a panic into a PanicError.
+func (g *Graceful) recoverServe(ctx context.Context, s *managedServer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+			if g.onServePanic != nil {
+				g.onServePanic(r)
+			}
+			if g.onPanic != nil {
+				g.onPanic(nil, r)
+			}
+		}
+	}()
+
+	return s.run(ctx, s)
+}
@@ -0,0 +1,399 @@
+package graceful
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// withPanickingAddr returns an Option serving on addr whose first n serve calls panic.
+func withPanickingAddr(addr string, n int32) Option {
+	return withFlakyAddr(addr, n, func() error { panic("boom") })
+}
+
+// withFailingAddr returns an Option serving on addr whose first n serve calls fail.
+func withFailingAddr(addr string, n int32) Option {
+	return withFlakyAddr(addr, n, func() error { return errors.New("transient") })
+}
+
+// withFlakyAddr returns an Option serving on addr whose first n serve calls run fail instead.
+func withFlakyAddr(addr string, n int32, fail func() error) Option {
+	var calls int32
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		return func(ctx context.Context, s *managedServer) error {
+			if atomic.AddInt32(&calls, 1) <= n {
+				return fail()
+			}
+
+			srv := g.appendHTTPServer(s)
+			l, err := g.listenTCP(ctx, s, addr, "")
+			if err != nil {
+				return err
+			}
+			return srv.Serve(l)
+		}, donothing, nil
+	})
+}
+
+func TestServePanicShutdown(t *testing.T) {
+	var recovered atomic.Value
+	router, err := Default(
+		WithAddr(":8082"),
+		withPanickingAddr(":8083", 1),
+		WithOnServePanic(func(r any) { recovered.Store(r) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		var panicErr *PanicError
+		if assert.ErrorAs(t, err, &panicErr) {
+			assert.Equal(t, "boom", panicErr.Value)
+			assert.NotEmpty(t, panicErr.Stack)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after a panic")
+	}
+	assert.Equal(t, "boom", recovered.Load())
+}
+
+func TestWithOnPanic(t *testing.T) {
+	var paths []string
+	var recovered []any
+	router, err := Default(
+		WithAddr(":8082"),
+		withPanickingAddr(":8083", 1),
+		WithOnPanic(func(c *gin.Context, r any) {
+			path := ""
+			if c != nil {
+				path = c.Request.URL.Path
+			}
+			paths = append(paths, path)
+			recovered = append(recovered, r)
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/panic", func(*gin.Context) { panic("handler boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	assert.Error(t, router.RunWithContext(context.Background()))
+	assert.Equal(t, []string{"/panic", ""}, paths)
+	assert.Equal(t, []any{"handler boom", "boom"}, recovered)
+}
+
+func TestServePanicRestart(t *testing.T) {
+	var panics int32
+	router, err := Default(
+		withPanickingAddr(":8083", 2),
+		WithPanicRestart(RestartPolicy{Backoff: 10 * time.Millisecond}),
+		WithOnServePanic(func(any) { atomic.AddInt32(&panics, 1) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.NoError(t, router.RunWithContext(ctx))
+		cancel()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	testRequest(t, "http://localhost:8083/example")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&panics))
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	<-ctx.Done()
+}
+
+func TestServePanicRestartLimit(t *testing.T) {
+	router, err := Default(
+		withPanickingAddr(":8083", 3),
+		WithPanicRestart(RestartPolicy{MaxRestarts: 2, Backoff: time.Millisecond}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	var panicErr *PanicError
+	assert.ErrorAs(t, router.RunWithContext(context.Background()), &panicErr)
+}
+
+func TestRestartPolicy(t *testing.T) {
+	router, err := Default(
+		withFailingAddr(":8083", 2),
+		WithRestartPolicy(RestartPolicy{Backoff: 10 * time.Millisecond}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.NoError(t, router.RunWithContext(ctx))
+		cancel()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	testRequest(t, "http://localhost:8083/example")
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	<-ctx.Done()
+}
+
+func TestRestartPolicyLimit(t *testing.T) {
+	router, err := Default(
+		withFailingAddr(":8083", 3),
+		WithRestartPolicy(RestartPolicy{MaxRestarts: 2, Backoff: time.Millisecond}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "transient")
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+	policy := RestartPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, policy.delay(0))
+	assert.Equal(t, 2*time.Second, policy.delay(1))
+	assert.Equal(t, 4*time.Second, policy.delay(2))
+	assert.Equal(t, 5*time.Second, policy.delay(3))
+	assert.Equal(t, defaultRestartBackoff, RestartPolicy{}.delay(0))
+	assert.Equal(t, defaultRestartMaxBackoff, RestartPolicy{}.delay(10))
+}
+
+func TestErrors(t *testing.T) {
+	router, err := Default(
+		withFailingAddr(":8107", 2),
+		WithRestartPolicy(RestartPolicy{Backoff: time.Millisecond}),
+	)
+	assert.NoError(t, err)
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	errs := router.Errors()
+	assert.NoError(t, router.Start())
+
+	for restarts := 1; restarts <= 2; restarts++ {
+		select {
+		case err := <-errs:
+			var restartErr *RestartError
+			if assert.ErrorAs(t, err, &restartErr) {
+				assert.Equal(t, restarts, restartErr.Restarts)
+				assert.EqualError(t, restartErr.Err, "transient")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no restart error")
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	testRequest(t, "http://localhost:8107/example")
+	assert.NoError(t, router.Stop())
+
+	router.Close()
+	_, ok := <-errs
+	assert.False(t, ok)
+}
+
+func TestWithOnServeError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	var addrs []string
+	var errs []error
+	router, err := Default(
+		WithListener(l),
+		WithOnServeError(func(addr string, err error) {
+			addrs = append(addrs, addr)
+			errs = append(errs, err)
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, l.Close())
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after the listener failed")
+	}
+	assert.Equal(t, []string{l.Addr().String()}, addrs)
+	if assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], net.ErrClosed)
+	}
+}
+
+func TestServeErrorShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	router, err := Default(WithAddr(":8107"), WithListener(l))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	testRequest(t, "http://localhost:8107/example")
+	assert.NoError(t, l.Close())
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, net.ErrClosed)
+		assert.ErrorIs(t, err, ErrServeFailed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after a server failed")
+	}
+
+	for _, status := range router.ServerStatus() {
+		assert.NotEqual(t, ServerServing, status.State)
+	}
+}
+
+// flakyListener is a TCP listener whose Accept fails once armed, after accepting the connection.
+type flakyListener struct {
+	*net.TCPListener
+	armed atomic.Bool
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	conn, err := l.TCPListener.Accept()
+	if err == nil && l.armed.CompareAndSwap(true, false) {
+		conn.Close()
+		return nil, errors.New("transient")
+	}
+	return conn, err
+}
+
+// fail makes the server of l fail, by arming it then connecting.
+func (l *flakyListener) fail(t *testing.T) {
+	t.Helper()
+
+	l.armed.Store(true)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
+
+func TestRestartPolicyListener(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	l := &flakyListener{TCPListener: tcp.(*net.TCPListener)}
+	url := "http://" + l.Addr().String() + "/example"
+
+	router, err := Default(
+		WithListener(l),
+		WithRestartPolicy(RestartPolicy{MaxRestarts: 1, Backoff: time.Millisecond, ResetAfter: 100 * time.Millisecond}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	errs := router.Errors()
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+
+	// An idle connection of the failed server is closed once it is restarted.
+	idle, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer idle.Close()
+	_, err = idle.Write([]byte("GET /example HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.NoError(t, err)
+	reader := bufio.NewReader(idle)
+	resp, err := http.ReadResponse(reader, nil)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	// The restarts are counted afresh once the server served for ResetAfter, so that it is
+	// restarted twice with MaxRestarts 1.
+	for i := 0; i < 2; i++ {
+		time.Sleep(150 * time.Millisecond)
+		l.fail(t)
+		select {
+		case err := <-errs:
+			var restartErr *RestartError
+			if assert.ErrorAs(t, err, &restartErr) {
+				assert.Equal(t, 1, restartErr.Restarts)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no restart error")
+		}
+		assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+	}
+
+	assert.NoError(t, idle.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = reader.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.NoError(t, router.Stop())
+}
+
+func TestServePanicRestartListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	url := "http://" + l.Addr().String() + "/example"
+
+	var panics int32
+	router, err := Default(
+		WithListener(l),
+		WithPanicRestart(RestartPolicy{MaxRestarts: 1, Backoff: time.Millisecond}),
+		WithOnServePanic(func(any) { atomic.AddInt32(&panics, 1) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	// The first Serve call panics, with the listener already handed to it.
+	var calls int32
+	router.ConfigureServers(func(srv *http.Server) {
+		base := srv.BaseContext
+		srv.BaseContext = func(l net.Listener) context.Context {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				panic("boom")
+			}
+			return base(l)
+		}
+	})
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, url) }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&panics))
+	assert.NoError(t, router.Stop())
+}
@@ -0,0 +1,111 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The exit codes of RunAndExit, see ExitCode.
+const (
+	// ExitOK is the exit code of a clean shutdown.
+	ExitOK = 0
+	// ExitFailure is the exit code of any other failure, including an invalid configuration and
+	// a recycled instance, so that a supervisor restarting the failed processes restarts it.
+	ExitFailure = 1
+	// ExitBindFailed is the exit code of a listener that could not be bound.
+	ExitBindFailed = 3
+	// ExitDrainTimeout is the exit code of a drain that did not complete in time.
+	ExitDrainTimeout = 4
+	// ExitHookFailed is the exit code of a failed shutdown hook.
+	ExitHookFailed = 5
+)
+
+// ExitCode returns the exit code of RunAndExit for err: ExitOK for nil, else the first of
+// ExitBindFailed, ExitDrainTimeout and ExitHookFailed err matches, else ExitFailure.
+func ExitCode(err error) int {
+	var hookErr *HookError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrBindFailed):
+		return ExitBindFailed
+	case errors.Is(err, ErrDrainTimeout):
+		return ExitDrainTimeout
+	case errors.As(err, &hookErr):
+		return ExitHookFailed
+	default:
+		return ExitFailure
+	}
+}
+
+// RunAndExit runs a Graceful instance built from engine and opts with RunWithSignals, until SIGINT
+// or SIGTERM is received, then exits the process with the ExitCode of the outcome, the error being
+// printed to the standard error, if any. It is meant to be the last call of main.
+func RunAndExit(engine *gin.Engine, opts ...Option) {
+	g, err := New(engine, opts...)
+	if err == nil {
+		err = g.RunWithSignals()
+		g.Close()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "graceful:", err)
+	}
+	exit(ExitCode(err))
+}
+
+// RunWithSignals runs the instance until one of signals is received, SIGINT or SIGTERM if none is
+// given, then gracefully shuts it down with the signal recorded as the cause of the shutdown, see
+// HookInfo. The shutdown waits at most the total of the ShutdownBudget, if configured. It returns
+// the error of the run, or of the shutdown, if any. The tests deliver the signals with Signal.
+func (g *Graceful) RunWithSignals(signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	ctx, stop, ch := notifyContext(context.Background(), signals...)
+	defer stop()
+
+	g.lock.Lock()
+	g.signalInbox = &signalInbox{ch: ch, signals: signals}
+	g.lock.Unlock()
+	defer func() {
+		g.lock.Lock()
+		g.signalInbox = nil
+		g.lock.Unlock()
+	}()
+
+	run := make(chan error, 1)
+	go func() {
+		run <- g.RunWithContext(context.Background())
+	}()
+
+	select {
+	case err := <-run:
+		// The run ended on its own, e.g. a listener could not be bound.
+		return err
+	case <-ctx.Done():
+	}
+
+	g.lock.Lock()
+	g.stopSignal = signalOf(ctx)
+	g.stopCause = causeOf(ctx)
+	g.lock.Unlock()
+
+	shutdownCtx, cancel := g.shutdownContext(context.Background())
+	defer cancel()
+	err := g.shutdown(shutdownCtx, "signal")
+	return errors.Join(err, <-run)
+}
+
+// shutdownContext returns the context of a shutdown triggered from outside of the instance,
+// derived from ctx: it is bounded by the total of the ShutdownBudget, if configured.
+func (g *Graceful) shutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.budget == nil {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.budget.Total)
+}
@@ -0,0 +1,27 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gin-contrib/graceful/lifecycle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	hookErr := &lifecycle.HookError{Name: "close", Phase: "after shutdown", Err: errors.New("already closed")}
+	drainErr := drainError(context.DeadlineExceeded)
+	for err, code := range map[error]int{
+		nil:                                  ExitOK,
+		errors.New("invalid"):                ExitFailure,
+		ErrRecycled:                          ExitFailure,
+		fmt.Errorf("%w: :80", ErrBindFailed): ExitBindFailed,
+		drainErr:                             ExitDrainTimeout,
+		hookErr:                              ExitHookFailed,
+		errors.Join(hookErr, drainErr):       ExitDrainTimeout,
+	} {
+		assert.Equal(t, code, ExitCode(err), fmt.Sprint(err))
+	}
+}
@@ -0,0 +1,214 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Runner is a component run alongside the servers of the Graceful instance, e.g. a gRPC server
+// or a queue consumer.
+type Runner interface {
+	// Run runs the component until ctx is canceled, which happens when the Graceful instance
+	// shuts down. The Lifecycle of the instance is available from ctx, see FromContext.
+	Run(ctx context.Context) error
+}
+
+// RunnerFunc is an adapter to allow the use of ordinary functions as Runner.
+type RunnerFunc func(ctx context.Context) error
+
+// Run calls f(ctx).
+func (f RunnerFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// ReadyNotifier can be implemented by a Runner to report when it is ready, so that the
+// components depending on it start only then. A Runner without it is ready once started.
+type ReadyNotifier interface {
+	Ready() <-chan struct{}
+}
+
+// WithRunner configure a Runner, named so that other components can depend on it, started once
+// every component it depends on is ready.
+func WithRunner(name string, r Runner, dependsOn ...string) Option {
+	return WithComponent(name, withRunner(r), dependsOn...)
+}
+
+// WithComponent names the servers of the given option so that other components can depend on
+// them, and declares the components they depend on. The servers start once every component
+// they depend on is ready, i.e. bound for servers. New fails on unknown dependencies and
+// dependency cycles.
+func WithComponent(name string, opt Option, dependsOn ...string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if name == "" {
+			return nil, donothing, errors.New("empty component name")
+		}
+		for _, s := range g.servers.load() {
+			if s.name == name {
+				return nil, donothing, fmt.Errorf("duplicate component %q", name)
+			}
+		}
+
+		servers, err := g.applyServers(opt)
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		for _, s := range servers {
+			s.name = name
+			s.dependsOn = append(s.dependsOn, dependsOn...)
+		}
+
+		return nil, donothing, nil
+	})
+}
+
+// withRunner returns an Option running r as a server of the Graceful instance.
+func withRunner(r Runner) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if r == nil {
+			return nil, donothing, errors.New("nil runner")
+		}
+
+		return func(_ context.Context, s *managedServer) error {
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), gracefulKey{}, g))
+			defer cancel()
+
+			stop := &runnerStopper{cancel: cancel, done: make(chan struct{})}
+			defer close(stop.done)
+			s.setServer(stop)
+
+			if n, ok := r.(ReadyNotifier); ok {
+				waited := make(chan struct{})
+				defer func() {
+					cancel()
+					<-waited
+				}()
+				go func() {
+					defer close(waited)
+					select {
+					case <-n.Ready():
+						s.bound("")
+						s.serving()
+					case <-ctx.Done():
+					}
+				}()
+			} else {
+				s.bound("")
+				s.serving()
+			}
+
+			err := r.Run(ctx)
+			if ctx.Err() != nil && errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}, donothing, nil
+	})
+}
+
+// runnerStopper stops a running Runner by canceling its context.
+type runnerStopper struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Shutdown cancels the Runner context and waits for Run to return or ctx to be done.
+func (r *runnerStopper) Shutdown(ctx context.Context) error {
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close cancels the Runner context without waiting for Run to return.
+func (r *runnerStopper) Close() error {
+	r.cancel()
+	return nil
+}
+
+// resolveDependencies gates the start of every component on the components it depends on, once,
+// when the Graceful instance is created. It returns an error if a dependency is unknown or if the
+// dependencies form a cycle.
+func (g *Graceful) resolveDependencies() error {
+	components, dependsOn, err := g.checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	for name, servers := range components {
+		for _, s := range servers {
+			for _, dep := range dependsOn[name] {
+				s.after = append(s.after, components[dep]...)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDependencies returns the servers of every component and the components they depend on,
+// without changing them, or an error if a dependency is unknown or if the dependencies form a
+// cycle.
+func (g *Graceful) checkDependencies() (map[string][]*managedServer, map[string][]string, error) {
+	components := map[string][]*managedServer{}
+	dependsOn := map[string][]string{}
+	for _, s := range g.servers.load() {
+		if s.name == "" {
+			continue
+		}
+		components[s.name] = append(components[s.name], s)
+		dependsOn[s.name] = s.dependsOn
+	}
+
+	for name, deps := range dependsOn {
+		for _, dep := range deps {
+			if _, ok := components[dep]; !ok {
+				return nil, nil, fmt.Errorf("component %q depends on unknown component %q", name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, dep := range dependsOn[name] {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+
+		return nil
+	}
+
+	for _, s := range g.servers.load() {
+		if s.name == "" || marks[s.name] != unvisited {
+			continue
+		}
+		if err := visit(s.name, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return components, dependsOn, nil
+}
@@ -0,0 +1,120 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// readyRunner is a Runner reporting itself ready once ready is closed.
+type readyRunner struct {
+	ready   chan struct{}
+	stopped chan struct{}
+}
+
+func (r *readyRunner) Run(ctx context.Context) error {
+	<-ctx.Done()
+	close(r.stopped)
+	return ctx.Err()
+}
+
+func (r *readyRunner) Ready() <-chan struct{} {
+	return r.ready
+}
+
+func TestWithRunnerDependencies(t *testing.T) {
+	backend := &readyRunner{ready: make(chan struct{}), stopped: make(chan struct{})}
+	router, err := Default(
+		WithComponent("frontend", WithAddr(":8090"), "backend"),
+		WithRunner("backend", backend),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	time.Sleep(50 * time.Millisecond)
+
+	statuses := router.ServerStatus()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "frontend", statuses[0].Name)
+		assert.Equal(t, ServerIdle, statuses[0].State)
+		assert.Equal(t, "backend", statuses[1].Name)
+		assert.Equal(t, ServerIdle, statuses[1].State)
+	}
+
+	close(backend.ready)
+	testRequest(t, "http://localhost:8090/example")
+	assert.Equal(t, ServerServing, router.ServerStatus()[1].State)
+
+	assert.NoError(t, router.Stop())
+	<-backend.stopped
+	assert.Equal(t, ServerStopped, router.ServerStatus()[1].State)
+}
+
+func TestWithRunnerError(t *testing.T) {
+	router, err := Default(
+		WithAddr(":8090"),
+		WithRunner("worker", RunnerFunc(func(context.Context) error {
+			return errors.New("worker failed")
+		})),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, router.Shutdown(context.Background()))
+	}()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "worker failed")
+}
+
+func TestWithComponentErrors(t *testing.T) {
+	noop := RunnerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	_, err := New(gin.New(), WithRunner("a", noop, "b"), WithRunner("b", noop, "c"), WithRunner("c", noop, "a"))
+	assert.EqualError(t, err, "dependency cycle: a -> b -> c -> a")
+
+	_, err = New(gin.New(), WithRunner("a", noop, "missing"))
+	assert.EqualError(t, err, `component "a" depends on unknown component "missing"`)
+
+	_, err = New(gin.New(), WithRunner("a", noop), WithRunner("a", noop))
+	assert.EqualError(t, err, `duplicate component "a"`)
+
+	_, err = New(gin.New(), WithRunner("", noop))
+	assert.EqualError(t, err, "empty component name")
+
+	_, err = New(gin.New(), WithRunner("a", nil))
+	assert.EqualError(t, err, "nil runner")
+}
+
+func TestRunnerLifecycle(t *testing.T) {
+	woken := make(chan struct{})
+	router, err := Default(WithRunner("worker", RunnerFunc(func(ctx context.Context) error {
+		lc, ok := FromContext(ctx)
+		if assert.True(t, ok) {
+			assert.False(t, lc.Draining())
+			defer lc.RegisterWakeup(func() { close(woken) })()
+		}
+		<-ctx.Done()
+		return nil
+	})))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+	<-woken
+}
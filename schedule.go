@@ -0,0 +1,57 @@
+package graceful
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WithScheduledRestart configure the instance to recycle itself at the times of the given cron
+// schedule, in the local time zone, e.g. "30 3 * * *" during a low-traffic window. Each restart
+// is delayed by a random duration up to jitter, so that the replicas of a fleet do not restart
+// all at once. The run is gracefully shut down, then the action configured with
+// WithRecycleAction, if any, is run, see WithMaxRequests.
+func WithScheduledRestart(spec string, jitter time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		schedule, err := parseCron(spec)
+		if err != nil {
+			return nil, donothing, err
+		}
+		g.restartSchedule = schedule
+		g.restartJitter = jitter
+		return nil, donothing, nil
+	})
+}
+
+// nextRestart returns the delay before the next scheduled restart after now, jitter included. It
+// reports false if the schedule has no next time.
+func (g *Graceful) nextRestart(now time.Time) (time.Duration, bool) {
+	next := g.restartSchedule.next(now)
+	if next.IsZero() {
+		return 0, false
+	}
+
+	delay := next.Sub(now)
+	if g.restartJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(g.restartJitter))) //nolint:gosec // no cryptographic use
+	}
+	return delay, true
+}
+
+// watchSchedule recycles the instance at the next scheduled restart, unless ctx is done first.
+func (g *Graceful) watchSchedule(ctx context.Context) {
+	delay, ok := g.nextRestart(time.Now())
+	if !ok {
+		return
+	}
+	g.debugLog("restart scheduled", "at", time.Now().Add(delay))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+		g.recycle("scheduled restart")
+	}
+}
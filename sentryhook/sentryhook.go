@@ -0,0 +1,29 @@
+// Package sentryhook flushes the events buffered by the Sentry SDK when a graceful.Graceful
+// instance shuts down, so that the errors reported while draining reach Sentry before the
+// process exits.
+package sentryhook
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-contrib/graceful"
+)
+
+// defaultName is the name of the after shutdown hook.
+const defaultName = "sentry"
+
+// Hook returns an after shutdown hook flushing hub, the current hub if nil, waiting at most d,
+// or less if the shutdown deadline is closer, see graceful.FlushHook.
+func Hook(hub *sentry.Hub, d time.Duration) graceful.Hook {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	return graceful.FlushHook(hub, d)
+}
+
+// WithSentry configure an after shutdown hook, named "sentry", flushing hub, the current hub if
+// nil, waiting at most d, e.g. graceful.Default(sentryhook.WithSentry(nil, 2*time.Second)).
+func WithSentry(hub *sentry.Hub, d time.Duration, opts ...graceful.HookOption) graceful.Option {
+	return graceful.WithAfterShutdown(defaultName, Hook(hub, d), opts...)
+}
@@ -0,0 +1,57 @@
+package sentryhook
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-contrib/graceful"
+	"github.com/stretchr/testify/assert"
+)
+
+// transport is a sentry.Transport recording the flushes.
+type transport struct {
+	flushed atomic.Int32
+	ok      bool
+}
+
+func (t *transport) Flush(time.Duration) bool {
+	t.flushed.Add(1)
+	return t.ok
+}
+
+func (*transport) Configure(sentry.ClientOptions) {}
+
+func (*transport) SendEvent(*sentry.Event) {}
+
+// newHub returns a hub whose client sends its events on t.
+func newHub(t *testing.T, tr *transport) *sentry.Hub {
+	t.Helper()
+
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: tr})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return sentry.NewHub(client, sentry.NewScope())
+}
+
+func TestWithSentry(t *testing.T) {
+	tr := &transport{ok: true}
+	router, err := graceful.Default(graceful.WithAddr("127.0.0.1:8171"), WithSentry(newHub(t, tr), time.Second))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), tr.flushed.Load())
+}
+
+func TestHookTimeout(t *testing.T) {
+	tr := &transport{}
+	assert.EqualError(t, Hook(newHub(t, tr), time.Second)(context.Background()), "flush timed out")
+	assert.Equal(t, int32(1), tr.flushed.Load())
+}
@@ -0,0 +1,161 @@
+package graceful
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ServerManager manages the http.Servers of other components of the process alongside the servers
+// of the Graceful instance, such as a metrics or pprof server with its own handler, so that they
+// are started with the instance and drained by its shutdown, and are reported by ServerStatus and
+// the shutdown report.
+type ServerManager struct {
+	g *Graceful
+}
+
+// Servers returns the ServerManager of the Graceful instance.
+func (g *Graceful) Servers() *ServerManager {
+	return &ServerManager{g: g}
+}
+
+// Add adds srv, serving its own handler on l, or on srv.Addr if l is nil, over HTTPS if srv has a
+// TLSConfig. If the Graceful instance is running, the server is started and Add waits for its
+// listener to be bound until ctx is done; otherwise it is started by the next run. The settings of
+// ConfigureServers do not apply to srv, and l is closed when srv shuts down.
+func (m *ServerManager) Add(ctx context.Context, srv *http.Server, l net.Listener) error {
+	g := m.g
+	if srv == nil {
+		return errors.New("nil http server")
+	}
+
+	g.reloadLock.Lock()
+	defer g.reloadLock.Unlock()
+
+	key := managedKey(srv)
+	if m.entry(key) != nil {
+		return errors.New("server already managed")
+	}
+	option := optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		return g.managedOption(srv, l)
+	})
+	return g.addEntry(ctx, keyedOption{Option: option, key: key, managed: true})
+}
+
+// Remove drains srv until ctx is done, and stops managing it.
+func (m *ServerManager) Remove(ctx context.Context, srv *http.Server) error {
+	g := m.g
+	g.reloadLock.Lock()
+	defer g.reloadLock.Unlock()
+
+	e := m.entry(managedKey(srv))
+	if e == nil {
+		return errors.New("server not managed")
+	}
+	return g.removeEntry(ctx, e)
+}
+
+// Shutdown drains every server added to the ServerManager, one after the other until ctx is done,
+// and stops managing them. The other servers of the Graceful instance keep serving.
+func (m *ServerManager) Shutdown(ctx context.Context) error {
+	g := m.g
+	g.reloadLock.Lock()
+	defer g.reloadLock.Unlock()
+
+	var errs []error
+	for _, e := range m.entries() {
+		if err := g.removeEntry(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Status returns the status of every server added to the ServerManager, in the order they were
+// added.
+func (m *ServerManager) Status() []ServerStatus {
+	entries := m.entries()
+
+	statuses := make([]ServerStatus, 0, len(entries))
+	for _, e := range entries {
+		statuses = append(statuses, e.server.status())
+	}
+	return statuses
+}
+
+// entries returns the entries of the servers added to the ServerManager.
+func (m *ServerManager) entries() []*reloadEntry {
+	m.g.lock.Lock()
+	defer m.g.lock.Unlock()
+
+	var entries []*reloadEntry
+	for _, e := range m.g.reloaded {
+		if e.option.managed {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// entry returns the entry of the server with the given key, nil if it is not managed.
+func (m *ServerManager) entry(key string) *reloadEntry {
+	for _, e := range m.entries() {
+		if e.option.key == key {
+			return e
+		}
+	}
+	return nil
+}
+
+// managedKey returns the key of srv added to the ServerManager.
+func managedKey(srv *http.Server) string {
+	return fmt.Sprintf("managed server %p", srv)
+}
+
+// managedOption serves srv, keeping its handler, on l or on srv.Addr if l is nil.
+func (g *Graceful) managedOption(srv *http.Server, l net.Listener) (listenAndServe, cleanup, error) {
+	fallback := ":http"
+	if srv.TLSConfig != nil {
+		fallback = ":https"
+	}
+	if l == nil {
+		if err := validateAddr(srv.Addr); err != nil {
+			return nil, donothing, err
+		}
+		if err := g.claimTCP(srv.Addr, fallback); err != nil {
+			return nil, donothing, err
+		}
+		g.checkBind("tcp", srv.Addr, fallback)
+	}
+	if srv.TLSConfig != nil {
+		g.checkCertificates(func() []tls.Certificate { return srv.TLSConfig.Certificates })
+	}
+
+	connState, baseContext := srv.ConnState, srv.BaseContext
+	run := func(ctx context.Context, s *managedServer) error {
+		srv.ConnState = s.trackConn(g.recordConnState(s, g.trackIdle(connState)))
+		srv.BaseContext = g.baseContext(baseContext)
+		s.setServer(srv)
+
+		listener := l
+		if listener == nil {
+			var err error
+			if listener, err = g.listenTCP(ctx, s, srv.Addr, fallback); err != nil {
+				return err
+			}
+		} else {
+			listener = g.wrapListener(s, listener)
+		}
+		if srv.TLSConfig == nil {
+			return srv.Serve(listener)
+		}
+		return s.serveTLS(srv, listener)
+	}
+	if l == nil {
+		return run, donothing, nil
+	}
+	return run, func() { _ = l.Close() }, nil
+}
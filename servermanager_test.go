@@ -0,0 +1,91 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerManager(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8142"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	metrics := &http.Server{
+		Addr:              "127.0.0.1:8143",
+		Handler:           http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		ReadHeaderTimeout: time.Second,
+	}
+	manager := router.Servers()
+	assert.NoError(t, manager.Add(context.Background(), metrics, nil))
+	assert.EqualError(t, manager.Add(context.Background(), metrics, nil), "server already managed")
+	assert.Equal(t, []ServerStatus{{State: ServerIdle}}, manager.Status())
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return reachable(t, "http://127.0.0.1:8143/") }, time.Second, 5*time.Millisecond)
+	assert.Len(t, router.ServerStatus(), 2)
+
+	// A server added to the running instance is started.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	pprof := &http.Server{
+		Handler:           http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		ReadHeaderTimeout: time.Second,
+	}
+	assert.NoError(t, manager.Add(context.Background(), pprof, listener))
+	assert.True(t, reachable(t, fmt.Sprintf("http://%s/", listener.Addr())))
+	statuses := manager.Status()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, ServerServing, statuses[0].State)
+		assert.Equal(t, listener.Addr().String(), statuses[1].Addr)
+	}
+
+	assert.NoError(t, manager.Remove(context.Background(), pprof))
+	assert.False(t, reachable(t, fmt.Sprintf("http://%s/", listener.Addr())))
+	assert.EqualError(t, manager.Remove(context.Background(), pprof), "server not managed")
+	assert.Equal(t, ServerServing, router.ServerStatus()[0].State)
+
+	assert.NoError(t, manager.Shutdown(context.Background()))
+	assert.Empty(t, manager.Status())
+	assert.False(t, reachable(t, "http://127.0.0.1:8143/"))
+	assert.Len(t, router.ServerStatus(), 1)
+
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.EqualError(t, manager.Add(context.Background(), nil, nil), "nil http server")
+}
+
+func TestServerManagerConcurrentChecks(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8170"), WithResourceChecks(ResourceChecks{}))
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.NoError(t, router.Start())
+
+	// The preflight checks and the resources are read while servers are added and removed.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			_ = router.Validate(context.Background())
+			_ = router.resourceProblems(time.Now())
+		}
+	}()
+
+	manager := router.Servers()
+	for i := 0; i < 20; i++ {
+		srv := &http.Server{
+			Addr:              "127.0.0.1:0",
+			Handler:           http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			ReadHeaderTimeout: time.Second,
+		}
+		assert.NoError(t, manager.Add(context.Background(), srv, nil))
+		assert.NoError(t, manager.Remove(context.Background(), srv))
+	}
+	<-done
+
+	assert.NoError(t, router.Stop())
+}
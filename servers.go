@@ -0,0 +1,53 @@
+package graceful
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// serverSet is the copy-on-write list of the servers of the Graceful instance, so that a run, a
+// shutdown or a status query reads a snapshot without contending with the others, even with
+// hundreds of listeners. The snapshots returned by load are immutable, a change stores a copy.
+type serverSet struct {
+	// mu serializes the changes.
+	mu      sync.Mutex
+	servers atomic.Pointer[[]*managedServer]
+}
+
+// load returns the current servers, in the order they were configured. The returned slice must
+// not be modified.
+func (s *serverSet) load() []*managedServer {
+	if servers := s.servers.Load(); servers != nil {
+		return *servers
+	}
+	return nil
+}
+
+// add appends the given servers.
+func (s *serverSet) add(servers ...*managedServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.load()
+	next := make([]*managedServer, 0, len(current)+len(servers))
+	next = append(append(next, current...), servers...)
+	s.servers.Store(&next)
+}
+
+// remove removes the given server.
+func (s *serverSet) remove(server *managedServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// removeItem copies the servers following the removed one.
+	next := removeItem(s.load(), server)
+	s.servers.Store(&next)
+}
+
+// clear removes every server.
+func (s *serverSet) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.servers.Store(nil)
+}
@@ -0,0 +1,37 @@
+package graceful
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerSet(t *testing.T) {
+	var set serverSet
+	a, b, c := &managedServer{}, &managedServer{}, &managedServer{}
+
+	set.add(a, b)
+	snapshot := set.load()
+	set.add(c)
+	set.remove(a)
+	assert.Equal(t, []*managedServer{a, b}, snapshot)
+	assert.Equal(t, []*managedServer{b, c}, set.load())
+
+	set.clear()
+	assert.Empty(t, set.load())
+}
+
+func BenchmarkServerStatus(b *testing.B) {
+	router, err := Default()
+	assert.NoError(b, err)
+	defer router.Close()
+	for i := 0; i < 200; i++ {
+		router.servers.add(&managedServer{})
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = router.activeConnections()
+		}
+	})
+}
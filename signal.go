@@ -0,0 +1,99 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// SignalError is the cause of the context returned by NotifyContext once a signal is received.
+type SignalError struct {
+	// Signal is the received signal.
+	Signal os.Signal
+}
+
+// Error implements the error interface.
+func (e *SignalError) Error() string {
+	return "received signal " + e.Signal.String()
+}
+
+// NotifyContext returns a copy of parent canceled once one of the given signals is received, or
+// any signal if none is given, like signal.NotifyContext. The received signal is recorded as a
+// *SignalError cause of the context, so that a RunWithContext run shut down by it passes the signal
+// to the hooks, see HookInfo. Calling stop releases the signals.
+func NotifyContext(parent context.Context, signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop, _ = notifyContext(parent, signals...)
+	return ctx, stop
+}
+
+// notifyContext is NotifyContext, also returning the channel the signals are received on, so that
+// Signal can deliver them as if the process received them.
+func notifyContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc, chan os.Signal) {
+	ctx, cancel := context.WithCancelCause(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			cancel(&SignalError{Signal: sig})
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel(nil)
+	}, ch
+}
+
+// signalInbox receives the signals of a RunWithSignals run.
+type signalInbox struct {
+	ch      chan os.Signal
+	signals []os.Signal
+}
+
+// Signal delivers sig to the run of RunWithSignals as if the process received it, so that the
+// tests exercise the signal-triggered shutdown, hooks included, without signaling the test
+// process, see gracefultest.SendShutdownSignal. It returns ErrNotStarted if the instance is not
+// run with RunWithSignals, and an error if the run does not handle sig.
+func (g *Graceful) Signal(sig os.Signal) error {
+	g.lock.Lock()
+	inbox := g.signalInbox
+	g.lock.Unlock()
+
+	if inbox == nil {
+		return ErrNotStarted
+	}
+	handled := false
+	for _, s := range inbox.signals {
+		handled = handled || s == sig
+	}
+	if !handled {
+		return fmt.Errorf("signal %v not handled", sig)
+	}
+	// Like the signals of the process, a signal is dropped while another one is pending.
+	select {
+	case inbox.ch <- sig:
+	default:
+	}
+	return nil
+}
+
+// causeOf returns the cause ctx was canceled with, nil if it is not done or has no cause of its
+// own.
+func causeOf(ctx context.Context) error {
+	if cause := context.Cause(ctx); cause != ctx.Err() {
+		return cause
+	}
+	return nil
+}
+
+// signalOf returns the signal recorded as the cause of ctx by NotifyContext, if any.
+func signalOf(ctx context.Context) os.Signal {
+	var e *SignalError
+	if errors.As(context.Cause(ctx), &e) {
+		return e.Signal
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+//go:build unix
+
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookInfo(t *testing.T) {
+	var infos []HookInfo
+	hook := func(ctx context.Context) error {
+		info, ok := HookInfoFromContext(ctx)
+		assert.True(t, ok)
+		infos = append(infos, info)
+		return nil
+	}
+	router, err := Default(
+		WithAddr("127.0.0.1:8125"),
+		WithBeforeShutdown("before", hook),
+		WithAfterShutdown("after", hook),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	ctx, stop := NotifyContext(context.Background(), syscall.SIGUSR1)
+	defer stop()
+	done := make(chan error)
+	go func() { done <- router.RunWithContext(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	<-done
+	assert.Equal(t, &SignalError{Signal: syscall.SIGUSR1}, context.Cause(ctx))
+
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, "context done", infos[0].Reason)
+		assert.Equal(t, syscall.SIGUSR1, infos[0].Signal)
+		assert.Equal(t, &SignalError{Signal: syscall.SIGUSR1}, infos[0].Cause)
+		assert.Equal(t, "before shutdown", infos[0].Phase)
+		assert.Equal(t, "after shutdown", infos[1].Phase)
+		assert.True(t, infos[1].Deadline.IsZero())
+	}
+
+	_, ok := HookInfoFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestRunAndExit(t *testing.T) {
+	codes := make(chan int, 1)
+	exit = func(code int) { codes <- code }
+	defer func() { exit = os.Exit }()
+
+	var info HookInfo
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	go RunAndExit(engine,
+		WithAddr("127.0.0.1:8154"),
+		WithAfterShutdown("close", func(ctx context.Context) error {
+			info, _ = HookInfoFromContext(ctx)
+			return errors.New("already closed")
+		}),
+	)
+	assert.Eventually(t, func() bool { return reachable(t, "http://127.0.0.1:8154/") }, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	select {
+	case code := <-codes:
+		assert.Equal(t, ExitHookFailed, code)
+	case <-time.After(time.Second):
+		t.Fatal("RunAndExit did not exit")
+	}
+	assert.Equal(t, "signal", info.Reason)
+	assert.Equal(t, syscall.SIGTERM, info.Signal)
+
+	// An invalid configuration exits right away.
+	go RunAndExit(gin.New(), WithShutdownWatchdog(0, 1))
+	assert.Equal(t, ExitFailure, <-codes)
+}
+
+func TestRunWithSignals(t *testing.T) {
+	var info HookInfo
+	router, err := Default(
+		WithAddr("127.0.0.1:8155"),
+		WithShutdownBudget(ShutdownBudget{Total: 100 * time.Millisecond, BeforeShutdown: 0.5, Drain: 0.5}),
+		WithBeforeShutdown("hang", func(ctx context.Context) error {
+			info, _ = HookInfoFromContext(ctx)
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- router.RunWithSignals(syscall.SIGUSR2) }()
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	err = <-done
+	// The shutdown is bounded by the budget.
+	assert.Less(t, time.Since(start), time.Second)
+	var hookErr *HookError
+	if assert.ErrorAs(t, err, &hookErr) {
+		assert.Equal(t, "hang", hookErr.Name)
+	}
+	assert.Equal(t, syscall.SIGUSR2, info.Signal)
+	assert.Equal(t, &SignalError{Signal: syscall.SIGUSR2}, info.Cause)
+}
@@ -0,0 +1,83 @@
+package graceful
+
+import (
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// WithSlowStart configure a slow start of the listeners managed by the Graceful instance: after
+// a listener is created, on start or restart, its accepted connection rate ramps up linearly from
+// zero to rate connections per second over d, after which connections are accepted unthrottled.
+// It protects cold caches from a thundering herd right after a deploy, the pending connections
+// waiting in the kernel backlog meanwhile.
+func WithSlowStart(d time.Duration, rate float64) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.slowStart = d
+		g.slowStartRate = rate
+		return nil, donothing, nil
+	})
+}
+
+// slowStartListener throttles the accepted connections during the slow start period.
+type slowStartListener struct {
+	net.Listener
+	start    time.Time
+	period   time.Duration
+	rate     float64
+	accepted int
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newSlowStartListener returns a listener throttling l over the given period.
+func newSlowStartListener(l net.Listener, period time.Duration, rate float64) *slowStartListener {
+	return &slowStartListener{
+		Listener: l,
+		start:    time.Now(),
+		period:   period,
+		rate:     rate,
+		closed:   make(chan struct{}),
+	}
+}
+
+// Accept waits for the slow start allowance of the next connection, then accepts it.
+func (l *slowStartListener) Accept() (net.Conn, error) {
+	if wait := time.Until(l.start.Add(l.allowedAt(l.accepted))); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-l.closed:
+			timer.Stop()
+		}
+	}
+
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted++
+	}
+	return conn, err
+}
+
+// allowedAt returns when, since the start, the connection following n accepted connections can
+// be accepted. The rate growing linearly to l.rate over l.period, n connections are allowed once
+// l.rate*t²/(2*l.period) reaches n. The first connection is accepted right away.
+func (l *slowStartListener) allowedAt(n int) time.Duration {
+	if n == 0 || l.rate <= 0 {
+		return 0
+	}
+
+	at := time.Duration(math.Sqrt(2 * float64(n) * float64(l.period) / l.rate * float64(time.Second)))
+	if at > l.period {
+		return l.period
+	}
+	return at
+}
+
+// Close closes the listener, interrupting a throttled Accept.
+func (l *slowStartListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
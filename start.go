@@ -0,0 +1,177 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStartTimeout is returned by RunWithContext when the startup of the instance does not complete
+// within the start timeout, see WithStartTimeout.
+var ErrStartTimeout = errors.New("start timeout")
+
+// WithStartOrder configure servers started one stage after another, in the given order: the
+// servers of each option start once every server of the previous option is bound. For example,
+// an admin server can be bound before the public listeners.
+func WithStartOrder(opts ...Option) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		var previous []*managedServer
+		for _, o := range opts {
+			servers, err := g.applyServers(o)
+			if err != nil {
+				return nil, donothing, err
+			}
+			if len(servers) == 0 {
+				continue
+			}
+
+			for _, s := range servers {
+				s.after = append(s.after, previous...)
+			}
+			previous = servers
+		}
+
+		return nil, donothing, nil
+	})
+}
+
+// WithStartDelay configure the servers of the given option to start after the given delay.
+// Within WithStartOrder, the delay starts once the previous stage is bound.
+func WithStartDelay(delay time.Duration, opt Option) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		servers, err := g.applyServers(opt)
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		for _, s := range servers {
+			s.delay = delay
+		}
+
+		return nil, donothing, nil
+	})
+}
+
+// WithStartAfterWarmup configure the servers of the given option to start only once the warmup
+// configured by WithWarmup has completed. The warmup does not wait for these servers to be bound.
+func WithStartAfterWarmup(opt Option) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		servers, err := g.applyServers(opt)
+		if err != nil {
+			return nil, donothing, err
+		}
+
+		for _, s := range servers {
+			s.afterWarmup = true
+		}
+
+		return nil, donothing, nil
+	})
+}
+
+// WithStartTimeout configure the time allowed to the startup of the instance: if a server is not
+// bound, or the warmup is not complete, within d of the start of the run, the startup is aborted,
+// the servers already started are drained, and RunWithContext returns an error matching
+// ErrStartTimeout, rather than leaving a half-started instance running.
+func WithStartTimeout(d time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if d <= 0 {
+			return nil, donothing, errors.New("invalid start timeout")
+		}
+		g.startTimeout = d
+		return nil, donothing, nil
+	})
+}
+
+// watchStart waits for every server to be bound and the warmup to complete, and cancels the run if
+// it takes longer than the start timeout.
+func (g *Graceful) watchStart(ctx context.Context, servers []*managedServer) error {
+	timer := time.NewTimer(g.startTimeout)
+	defer timer.Stop()
+
+	for i, s := range servers {
+		select {
+		case <-s.startedChan():
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			return g.startTimedOut(fmt.Sprintf("%d of %d servers not bound", len(servers)-i, len(servers)))
+		}
+	}
+
+	g.lock.Lock()
+	warmed := g.warmed
+	g.lock.Unlock()
+	select {
+	case <-warmed:
+		return nil
+	case <-ctx.Done():
+		return nil
+	case <-timer.C:
+		return g.startTimedOut("warmup not complete")
+	}
+}
+
+// startTimedOut cancels the run whose startup timed out, and returns its error.
+func (g *Graceful) startTimedOut(pending string) error {
+	g.log().Error("start timeout", "timeout", g.startTimeout, "pending", pending)
+	g.cancelRun("start timeout")
+	return fmt.Errorf("%w: %s", ErrStartTimeout, pending)
+}
+
+// applyServers applies the given option to the Graceful instance and returns the servers it added.
+func (g *Graceful) applyServers(o Option) ([]*managedServer, error) {
+	n := len(g.servers.load())
+	if err := g.apply(o); err != nil {
+		return nil, err
+	}
+
+	return g.servers.load()[n:], nil
+}
+
+// waitStart blocks until s is allowed to start: once the servers it starts after are bound,
+// the warmup has completed if required, and its start delay has elapsed. It returns false if
+// the run is canceled or s is shut down first.
+func (g *Graceful) waitStart(ctx context.Context, s *managedServer) bool {
+	done := s.doneChan()
+
+	for _, dep := range s.after {
+		select {
+		case <-dep.startedChan():
+		case <-done:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if s.afterWarmup {
+		g.lock.Lock()
+		warmed := g.warmed
+		g.lock.Unlock()
+
+		select {
+		case <-warmed:
+		case <-done:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if s.delay > 0 {
+		timer := time.NewTimer(s.delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-done:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,119 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStartOrder(t *testing.T) {
+	router, err := Default(WithStartOrder(
+		WithAddr(":8088"),
+		WithStartDelay(200*time.Millisecond, WithAddr(":8089")),
+	))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8088/example")
+
+	statuses := router.ServerStatus()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, ServerServing, statuses[0].State)
+		assert.Equal(t, ServerIdle, statuses[1].State)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	testRequest(t, "http://localhost:8089/example")
+
+	assert.NoError(t, router.Stop())
+}
+
+func TestWithStartAfterWarmup(t *testing.T) {
+	release := make(chan struct{})
+	router, err := Default(
+		WithAddr(":8088"),
+		WithStartAfterWarmup(WithAddr(":8089")),
+		WithWarmup(func(ctx context.Context) error {
+			<-release
+			return nil
+		}, 0),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8088/example")
+	assert.Equal(t, ServerIdle, router.ServerStatus()[1].State)
+
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	testRequest(t, "http://localhost:8089/example")
+
+	assert.NoError(t, router.Stop())
+}
+
+func TestWithStartOrderShutdown(t *testing.T) {
+	router, err := Default(WithStartDelay(time.Hour, WithAddr(":8088")))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, router.Shutdown(context.Background()))
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("delayed server kept the run alive after shutdown")
+	}
+}
+
+func TestWithStartTimeout(t *testing.T) {
+	var report ShutdownReport
+	router, err := Default(
+		WithAddr("127.0.0.1:8151"),
+		WithStartDelay(time.Hour, WithAddr("127.0.0.1:8152")),
+		WithStartTimeout(100*time.Millisecond),
+		WithShutdownReportFunc(func(r ShutdownReport) { report = r }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	start := time.Now()
+	err = router.RunWithContext(context.Background())
+	assert.ErrorIs(t, err, ErrStartTimeout)
+	assert.EqualError(t, err, "start timeout: 1 of 2 servers not bound")
+	assert.Less(t, time.Since(start), time.Second)
+	// The server already started is drained.
+	assert.Equal(t, "start timeout", report.Reason)
+	assert.Equal(t, ServerStopped, router.ServerStatus()[0].State)
+
+	router, err = Default(
+		WithAddr("127.0.0.1:8151"),
+		WithWarmup(func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}, 0),
+		WithStartTimeout(100*time.Millisecond),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	assert.EqualError(t, router.RunWithContext(context.Background()), "start timeout: warmup not complete")
+
+	_, err = Default(WithStartTimeout(0))
+	assert.EqualError(t, err, "invalid start timeout")
+}
@@ -0,0 +1,98 @@
+package graceful
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// statsdInterval is the interval the gauges are sent to statsd at.
+const statsdInterval = 10 * time.Second
+
+// statsdClient sends metrics to a statsd server over UDP, with the DogStatsD tags extension.
+type statsdClient struct {
+	conn   net.Conn
+	prefix string
+}
+
+// WithStatsd configure a statsd, or DogStatsD, server at addr receiving the lifecycle metrics,
+// for the deployments not scraped by Prometheus or OpenTelemetry, every metric name being prefixed
+// with prefix and a dot, if not empty:
+//   - connections.active, every ten seconds, the number of connections with a request in flight,
+//   - shutdown, the duration of every completed shutdown, tagged with its reason,
+//   - bind, uptime and drain, the Timings of the run, with a final connections.active gauge.
+//
+// The shutdown metrics are sent before the shutdown returns, so that they are delivered before
+// the process exits. The metrics are sent over UDP, without blocking on an unreachable server.
+func WithStatsd(addr, prefix string) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			return nil, donothing, fmt.Errorf("statsd: %w", err)
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, ".") {
+			prefix += "."
+		}
+		client := &statsdClient{conn: conn, prefix: prefix}
+		g.statsd = client
+
+		stop := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(statsdInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					_ = client.gauge("connections.active", int64(g.activeConnections()))
+				}
+			}
+		}()
+
+		return nil, func() {
+			close(stop)
+			_ = conn.Close()
+		}, nil
+	})
+}
+
+// send sends one metric of the given statsd type.
+func (c *statsdClient) send(name, value, kind string, tags ...string) error {
+	metric := c.prefix + name + ":" + value + "|" + kind
+	if len(tags) > 0 {
+		metric += "|#" + strings.Join(tags, ",")
+	}
+	_, err := c.conn.Write([]byte(metric))
+	return err
+}
+
+// gauge sends a gauge.
+func (c *statsdClient) gauge(name string, v int64, tags ...string) error {
+	return c.send(name, fmt.Sprint(v), "g", tags...)
+}
+
+// timing sends a duration, in milliseconds.
+func (c *statsdClient) timing(name string, d time.Duration, tags ...string) error {
+	return c.send(name, fmt.Sprint(d.Milliseconds()), "ms", tags...)
+}
+
+// flush sends the metrics of the shutdown of g for the given reason, then the final gauges and
+// the Timings of the run.
+func (c *statsdClient) flush(g *Graceful, reason string, d time.Duration) error {
+	timings := g.Timings()
+	if err := c.timing("shutdown", d, "reason:"+strings.ReplaceAll(reason, " ", "_")); err != nil {
+		return err
+	}
+	if err := c.gauge("connections.active", int64(g.activeConnections())); err != nil {
+		return err
+	}
+	if err := c.timing("bind", timings.Bind); err != nil {
+		return err
+	}
+	if err := c.timing("uptime", timings.Uptime); err != nil {
+		return err
+	}
+	return c.timing("drain", timings.Drain)
+}
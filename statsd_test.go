@@ -0,0 +1,50 @@
+package graceful
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	router, err := Default(WithAddr("127.0.0.1:8126"), WithStatsd(conn.LocalAddr().String(), "app"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, router.Shutdown(context.Background()))
+
+	var metrics []string
+	buf := make([]byte, 512)
+	for len(metrics) < 5 {
+		assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+		name, _, _ := strings.Cut(string(buf[:n]), ":")
+		metrics = append(metrics, name)
+	}
+	assert.Equal(t, []string{"app.shutdown", "app.connections.active", "app.bind", "app.uptime", "app.drain"}, metrics)
+}
+
+func TestStatsdSend(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	c := &statsdClient{conn: client, prefix: "app."}
+
+	go func() { _ = c.timing("shutdown", 1500*time.Millisecond, "reason:context_done") }()
+	buf := make([]byte, 512)
+	n, err := server.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "app.shutdown:1500|ms|#reason:context_done", string(buf[:n]))
+}
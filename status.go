@@ -0,0 +1,380 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ServerState is the lifecycle state of a server managed by the Graceful instance.
+type ServerState int
+
+const (
+	// ServerIdle means the server has not been started yet.
+	ServerIdle ServerState = iota
+	// ServerBound means the server listener is bound but not accepting connections yet.
+	ServerBound
+	// ServerServing means the server is accepting connections.
+	ServerServing
+	// ServerDraining means the server is shutting down and waiting for active connections.
+	ServerDraining
+	// ServerStopped means the server has been shut down.
+	ServerStopped
+	// ServerFailed means the server stopped with an unexpected error.
+	ServerFailed
+)
+
+// String returns the name of the server state.
+func (s ServerState) String() string {
+	switch s {
+	case ServerIdle:
+		return "idle"
+	case ServerBound:
+		return "bound"
+	case ServerServing:
+		return "serving"
+	case ServerDraining:
+		return "draining"
+	case ServerStopped:
+		return "stopped"
+	case ServerFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// ServerStatus describes the state of one server managed by the Graceful instance.
+type ServerStatus struct {
+	// Name is the component name given with WithComponent or WithRunner, if any.
+	Name string
+	// Label is the label given with Labeled, if any.
+	Label string
+	// Addr is the address the server listens on, once its listener is bound.
+	Addr string
+	// TLS reports whether the server serves TLS, once it is bound.
+	TLS bool
+	// State is the current state of the server.
+	State ServerState
+	// Err is the last error the server stopped with, if any.
+	Err error
+	// BytesRead and BytesWritten are the bytes read from and written to the connections of the
+	// server, with WithTrafficStats.
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// ServerStatus returns the status of every server managed by the Graceful instance, in the
+// order the servers were configured.
+func (g *Graceful) ServerStatus() []ServerStatus {
+	servers := g.servers.load()
+
+	statuses := make([]ServerStatus, 0, len(servers))
+	for _, s := range servers {
+		statuses = append(statuses, s.status())
+	}
+
+	return statuses
+}
+
+// stopper is implemented by the servers and runners tracked by a managedServer.
+type stopper interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// managedServer tracks one server configured on the Graceful instance, across runs and restarts.
+type managedServer struct {
+	run listenAndServe
+	// release runs the cleanup of the option which configured the server, once, see
+	// WithCleanupOnShutdown.
+	release func()
+
+	// name and dependsOn identify the server as a component, see WithComponent.
+	name      string
+	dependsOn []string
+	// tag is the label of the server, see Labeled.
+	tag string
+	// tls reports whether the server serves TLS, recorded by serveTLS.
+	tls bool
+
+	// after, delay and afterWarmup gate the start of the server, see waitStart.
+	after       []*managedServer
+	delay       time.Duration
+	afterWarmup bool
+
+	mu         sync.Mutex
+	srv        stopper
+	closed     bool
+	done       chan struct{}
+	started    chan struct{}
+	hasStarted bool
+	addr       string
+	state      ServerState
+	err        error
+	active     connSet
+
+	// traffic counts the traffic of the server, once served with WithTrafficStats.
+	traffic atomic.Pointer[traffic]
+	// open counts the open connections of the server, once conns reports that its ConnState
+	// hook records them, see recordConnState.
+	open  atomic.Int64
+	conns atomic.Bool
+}
+
+// reset prepares the server for a new run.
+func (s *managedServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = false
+	s.done = make(chan struct{})
+	s.state = ServerIdle
+	s.started = make(chan struct{})
+	s.hasStarted = false
+}
+
+// startedChan returns a channel closed once the server listener is bound in the current run,
+// or once the server stopped without binding.
+func (s *managedServer) startedChan() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.started
+}
+
+// doneChan returns a channel closed once the server is shut down in the current run.
+func (s *managedServer) doneChan() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.done
+}
+
+// markStarted closes the channel returned by startedChan, if not already closed.
+// The caller must hold s.mu.
+func (s *managedServer) markStarted() {
+	if s.started != nil && !s.hasStarted {
+		close(s.started)
+		s.hasStarted = true
+	}
+}
+
+// setServer records the http.Server, or runner, currently serving. If the server has already
+// been shut down, srv is closed right away so that it does not start serving after the shutdown.
+func (s *managedServer) setServer(srv stopper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.srv = srv
+	if s.closed {
+		_ = srv.Close()
+	}
+}
+
+// bound records that the server listener is bound to addr.
+func (s *managedServer) bound(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == ServerDraining || s.state == ServerStopped {
+		return
+	}
+
+	s.addr = addr
+	s.state = ServerBound
+	s.markStarted()
+}
+
+// serving records that the server accepts connections.
+func (s *managedServer) serving() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == ServerBound {
+		s.state = ServerServing
+	}
+}
+
+// stopped records the error the server stopped serving with.
+func (s *managedServer) stopped(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.markStarted()
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		if s.state == ServerBound || s.state == ServerServing {
+			s.state = ServerStopped
+		}
+		return
+	}
+
+	s.state = ServerFailed
+	s.err = err
+}
+
+// running reports whether a http.Server, or runner, is currently serving.
+func (s *managedServer) running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.srv != nil && !s.closed
+}
+
+// shutdown gracefully shuts down the http.Server currently serving, if any. It reports whether
+// a server was running.
+func (s *managedServer) shutdown(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	if !s.closed && s.done != nil {
+		close(s.done)
+	}
+	s.closed = true
+	srv := s.srv
+	s.srv = nil
+	draining := s.state == ServerBound || s.state == ServerServing
+	if draining {
+		s.state = ServerDraining
+	}
+	s.mu.Unlock()
+
+	if srv == nil {
+		return false, nil
+	}
+
+	err := srv.Shutdown(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if draining {
+		s.state = ServerStopped
+	}
+	if err != nil {
+		s.err = err
+	}
+
+	return true, err
+}
+
+// retire shuts down the http.Server of a failed run before the server is restarted, so that its
+// connections are not orphaned: they are waited for up to d, then closed.
+func (s *managedServer) retire(d time.Duration) {
+	s.mu.Lock()
+	srv := s.srv
+	s.srv = nil
+	s.mu.Unlock()
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		_ = srv.Close()
+	}
+}
+
+// close immediately closes the http.Server currently serving, if any, and its active
+// connections. It reports whether a server was running.
+func (s *managedServer) close() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed && s.done != nil {
+		close(s.done)
+	}
+	s.closed = true
+	srv := s.srv
+	s.srv = nil
+	if srv == nil {
+		return false, nil
+	}
+
+	if s.state == ServerBound || s.state == ServerServing {
+		s.state = ServerStopped
+	}
+	err := srv.Close()
+	if err != nil {
+		s.err = err
+	}
+
+	return true, err
+}
+
+// serveTLS records that the server serves TLS, then serves srv with TLS on l.
+func (s *managedServer) serveTLS(srv *http.Server, l net.Listener) error {
+	s.mu.Lock()
+	s.tls = true
+	s.mu.Unlock()
+
+	return srv.ServeTLS(l, "", "")
+}
+
+// label identifies the server in reports: its component name, its label, or its address.
+func (s *managedServer) label() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.name != "" {
+		return s.name
+	}
+	if s.tag != "" {
+		return s.tag
+	}
+	return s.addr
+}
+
+// trackConn returns an http.Server ConnState hook counting the connections of the server with a
+// request in flight, then calling next, if any.
+func (s *managedServer) trackConn(next func(net.Conn, http.ConnState)) func(net.Conn, http.ConnState) {
+	return func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateActive:
+			s.active.add(conn)
+		case http.StateNew, http.StateIdle, http.StateHijacked, http.StateClosed:
+			s.active.remove(conn)
+		}
+
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// activeConnections returns the number of connections of the server with a request in flight.
+func (s *managedServer) activeConnections() int {
+	return s.active.len()
+}
+
+// status returns a snapshot of the server status.
+func (s *managedServer) status() ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return ServerStatus{
+		Name:         s.name,
+		Label:        s.tag,
+		Addr:         s.addr,
+		TLS:          s.tls,
+		State:        s.state,
+		Err:          s.err,
+		BytesRead:    s.traffic.Load().read(),
+		BytesWritten: s.traffic.Load().written(),
+	}
+}
+
+// statusListener marks its server as serving once the accept loop is running.
+type statusListener struct {
+	net.Listener
+	server *managedServer
+	once   sync.Once
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *statusListener) Accept() (net.Conn, error) {
+	l.once.Do(l.server.serving)
+	return l.Listener.Accept()
+}
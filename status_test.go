@@ -0,0 +1,115 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestServerStatus(t *testing.T) {
+	fail := make(chan struct{})
+	router, err := Default(
+		WithAddr(":8084"),
+		withFlakyAddr(":8085", 1, func() error {
+			<-fail
+			return errors.New("transient")
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	statuses := router.ServerStatus()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, ServerIdle, statuses[0].State)
+		assert.Equal(t, ServerIdle, statuses[1].State)
+	}
+
+	release := make(chan struct{})
+	router.GET("/example", func(c *gin.Context) {
+		<-release
+		c.String(http.StatusOK, "it worked")
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- router.RunWithContext(context.Background())
+	}()
+
+	requested := make(chan struct{})
+	go func() {
+		defer close(requested)
+		testRequest(t, "http://localhost:8084/example")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	statuses = router.ServerStatus()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, ServerServing, statuses[0].State)
+		assert.Contains(t, statuses[0].Addr, ":8084")
+		assert.NoError(t, statuses[0].Err)
+		assert.Equal(t, ServerIdle, statuses[1].State)
+	}
+
+	// The failure of the second server shuts down the first one, which drains its request.
+	close(fail)
+	time.Sleep(100 * time.Millisecond)
+
+	statuses = router.ServerStatus()
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, ServerDraining, statuses[0].State)
+		assert.Equal(t, ServerFailed, statuses[1].State)
+		assert.EqualError(t, statuses[1].Err, "transient")
+	}
+
+	close(release)
+	<-requested
+	assert.EqualError(t, <-done, "transient")
+	assert.Equal(t, ServerStopped, router.ServerStatus()[0].State)
+}
+
+func TestServerStateString(t *testing.T) {
+	assert.Equal(t, "idle", ServerIdle.String())
+	assert.Equal(t, "bound", ServerBound.String())
+	assert.Equal(t, "serving", ServerServing.String())
+	assert.Equal(t, "draining", ServerDraining.String())
+	assert.Equal(t, "stopped", ServerStopped.String())
+	assert.Equal(t, "failed", ServerFailed.String())
+	assert.Equal(t, "unknown", ServerState(-1).String())
+}
+
+func TestLabeled(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	var reports []ShutdownReport
+	router, err := Default(
+		Labeled("admin", WithAddr("127.0.0.1:8158")),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithShutdownReportFunc(func(r ShutdownReport) { reports = append(reports, r) }),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.Equal(t, "admin", router.ServerStatus()[0].Label)
+	reachable(t, "http://127.0.0.1:8158/")
+	assert.NoError(t, router.Stop())
+
+	if sum, ok := collect(t, reader)["graceful.listener.accepted"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		label, _ := sum.DataPoints[0].Attributes.Value("label")
+		assert.Equal(t, "admin", label.AsString())
+	}
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "drain admin", reports[0].Phases[0].Name)
+	}
+
+	_, err = Default(Labeled("", WithAddr(":8080")))
+	assert.EqualError(t, err, "empty listener label")
+}
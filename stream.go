@@ -0,0 +1,99 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithStreamCutoff configure the grace period given to the streaming responses, the ones whose
+// handler flushed a part of the response, once the drain starts. After it, each stream is
+// flushed, the context of its request is canceled and everything its handler writes is
+// discarded, so that the response ends with a well-formed final chunk once the handler returns
+// instead of being cut when the shutdown times out.
+func WithStreamCutoff(grace time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.streamCutoff = grace
+		return nil, donothing, nil
+	})
+}
+
+// serveStream serves a request whose response is cut off if it is still streaming once the
+// stream grace period has elapsed after the drain started.
+func (g *Graceful) serveStream(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	sw := &streamWriter{cancelableWriter: cancelableWriter{ResponseWriter: w, cancel: cancel}}
+	g.lock.Lock()
+	if g.streams == nil {
+		g.streams = map[*streamWriter]struct{}{}
+	}
+	g.streams[sw] = struct{}{}
+	g.lock.Unlock()
+
+	defer func() {
+		g.lock.Lock()
+		delete(g.streams, sw)
+		g.lock.Unlock()
+	}()
+
+	g.serveEngine(sw, req.WithContext(ctx))
+}
+
+// scheduleStreamCutoff cuts off the streaming responses once the stream grace period has
+// elapsed.
+func (g *Graceful) scheduleStreamCutoff() {
+	if g.streamCutoff <= 0 {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.streamTimer != nil {
+		g.streamTimer.Stop()
+	}
+	g.streamTimer = time.AfterFunc(g.streamCutoff, func() {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+
+		for sw := range g.streams {
+			if sw.streaming() {
+				sw.cutoff()
+			}
+		}
+	})
+}
+
+// streamWriter is the http.ResponseWriter of a request whose response may be cut off.
+type streamWriter struct {
+	cancelableWriter
+
+	flushMu sync.Mutex
+	flushed bool
+}
+
+// Flush sends the buffered response and marks the response as streaming.
+func (w *streamWriter) Flush() {
+	w.flushMu.Lock()
+	w.flushed = true
+	w.flushMu.Unlock()
+
+	w.cancelableWriter.Flush()
+}
+
+// streaming reports whether the handler flushed a part of the response.
+func (w *streamWriter) streaming() bool {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	return w.flushed
+}
+
+// cutoff flushes what was written so far, then cancels the request.
+func (w *streamWriter) cutoff() {
+	w.cancelableWriter.Flush()
+	w.cancelRequest()
+}
@@ -0,0 +1,65 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStreamCutoff(t *testing.T) {
+	router, err := Default(WithAddr(":8111"), WithStreamCutoff(50*time.Millisecond))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/stream", func(c *gin.Context) {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+				_, _ = io.WriteString(w, "event\n")
+				return true
+			}
+		})
+	})
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	go func() {
+		assert.NoError(t, router.RunWithContext(context.Background()))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	testRequest(t, "http://localhost:8111/example")
+
+	streamed := make(chan string)
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8111/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			streamed <- ""
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		streamed <- string(body)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+
+	body := <-streamed
+	assert.True(t, strings.HasPrefix(body, "event\n"))
+	assert.Equal(t, strings.Repeat("event\n", strings.Count(body, "\n")), body)
+}
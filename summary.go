@@ -0,0 +1,36 @@
+package graceful
+
+import (
+	"context"
+	"time"
+)
+
+// WithStartupSummary configure the instance to log a summary of every run once all its listeners
+// are bound: the address of each server, whether it serves TLS, and the number of routes served.
+// Unlike the debug print of gin, which lists the routes as they are registered, the summary is
+// only logged once the instance actually serves, see WithLogger.
+func WithStartupSummary() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.startupSummary = true
+		return nil, donothing, nil
+	})
+}
+
+// logStartupSummary logs the startup summary of the run started at start, once every server is
+// bound, unless ctx is done first.
+func (g *Graceful) logStartupSummary(ctx context.Context, start time.Time, servers []*managedServer) {
+	for _, s := range servers {
+		select {
+		case <-s.startedChan():
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	for _, s := range servers {
+		status := s.status()
+		g.log().Info("server bound", serverAttrs(s, "addr", status.Addr, "tls", status.TLS)...)
+	}
+	g.log().Info("startup complete", "servers", len(servers), "routes", len(g.currentEngine().Routes()),
+		"duration", time.Since(start))
+}
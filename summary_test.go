@@ -0,0 +1,42 @@
+package graceful
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithStartupSummary(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	router, err := Default(
+		WithAddr("127.0.0.1:8159"),
+		Labeled("secure", WithTLS("127.0.0.1:8160", "./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")),
+		WithStartupSummary(),
+		WithLogger(ZapLogger(zap.New(core))),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/example", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return logs.FilterMessage("startup complete").Len() == 1 },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Stop())
+
+	bound := logs.FilterMessage("server bound").All()
+	if assert.Len(t, bound, 2) {
+		assert.Equal(t, "127.0.0.1:8159", bound[0].ContextMap()["addr"])
+		assert.Equal(t, false, bound[0].ContextMap()["tls"])
+		assert.Equal(t, "secure", bound[1].ContextMap()["server"])
+		assert.Equal(t, true, bound[1].ContextMap()["tls"])
+	}
+	summary := logs.FilterMessage("startup complete").All()[0].ContextMap()
+	assert.Equal(t, int64(2), summary["servers"])
+	assert.Equal(t, int64(2), summary["routes"])
+}
@@ -0,0 +1,118 @@
+// Package tailscale serves a graceful.Graceful instance on a Tailscale node, so that internal
+// tools are only reachable from the tailnet.
+package tailscale
+
+import (
+	"net"
+	"sync"
+
+	"github.com/gin-contrib/graceful"
+	"tailscale.com/tsnet"
+)
+
+// Option configures the node created by WithTailscale.
+type Option func(*tsnet.Server)
+
+// WithDir configure the directory holding the state of the node.
+func WithDir(dir string) Option {
+	return func(s *tsnet.Server) {
+		s.Dir = dir
+	}
+}
+
+// WithAuthKey configure the auth key used to join the node to the tailnet. Without it, the
+// TS_AUTHKEY environment variable is used, or a login URL is logged.
+func WithAuthKey(key string) Option {
+	return func(s *tsnet.Server) {
+		s.AuthKey = key
+	}
+}
+
+// WithEphemeral configure the node to be removed from the tailnet once it goes offline.
+func WithEphemeral() Option {
+	return func(s *tsnet.Server) {
+		s.Ephemeral = true
+	}
+}
+
+// WithTailscale configure a http.Server to serve HTTP requests on port 80 of a Tailscale node
+// with the given hostname. The node is started along with the servers of the Graceful instance,
+// and shut down once they are.
+func WithTailscale(hostname string, opts ...Option) graceful.Option {
+	s := &tsnet.Server{Hostname: hostname}
+	for _, o := range opts {
+		o(s)
+	}
+
+	return graceful.WithListener(newNodeListener(s, hostname, ":80"))
+}
+
+// node is the part of tsnet.Server used by nodeListener.
+type node interface {
+	Listen(network, addr string) (net.Listener, error)
+	Close() error
+}
+
+// nodeListener is a net.Listener starting its node on the first Accept, and shutting it
+// down on Close.
+type nodeListener struct {
+	node     node
+	hostname string
+	port     string
+
+	startOnce sync.Once
+	l         net.Listener
+	err       error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newNodeListener returns a listener on the given port of node.
+func newNodeListener(n node, hostname, port string) *nodeListener {
+	return &nodeListener{node: n, hostname: hostname, port: port}
+}
+
+// start starts the node and its listener, once.
+func (l *nodeListener) start() (net.Listener, error) {
+	l.startOnce.Do(func() {
+		l.l, l.err = l.node.Listen("tcp", l.port)
+	})
+	return l.l, l.err
+}
+
+// Accept starts the node, if not started yet, then waits for and returns the next connection.
+func (l *nodeListener) Accept() (net.Conn, error) {
+	ln, err := l.start()
+	if err != nil {
+		return nil, err
+	}
+	return ln.Accept()
+}
+
+// Close closes the listener and shuts down the node.
+func (l *nodeListener) Close() error {
+	l.closeOnce.Do(func() {
+		// A listener closed before the node started must not start it afterward.
+		l.startOnce.Do(func() { l.err = net.ErrClosed })
+		if l.l != nil {
+			_ = l.l.Close()
+		}
+		l.closeErr = l.node.Close()
+	})
+	return l.closeErr
+}
+
+// Addr returns the address of the listener, the hostname of the node and its port.
+func (l *nodeListener) Addr() net.Addr {
+	return nodeAddr(l.hostname + l.port)
+}
+
+// nodeAddr is the net.Addr of a nodeListener.
+type nodeAddr string
+
+// Network returns the network of the address.
+func (a nodeAddr) Network() string { return "tcp" }
+
+// String returns the address.
+func (a nodeAddr) String() string { return string(a) }
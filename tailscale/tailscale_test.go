@@ -0,0 +1,70 @@
+package tailscale
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNode struct {
+	listens int
+	closed  bool
+	l       net.Listener
+	err     error
+}
+
+func (n *fakeNode) Listen(network, addr string) (net.Listener, error) {
+	n.listens++
+	return n.l, n.err
+}
+
+func (n *fakeNode) Close() error {
+	n.closed = true
+	return nil
+}
+
+func TestNodeListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	n := &fakeNode{l: inner}
+	l := newNodeListener(n, "tools", ":80")
+
+	assert.Equal(t, "tools:80", l.Addr().String())
+	assert.Zero(t, n.listens)
+
+	client, err := net.Dial("tcp", inner.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, 1, n.listens)
+
+	assert.NoError(t, l.Close())
+	assert.True(t, n.closed)
+	_, err = l.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestNodeListenerStartError(t *testing.T) {
+	n := &fakeNode{err: errors.New("no auth key")}
+	l := newNodeListener(n, "tools", ":80")
+
+	_, err := l.Accept()
+	assert.EqualError(t, err, "no auth key")
+	assert.NoError(t, l.Close())
+}
+
+func TestNodeListenerClosedBeforeStart(t *testing.T) {
+	n := &fakeNode{}
+	l := newNodeListener(n, "tools", ":80")
+
+	assert.NoError(t, l.Close())
+	_, err := l.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.Zero(t, n.listens)
+	assert.True(t, n.closed)
+}
@@ -0,0 +1,73 @@
+package graceful
+
+import (
+	"context"
+	"time"
+)
+
+// Timings holds the measured durations of the last run of the Graceful instance.
+type Timings struct {
+	// Bind is the time from the start of the run until every listener was bound.
+	Bind time.Duration
+	// Uptime is the time every server has been serving, until the shutdown started.
+	Uptime time.Duration
+	// Drain is the time the last shutdown took to drain every server.
+	Drain time.Duration
+	// Hooks is the time the shutdown hooks of the last shutdown took, before and after the drain.
+	Hooks time.Duration
+	// Cleanup is the time the cleanup of Close took.
+	Cleanup time.Duration
+}
+
+// Timings returns the measured durations of the last run, so that they can be emitted to
+// telemetry. The durations of the steps not reached yet are zero, except for Uptime which
+// grows while serving.
+func (g *Graceful) Timings() Timings {
+	g.timingsLock.Lock()
+	defer g.timingsLock.Unlock()
+
+	timings := g.timings
+	if !g.servingSince.IsZero() {
+		timings.Uptime = time.Since(g.servingSince)
+	}
+	return timings
+}
+
+// measureBind records the bind duration of the run started at start, once every server is bound.
+func (g *Graceful) measureBind(ctx context.Context, start time.Time, servers []*managedServer) {
+	for _, s := range servers {
+		select {
+		case <-s.startedChan():
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	g.timingsLock.Lock()
+	defer g.timingsLock.Unlock()
+
+	g.timings.Bind = time.Since(start)
+	g.servingSince = time.Now()
+}
+
+// measureShutdown records the drain and hooks durations of the shutdown started at start, and
+// stops the uptime.
+func (g *Graceful) measureShutdown(start time.Time, drain, hooks time.Duration) {
+	g.timingsLock.Lock()
+	defer g.timingsLock.Unlock()
+
+	if !g.servingSince.IsZero() {
+		g.timings.Uptime = start.Sub(g.servingSince)
+		g.servingSince = time.Time{}
+	}
+	g.timings.Drain = drain
+	g.timings.Hooks = hooks
+}
+
+// measureCleanup records the cleanup duration of Close.
+func (g *Graceful) measureCleanup(d time.Duration) {
+	g.timingsLock.Lock()
+	defer g.timingsLock.Unlock()
+
+	g.timings.Cleanup = d
+}
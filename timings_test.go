@@ -0,0 +1,43 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimings(t *testing.T) {
+	router, err := Default(WithAddr(":8098"), WithBeforeShutdown("slow", func(context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}))
+	assert.NoError(t, err)
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8098/example")
+
+	timings := router.Timings()
+	assert.Positive(t, timings.Bind)
+	assert.Positive(t, timings.Uptime)
+	assert.Zero(t, timings.Drain)
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Greater(t, router.Timings().Uptime, timings.Uptime)
+
+	assert.NoError(t, router.Stop())
+	router.Close()
+
+	timings = router.Timings()
+	assert.Positive(t, timings.Drain)
+	// The drain excludes the hooks, timed on their own.
+	assert.GreaterOrEqual(t, timings.Hooks, 50*time.Millisecond)
+	assert.Less(t, timings.Drain, timings.Hooks)
+	assert.Equal(t, timings.Uptime, router.Timings().Uptime)
+	assert.GreaterOrEqual(t, timings.Cleanup, time.Duration(0))
+}
@@ -0,0 +1,84 @@
+package graceful
+
+import (
+	"net"
+)
+
+// WithTrafficStats configure the Graceful instance to count the bytes read from and written to the
+// connections of every listener, reported by ServerStatus, the admin server and, with
+// WithMeterProvider, the graceful.listener.bytes_read and graceful.listener.bytes_written metrics,
+// e.g. for capacity planning or to check that a drain really stopped the traffic. The bytes are
+// counted on the wire, before the TLS decryption, and over the lifetime of the instance.
+func WithTrafficStats() Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.trafficStats = true
+		return nil, donothing, nil
+	})
+}
+
+// traffic counts the bytes read from and written to the connections of a server, sharded as they
+// are updated by every read and write.
+type traffic struct {
+	bytesRead    counter
+	bytesWritten counter
+}
+
+// read returns the bytes read, 0 if t is nil.
+func (t *traffic) read() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.bytesRead.load()
+}
+
+// written returns the bytes written, 0 if t is nil.
+func (t *traffic) written() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.bytesWritten.load()
+}
+
+// trafficListener counts the bytes read from and written to the connections it accepts.
+type trafficListener struct {
+	net.Listener
+	traffic *traffic
+}
+
+// Accept waits for and returns the next connection, counting its traffic.
+func (l *trafficListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &trafficConn{Conn: conn, traffic: l.traffic}, nil
+}
+
+// trafficConn counts the bytes read from and written to a connection.
+type trafficConn struct {
+	net.Conn
+	traffic *traffic
+}
+
+// Read reads from the connection, counting the bytes read.
+func (c *trafficConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.traffic.bytesRead.add(int64(n))
+	}
+	return n, err
+}
+
+// Write writes to the connection, counting the bytes written.
+func (c *trafficConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.traffic.bytesWritten.add(int64(n))
+	}
+	return n, err
+}
+
+// Unwrap returns the wrapped connection.
+func (c *trafficConn) Unwrap() net.Conn {
+	return c.Conn
+}
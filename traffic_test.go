@@ -0,0 +1,43 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTrafficStats(t *testing.T) {
+	router, err := Default(WithAddr("127.0.0.1:8145"), WithTrafficStats(), WithTCPKeepAlive(time.Minute))
+	assert.NoError(t, err)
+	defer router.Close()
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, strings.Repeat(string(body), 10))
+	})
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:8145/echo",
+		strings.NewReader(strings.Repeat("a", 1000)))
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	assert.NoError(t, router.Stop())
+
+	status := router.ServerStatus()[0]
+	assert.Greater(t, status.BytesRead, int64(1000))
+	assert.Less(t, status.BytesRead, int64(2000))
+	assert.Greater(t, status.BytesWritten, int64(10000))
+	assert.Less(t, status.BytesWritten, int64(11000))
+}
@@ -0,0 +1,117 @@
+// Package tunnel serves a graceful.Graceful instance through a tunnel, such as ngrok or
+// Cloudflare Tunnel, established and torn down along with the servers of the instance.
+package tunnel
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/gin-contrib/graceful"
+)
+
+// Factory establishes a tunnel and returns the listener accepting its connections. Closing the
+// listener must tear down the tunnel. The context is canceled once the tunnel is torn down, or
+// if the Graceful instance shuts down while the tunnel is being established.
+type Factory func(ctx context.Context) (net.Listener, error)
+
+// Listener is a net.Listener establishing its tunnel on the first Accept and tearing it down on
+// Close. A closed Listener establishes a new tunnel on the next Accept, so that it can serve a
+// new run of the Graceful instance. Accept must not be called concurrently, as http.Server does.
+type Listener struct {
+	factory Factory
+
+	mu     sync.Mutex
+	l      net.Listener
+	cancel context.CancelFunc
+}
+
+// WithTunnel configure a http.Server to serve HTTP requests through the tunnel established by
+// factory when the Graceful instance starts. The tunnel is torn down when it shuts down.
+func WithTunnel(factory Factory) graceful.Option {
+	return graceful.WithListener(New(factory))
+}
+
+// New returns a Listener establishing its tunnel with factory.
+func New(factory Factory) *Listener {
+	return &Listener{factory: factory}
+}
+
+// Accept establishes the tunnel, if not established yet, then waits for and returns the next
+// connection.
+func (t *Listener) Accept() (net.Conn, error) {
+	l, err := t.establish()
+	if err != nil {
+		return nil, err
+	}
+	return l.Accept()
+}
+
+// establish returns the listener of the tunnel, establishing the tunnel if needed.
+func (t *Listener) establish() (net.Listener, error) {
+	t.mu.Lock()
+	if t.l != nil {
+		defer t.mu.Unlock()
+		return t.l, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.mu.Unlock()
+
+	l, err := t.factory(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ctx.Err() != nil {
+		// The listener was closed while establishing the tunnel.
+		if l != nil {
+			_ = l.Close()
+		}
+		return nil, net.ErrClosed
+	}
+	if err != nil {
+		cancel()
+		t.cancel = nil
+		return nil, err
+	}
+
+	t.l = l
+	return l, nil
+}
+
+// Close tears down the tunnel, or aborts its establishment.
+func (t *Listener) Close() error {
+	t.mu.Lock()
+	l, cancel := t.l, t.cancel
+	t.l, t.cancel = nil, nil
+	t.mu.Unlock()
+
+	if cancel != nil {
+		defer cancel()
+	}
+	if l == nil {
+		return nil
+	}
+	return l.Close()
+}
+
+// Addr returns the address of the tunnel listener once established, or a placeholder address.
+func (t *Listener) Addr() net.Addr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.l != nil {
+		return t.l.Addr()
+	}
+	return addr{}
+}
+
+// addr is the address of a Listener whose tunnel is not established.
+type addr struct{}
+
+// Network returns the network of the address.
+func (addr) Network() string { return "tunnel" }
+
+// String returns the address.
+func (addr) String() string { return "tunnel" }
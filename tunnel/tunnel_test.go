@@ -0,0 +1,82 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/graceful"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTunnel(t *testing.T) {
+	var established atomic.Int32
+	var endpoint atomic.Value
+	router, err := graceful.New(gin.New(), WithTunnel(func(ctx context.Context) (net.Listener, error) {
+		established.Add(1)
+		l, err := net.Listen("tcp", "localhost:0")
+		if err == nil {
+			endpoint.Store(l.Addr().String())
+		}
+		return l, err
+	}))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	for run := 1; run <= 2; run++ {
+		assert.NoError(t, router.Start())
+		time.Sleep(10 * time.Millisecond)
+
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://"+endpoint.Load().(string)+"/example", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if assert.NoError(t, err) {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, "it worked", string(body))
+		}
+
+		assert.NoError(t, router.Stop())
+		assert.Equal(t, int32(run), established.Load())
+
+		_, err = net.Dial("tcp", endpoint.Load().(string))
+		assert.Error(t, err, "the tunnel must be torn down")
+	}
+}
+
+func TestListenerCloseWhileEstablishing(t *testing.T) {
+	canceled := make(chan struct{})
+	l := New(func(ctx context.Context) (net.Listener, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+
+	accepted := make(chan error)
+	go func() {
+		_, err := l.Accept()
+		accepted <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, "tunnel", l.Addr().String())
+	assert.NoError(t, l.Close())
+	<-canceled
+	assert.ErrorIs(t, <-accepted, net.ErrClosed)
+}
+
+func TestListenerEstablishError(t *testing.T) {
+	l := New(func(ctx context.Context) (net.Listener, error) {
+		return nil, io.ErrUnexpectedEOF
+	})
+
+	_, err := l.Accept()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.NoError(t, l.Close())
+}
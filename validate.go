@@ -0,0 +1,99 @@
+package graceful
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+)
+
+// validateAddr checks that addr is a valid TCP address, i.e. an optional host and a port number
+// or service name. An empty address is valid, the option falling back to a default address.
+func validateAddr(addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if _, err := net.LookupPort("tcp", port); err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+
+	return nil
+}
+
+// configuredListener is a listener address configured on the Graceful instance.
+type configuredListener struct {
+	network string
+	addr    string
+}
+
+// claimTCP records the TCP address addr, or fallback if empty, as configured on the Graceful
+// instance. It fails if the address conflicts with a previously configured one.
+func (g *Graceful) claimTCP(addr, fallback string) error {
+	if addr == "" {
+		addr = fallback
+	}
+	return g.claimListener(configuredListener{network: "tcp", addr: addr})
+}
+
+// claimUnix records the unix socket path as configured on the Graceful instance. It fails if
+// the path is already configured.
+func (g *Graceful) claimUnix(path string) error {
+	return g.claimListener(configuredListener{network: "unix", addr: filepath.Clean(path)})
+}
+
+// claimListener records l as configured, unless it conflicts with a configured listener.
+func (g *Graceful) claimListener(l configuredListener) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, used := range g.listeners {
+		if used.conflicts(l) {
+			return fmt.Errorf("duplicate listener: %s address %q conflicts with %q", l.network, l.addr, used.addr)
+		}
+	}
+
+	g.listeners = append(g.listeners, l)
+	return nil
+}
+
+// conflicts reports whether l and other cannot be bound together: the same unix socket path,
+// or the same TCP port on the same host or on a wildcard host. Port 0 never conflicts.
+func (l configuredListener) conflicts(other configuredListener) bool {
+	if l.network != other.network {
+		return false
+	}
+	if l.network == "unix" {
+		return l.addr == other.addr
+	}
+
+	host, port, err := splitTCPAddr(l.addr)
+	if err != nil || port == 0 {
+		return false
+	}
+	otherHost, otherPort, err := splitTCPAddr(other.addr)
+	if err != nil || port != otherPort {
+		return false
+	}
+
+	return isWildcardHost(host) || isWildcardHost(otherHost) || strings.EqualFold(host, otherHost)
+}
+
+// splitTCPAddr splits the TCP address addr into its host and port number.
+func splitTCPAddr(addr string) (string, int, error) {
+	host, service, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := net.LookupPort("tcp", service)
+	return host, port, err
+}
+
+// isWildcardHost reports whether host listens on every interface.
+func isWildcardHost(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::"
+}
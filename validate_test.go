@@ -0,0 +1,127 @@
+package graceful
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEagerValidation(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("./testdata/certificate/cert.pem", "./testdata/certificate/key.pem")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opt  Option
+		err  string
+	}{
+		{
+			name: "missing port",
+			opt:  WithAddr("localhost"),
+			err:  `invalid address "localhost": address localhost: missing port in address`,
+		},
+		{
+			name: "invalid port",
+			opt:  WithAddr(":99999"),
+			err:  `invalid address ":99999": address 99999: invalid port`,
+		},
+		{
+			name: "unknown service",
+			opt:  WithTLSCertificates(":nope", cert),
+			err:  `invalid address ":nope": lookup tcp/nope: unknown port`,
+		},
+		{
+			name: "server address",
+			opt:  WithServer(&http.Server{Addr: "bad"}),
+			err:  `invalid address "bad": address bad: missing port in address`,
+		},
+		{
+			name: "admin address",
+			opt:  WithAdmin(AdminConfig{Addr: "bad", Token: "token"}),
+			err:  `invalid address "bad": address bad: missing port in address`,
+		},
+		{
+			name: "missing certificate",
+			opt:  WithTLS(":8443", "./testdata/certificate/missing.pem", "./testdata/certificate/key.pem"),
+			err:  "tls certificate: open ./testdata/certificate/missing.pem: no such file or directory",
+		},
+		{
+			name: "mismatched key",
+			opt:  WithTLS(":8443", "./testdata/certificate/cert.pem", "./testdata/certificate/example-key.pem"),
+			err:  "tls certificate: tls: private key does not match public key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, tt.opt)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestEagerValidationValidAddresses(t *testing.T) {
+	for _, addr := range []string{"", ":8080", "localhost:http", "[::1]:443", "127.0.0.1:0"} {
+		assert.NoError(t, validateAddr(addr), addr)
+	}
+}
+
+func TestDuplicateListeners(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		err  string
+	}{
+		{
+			name: "same address",
+			opts: []Option{WithAddr(":8105"), WithAddr(":8105")},
+			err:  `duplicate listener: tcp address ":8105" conflicts with ":8105"`,
+		},
+		{
+			name: "wildcard host",
+			opts: []Option{WithAddr("localhost:8105"), WithAddr("0.0.0.0:8105")},
+			err:  `duplicate listener: tcp address "0.0.0.0:8105" conflicts with "localhost:8105"`,
+		},
+		{
+			name: "service name",
+			opts: []Option{WithAddr(":80"), WithAddr(":http")},
+			err:  `duplicate listener: tcp address ":http" conflicts with ":80"`,
+		},
+		{
+			name: "fallback address",
+			opts: []Option{WithAddr(":http"), WithServer(&http.Server{})},
+			err:  `duplicate listener: tcp address ":http" conflicts with ":http"`,
+		},
+		{
+			name: "admin address",
+			opts: []Option{WithAddr(":8105"), WithAdmin(AdminConfig{Addr: ":8105", Token: "token"})},
+			err:  `duplicate listener: tcp address ":8105" conflicts with ":8105"`,
+		},
+		{
+			name: "unix socket",
+			opts: []Option{WithControlSocket("/tmp/graceful.sock"), WithUnix("/tmp/../tmp/graceful.sock")},
+			err:  `duplicate listener: unix address "/tmp/graceful.sock" conflicts with "/tmp/graceful.sock"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, tt.opts...)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestDistinctListeners(t *testing.T) {
+	router, err := New(nil,
+		WithAddr("127.0.0.1:8105"),
+		WithAddr("127.0.0.2:8105"),
+		WithAddr(":8106"),
+		WithAddr(":0"),
+		WithAddr(":0"),
+	)
+	assert.NoError(t, err)
+	router.Close()
+}
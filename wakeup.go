@@ -0,0 +1,54 @@
+package graceful
+
+import "sync"
+
+// RegisterWakeup registers fn to be invoked once the drain starts, so that a long-poll handler
+// can return early, e.g. with an empty response asking the client to retry, instead of holding
+// the shutdown until its poll times out. fn is invoked right away if the instance is already
+// draining. The returned function unregisters fn, and must be called once the handler returns.
+func (g *Graceful) RegisterWakeup(fn func()) func() {
+	w := &wakeup{fn: fn}
+
+	g.lock.Lock()
+	if g.wakeups == nil {
+		g.wakeups = map[*wakeup]struct{}{}
+	}
+	g.wakeups[w] = struct{}{}
+	g.lock.Unlock()
+
+	// A drain started while registering wakes the handler up right away.
+	if g.draining.Load() {
+		w.wake()
+	}
+
+	return func() {
+		g.lock.Lock()
+		delete(g.wakeups, w)
+		g.lock.Unlock()
+	}
+}
+
+// wakeUp invokes every registered wakeup function.
+func (g *Graceful) wakeUp() {
+	g.lock.Lock()
+	wakeups := make([]*wakeup, 0, len(g.wakeups))
+	for w := range g.wakeups {
+		wakeups = append(wakeups, w)
+	}
+	g.lock.Unlock()
+
+	for _, w := range wakeups {
+		w.wake()
+	}
+}
+
+// wakeup is a function registered with RegisterWakeup.
+type wakeup struct {
+	fn   func()
+	once sync.Once
+}
+
+// wake invokes the function, at most once.
+func (w *wakeup) wake() {
+	w.once.Do(w.fn)
+}
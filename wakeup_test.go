@@ -0,0 +1,56 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterWakeup(t *testing.T) {
+	router, err := Default(WithAddr(":8110"))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/poll", func(c *gin.Context) {
+		woken := make(chan struct{})
+		defer router.RegisterWakeup(func() { close(woken) })()
+
+		select {
+		case <-woken:
+			c.Status(http.StatusNoContent)
+		case <-time.After(10 * time.Second):
+			c.String(http.StatusOK, "event")
+		}
+	})
+
+	go func() {
+		assert.NoError(t, router.RunWithContext(context.Background()))
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	polled := make(chan int)
+	go func() {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:8110/poll", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			polled <- 0
+			return
+		}
+		resp.Body.Close()
+		polled <- resp.StatusCode
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	assert.NoError(t, router.Shutdown(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusNoContent, <-polled)
+
+	woken := false
+	router.RegisterWakeup(func() { woken = true })()
+	assert.True(t, woken)
+}
@@ -0,0 +1,64 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithWarmup configure a function run once every listener is bound, before the instance
+// reports itself as ready. It lets caches and other lazy initialization complete before
+// traffic is routed to the instance. If fn fails or does not complete within timeout, every
+// server is shut down and the error is returned by RunWithContext. A zero timeout means no limit.
+func WithWarmup(fn func(ctx context.Context) error, timeout time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		g.warmup = fn
+		g.warmupTimeout = timeout
+		return nil, donothing, nil
+	})
+}
+
+// warmUp waits for every server not started after the warmup to be bound, then runs the
+// configured warmup function and marks the instance as warm. A failed warmup cancels the run.
+func (g *Graceful) warmUp(ctx context.Context, cancel context.CancelFunc, servers []*managedServer) error {
+	if g.warmup == nil {
+		g.markWarm()
+		return nil
+	}
+
+	for _, s := range servers {
+		if s.afterWarmup {
+			continue
+		}
+		select {
+		case <-s.startedChan():
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	warmupCtx := ctx
+	if g.warmupTimeout > 0 {
+		var cancelWarmup context.CancelFunc
+		warmupCtx, cancelWarmup = context.WithTimeout(ctx, g.warmupTimeout)
+		defer cancelWarmup()
+	}
+
+	if err := g.warmup(warmupCtx); err != nil {
+		g.log().Error("warmup failed", "error", err)
+		cancel()
+		return fmt.Errorf("warmup: %w", err)
+	}
+
+	g.markWarm()
+	return nil
+}
+
+// markWarm marks the instance as warm and releases the servers started after the warmup.
+func (g *Graceful) markWarm() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.warm.Store(true)
+	close(g.warmed)
+}
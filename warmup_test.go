@@ -0,0 +1,76 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWarmup(t *testing.T) {
+	release := make(chan struct{})
+	var statuses []ServerStatus
+	var router *Graceful
+	router, err := Default(
+		WithAddr(":8087"),
+		WithHealthEndpoints(),
+		WithWarmup(func(ctx context.Context) error {
+			statuses = router.ServerStatus()
+			<-release
+			return nil
+		}, time.Second),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	router.GET("/example", func(c *gin.Context) { c.String(http.StatusOK, "it worked") })
+
+	assert.NoError(t, router.Start())
+	testRequest(t, "http://localhost:8087/example")
+
+	code, body := probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "warming up", body["servers"])
+
+	close(release)
+	time.Sleep(10 * time.Millisecond)
+
+	code, _ = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	assert.NoError(t, router.Stop())
+	if assert.Len(t, statuses, 1) {
+		assert.NotEqual(t, ServerIdle, statuses[0].State)
+	}
+}
+
+func TestWithWarmupFailure(t *testing.T) {
+	router, err := Default(
+		WithAddr(":8087"),
+		WithWarmup(func(ctx context.Context) error {
+			return errors.New("cache unavailable")
+		}, time.Second),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.EqualError(t, router.RunWithContext(context.Background()), "warmup: cache unavailable")
+}
+
+func TestWithWarmupTimeout(t *testing.T) {
+	router, err := Default(
+		WithAddr(":8087"),
+		WithWarmup(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, 10*time.Millisecond),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.ErrorIs(t, router.RunWithContext(context.Background()), context.DeadlineExceeded)
+}
@@ -0,0 +1,39 @@
+package graceful
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// exit exits the process, replaced in the tests.
+var exit = os.Exit
+
+// WithShutdownWatchdog configure a watchdog exiting the process with exitCode if the shutdown of
+// a running instance has not completed d after it started, as a last resort against a hook or a
+// drain that hangs regardless of its context. The watchdog is a timer of its own, so that it
+// fires whatever the shutdown is blocked on.
+func WithShutdownWatchdog(d time.Duration, exitCode int) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		if d <= 0 {
+			return nil, donothing, errors.New("invalid watchdog timeout")
+		}
+		g.watchdog = d
+		g.watchdogExitCode = exitCode
+		return nil, donothing, nil
+	})
+}
+
+// startWatchdog starts the shutdown watchdog, if configured, and returns the function stopping it
+// once the shutdown completed.
+func (g *Graceful) startWatchdog(reason string) func() {
+	if g.watchdog <= 0 {
+		return donothing
+	}
+	timer := time.AfterFunc(g.watchdog, func() {
+		g.log().Error("shutdown watchdog expired, exiting", "reason", reason, "timeout", g.watchdog,
+			"code", g.watchdogExitCode)
+		exit(g.watchdogExitCode)
+	})
+	return func() { timer.Stop() }
+}
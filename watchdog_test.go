@@ -0,0 +1,51 @@
+package graceful
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithShutdownWatchdog(t *testing.T) {
+	codes := make(chan int, 1)
+	exit = func(code int) { codes <- code }
+	defer func() { exit = os.Exit }()
+
+	release := make(chan struct{})
+	router, err := Default(
+		WithAddr("127.0.0.1:8153"),
+		WithShutdownWatchdog(50*time.Millisecond, 3),
+		WithBeforeShutdown("hang", func(context.Context) error {
+			<-release
+			return nil
+		}),
+	)
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- router.Shutdown(context.Background()) }()
+	select {
+	case code := <-codes:
+		assert.Equal(t, 3, code)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not exit")
+	}
+	close(release)
+	assert.NoError(t, <-done)
+
+	// A watchdog stopped in time does not exit.
+	router.startWatchdog("shutdown")()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, codes)
+
+	_, err = Default(WithShutdownWatchdog(0, 1))
+	assert.EqualError(t, err, "invalid watchdog timeout")
+}
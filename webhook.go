@@ -0,0 +1,96 @@
+package graceful
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-contrib/graceful/lifecycle"
+)
+
+const (
+	// webhookAttempts is the maximum number of attempts to post the shutdown report.
+	webhookAttempts = 5
+	// webhookBackoff is the wait before the second attempt, doubled after every attempt.
+	webhookBackoff = 100 * time.Millisecond
+)
+
+// shutdownWebhook posts the shutdown reports to a URL.
+type shutdownWebhook struct {
+	url     string
+	timeout time.Duration
+}
+
+// WithShutdownWebhook configure a URL the JSON record of every shutdown of a running instance is
+// posted to, e.g. a deployment orchestrator or an audit service, once the hooks configured with
+// WithAfterShutdown ran. Every attempt waits at most timeout for the response, and the failed
+// attempts are retried with a backoff until the shutdown budget, or the deadline of the shutdown
+// context, runs out. A final failure is returned by the shutdown as a *HookError.
+func WithShutdownWebhook(rawURL string, timeout time.Duration) Option {
+	return optionFunc(func(g *Graceful) (listenAndServe, cleanup, error) {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, donothing, fmt.Errorf("invalid shutdown webhook url %q", rawURL)
+		}
+		if timeout <= 0 {
+			return nil, donothing, errors.New("invalid shutdown webhook timeout")
+		}
+		g.webhook = &shutdownWebhook{url: rawURL, timeout: timeout}
+		return nil, donothing, nil
+	})
+}
+
+// postReport posts report to the shutdown webhook until it succeeds, the attempts run out or ctx
+// is done.
+func (g *Graceful) postReport(ctx context.Context, report ShutdownReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return &HookError{Name: "webhook", Phase: "after shutdown", Err: err}
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err = g.webhook.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if attempt == webhookAttempts || !lifecycle.WaitBackoff(ctx, backoff) {
+			g.log().Error("shutdown webhook failed", "attempt", attempt, "error", err)
+			return &HookError{Name: "webhook", Phase: "after shutdown", Err: err}
+		}
+		g.log().Warn("shutdown webhook failed, retrying", "attempt", attempt, "error", err)
+		backoff *= 2
+	}
+}
+
+// post posts body to the webhook, waiting at most its timeout for the response.
+func (w *shutdownWebhook) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return postJSON(ctx, w.url, body)
+}
+
+// postJSON posts the JSON body to url, and fails on a non 2xx response.
+func postJSON(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package graceful
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithShutdownWebhook(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan ShutdownReport, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var report ShutdownReport
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		received <- report
+	}))
+	defer hook.Close()
+
+	router, err := Default(WithAddr("127.0.0.1:8140"), WithShutdownWebhook(hook.URL, time.Second))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	assert.NoError(t, router.Shutdown(context.Background()))
+
+	report := <-received
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, "shutdown", report.Reason)
+	if assert.Len(t, report.Phases, 1) {
+		assert.Equal(t, "drain 127.0.0.1:8140", report.Phases[0].Name)
+	}
+}
+
+func TestShutdownWebhookBudget(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer hook.Close()
+
+	router, err := Default(WithAddr("127.0.0.1:8140"), WithShutdownWebhook(hook.URL, time.Second))
+	assert.NoError(t, err)
+	defer router.Close()
+
+	assert.NoError(t, router.Start())
+	assert.Eventually(t, func() bool { return router.ServerStatus()[0].State == ServerServing },
+		time.Second, 5*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = router.Shutdown(ctx)
+	assert.Less(t, time.Since(start), time.Second)
+
+	var hookErr *HookError
+	if assert.ErrorAs(t, err, &hookErr) {
+		assert.Equal(t, "webhook", hookErr.Name)
+		assert.EqualError(t, hookErr.Err, "unexpected status 503 Service Unavailable")
+	}
+
+	_, err = Default(WithShutdownWebhook("localhost:8080", time.Second))
+	assert.EqualError(t, err, `invalid shutdown webhook url "localhost:8080"`)
+	_, err = Default(WithShutdownWebhook(hook.URL, 0))
+	assert.EqualError(t, err, "invalid shutdown webhook timeout")
+}